@@ -3,9 +3,12 @@ package config
 import (
 	"context"
 	_ "embed"
+	"encoding/json"
 	"errors"
 	"flag"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -38,6 +41,14 @@ type Config struct {
 		SSLCiphers      string   `yaml:"ssl_ciphers"`      // 支持的TLS加密算法
 		SSLECDHCurve    string   `yaml:"ssl_ecdh_curve"`   // 支持的TLS曲线
 		MulticastIfaces []string `yaml:"multicast_ifaces"` // 多播网卡列表
+
+		MulticastLocalAddr string `yaml:"multicast_local_addr"` // 组播监听绑定的本地 IP（优先于网卡列表）
+		DSCP               int    `yaml:"dscp"`                 // 输出连接的 DSCP 标记 (0-63，0 = 不标记)
+		H2C                bool   `yaml:"h2c"`                  // 未配置证书时允许明文 HTTP/2 (h2c prior knowledge)
+		H2MaxStreams       uint32 `yaml:"h2_max_streams"`       // 单个 HTTP/2 连接最大并发流数 (0 = 默认 250)
+
+		ClientIPHeaders []string `yaml:"client_ip_headers"` // 识别客户端 IP 的请求头，按顺序查找 (为空 = X-Forwarded-For、X-Real-IP)
+		TrustedProxies  []string `yaml:"trusted_proxies"`   // 仅当来源地址属于这些 IP/网段时才采信上述请求头 (为空 = 全部采信)
 	} `yaml:"server"`
 
 	Log struct {
@@ -65,9 +76,37 @@ type Config struct {
 	} `yaml:"http"`
 
 	Monitor struct {
-		Path string `yaml:"path"` // 监控路径
+		Path          string  `yaml:"path"`            // 监控路径
+		FDWarnPercent float64 `yaml:"fd_warn_percent"` // 文件描述符使用率告警阈值(%)
+
+		DisableAutoRefresh bool   `yaml:"disable_auto_refresh"` // 状态页不输出自动刷新脚本（也可用 ?static=1）
+		JSONNaming         string `yaml:"json_naming"`          // 状态 JSON 字段命名：legacy（默认，Go 字段名）/ snake
+		MaxConcurrent      int    `yaml:"max_concurrent"`       // 监控接口最大并发处理数 (负数 = 不限制)
+
+		BandwidthInterval time.Duration `yaml:"bandwidth_interval"` // 系统统计/带宽采样间隔 (最小 1s)
+		StateFile         string        `yaml:"state_file"`         // 累计计数持久化文件 (JSON，为空不持久化)
+		StateInterval     time.Duration `yaml:"state_interval"`     // 持久化保存间隔
+		CacheControl      string        `yaml:"cache_control"`      // 状态/JSON 响应的 Cache-Control，默认 no-store
+		RecentSessions    int           `yaml:"recent_sessions"`    // 保留最近结束的客户端会话数 (0 = 默认 50，负数 = 关闭)
+
+		MinRefreshInterval time.Duration   `yaml:"min_refresh_interval"` // 状态页最小刷新间隔，服务端按客户端 IP 强制 (默认 3s)
+		RefreshIntervals   []time.Duration `yaml:"refresh_intervals"`    // 状态页可选的自动刷新间隔，小于最小间隔的项被忽略
+
+		ExcludeIfaces []string `yaml:"exclude_ifaces"` // 网卡列表/指标中排除的网卡名（支持通配符，如 lo、veth*、docker*），不影响总流量
+
+		ServerTiming bool `yaml:"server_timing"` // 调试：状态页响应附带 Server-Timing 头（统计采集、配置拷贝、模板渲染耗时）
 	} `yaml:"monitor"`
 
+	Stream StreamConfig `yaml:"stream"` // UDP/组播流转发配置
+
+	Channels []*ChannelConfig `yaml:"channels"` // 频道路由表：HTTP 路径 → 组播源
+
+	TCPOutputs []*TCPOutputConfig `yaml:"tcp_outputs"` // 裸 TS over TCP 输出：监听端口 → 频道
+
+	Maintenance MaintenanceConfig `yaml:"maintenance"` // 维护模式：新请求返回 503 维护提示
+
+	SignedURL SignedURLConfig `yaml:"signed_url"` // 限时签名链接：流请求须带 expires 与 sig
+
 	Web struct {
 		Enabled  bool   `yaml:"enabled"`  // 启用Web管理界面
 		Username string `yaml:"username"` // Web管理用户名
@@ -81,10 +120,159 @@ type Config struct {
 	DomainMap []*DomainMapConfig `yaml:"domainmap"`
 
 	ProxyGroups map[string]*ProxyGroupConfig `yaml:"proxygroups"` // 代理组配置
+	ProxyDNS    ProxyDNSConfig               `yaml:"proxy_dns"`   // 代理主机名解析缓存
+	Recording   RecordingConfig              `yaml:"recording"`   // 按需录制频道到磁盘
 	JX          JXConfig                     `yaml:"jx"`          // 视频解析配置
 	Reload      int                          `yaml:"reload"`      // 添加 Reload 字段
 }
 
+// StreamConfig UDP/组播流转发配置
+type StreamConfig struct {
+	KeepaliveInterval  time.Duration `yaml:"keepalive_interval"`   // 源无数据时发送 TS 空包保活的间隔 (0 = 关闭)
+	JoinRetries        int           `yaml:"join_retries"`         // 首次加入组播失败的重试次数 (0 = 不重试)
+	JoinRetryDelay     time.Duration `yaml:"join_retry_delay"`     // 首次重试间隔，之后指数退避，最长 10s
+	ReadDeadline       time.Duration `yaml:"read_deadline"`        // UDP 读超时间隔，防止读操作永久阻塞 (默认 5s，负数表示不设置)
+	JitterBufferFrames int           `yaml:"jitter_buffer_frames"` // 每个 Hub 的抖动缓冲帧数，平滑源端短暂停顿 (0 = 关闭)
+	LogClientChurn     bool          `yaml:"log_client_churn"`     // 记录每次客户端加入/离开日志 (默认关闭)
+	FullChannelPolicy  string        `yaml:"full_channel_policy"`  // 客户端通道满时的策略：drop-newest/drop-oldest/block-with-deadline/disconnect
+	BlockTimeout       time.Duration `yaml:"block_timeout"`        // block-with-deadline 策略单帧最长等待时间
+	WriteTimeout       time.Duration `yaml:"write_timeout"`        // 向客户端写入单帧的超时，超时断开客户端
+	IdleTimeout        time.Duration `yaml:"idle_timeout"`         // 客户端持续收不到数据的超时（启用保活时不生效）
+	InitialDataTimeout time.Duration `yaml:"initial_data_timeout"` // 客户端加入后首帧的最长等待时间，超时返回 504 (0 = 关闭)
+	ErrorBackoff       time.Duration `yaml:"error_backoff"`        // UDP 读错误（非超时）后的重试间隔
+	SubscribeTimeout   time.Duration `yaml:"subscribe_timeout"`    // 客户端加入/退出 Hub 的最长等待时间
+	WriteBufferSize    int           `yaml:"write_buffer_size"`    // 客户端写合并缓冲字节数 (0 = 逐帧写入)
+	FlushInterval      time.Duration `yaml:"flush_interval"`       // 写合并的最长等待时间
+	BroadcastWorkers   int           `yaml:"broadcast_workers"`    // 每个 Hub 并行广播的工作协程数 (0 = 串行，上限 64)
+	MaxConnsPerIP      int           `yaml:"max_conns_per_ip"`     // 单个客户端 IP 最大并发流连接数 (0 = 不限制)
+	PSIReplay          bool          `yaml:"psi_replay"`           // 缓存最近的 PAT/PMT 并在新客户端加入时先行发送
+	ClientChecksum     bool          `yaml:"client_checksum"`      // 为每个客户端维护最近 N 帧的滚动 CRC32（调试用）
+	ChecksumFrames     int           `yaml:"checksum_frames"`      // 滚动校验窗口帧数
+	MaxOutboundMbps    float64       `yaml:"max_outbound_mbps"`    // 系统总出口带宽上限 (Mbps，0 = 不限制)
+	BandwidthPolicy    string        `yaml:"bandwidth_policy"`     // 超限策略：reject（拒绝新连接）/ shed（并关闭观众最少的频道）
+
+	EndOfStream map[string]string `yaml:"end_of_stream"` // Hub 关闭时按 Content-Type 的收尾动作：none/null/trailer/null+trailer（"*" 为默认）
+
+	AllInterfaces bool `yaml:"all_interfaces"` // 在全部（或 ifaces 指定的）网卡上同时加入组播并去重
+	DedupWindow   int  `yaml:"dedup_window"`   // 去重窗口包数 (0 = 默认 1024，上限 65536)
+
+	ReloadGrace time.Duration `yaml:"reload_grace"` // 配置重载后保留无客户端 Hub 的宽限期，避免重连时反复加入组播 (0 = 关闭)
+
+	ReadSockets int `yaml:"read_sockets"` // 单播源以 SO_REUSEPORT 打开的并行接收套接字数 (0/1 = 单套接字，上限 16)
+
+	RTPReorderDepth   int           `yaml:"rtp_reorder_depth"`   // RTP 序号重排缓冲包数 (0 = 关闭，上限 1024)，裸 TS 不受影响
+	RTPReorderTimeout time.Duration `yaml:"rtp_reorder_timeout"` // 缺口最长等待时间，超时跳过缺口 (默认 50ms)
+
+	HubSettingsFile string `yaml:"hub_settings_file"` // 管理接口调整的频道参数持久化文件 (JSON，为空不持久化)
+
+	LockStats bool `yaml:"lock_stats"` // 统计每个 Hub 互斥锁的加锁次数与竞争等待（调试用，见 /debug/hubs）
+
+	MaxMemoryMB  int    `yaml:"max_memory_mb"` // 内存超过该值 (MB) 时拒绝新的流连接 (0 = 不限制)
+	MemorySource string `yaml:"memory_source"` // 内存计量来源：heap（HeapInuse，默认）/ rss（进程常驻内存）
+
+	ReadThreadNice int `yaml:"read_thread_nice"` // 高优先级频道接收线程的 nice 值 (-20~19，0 = 不调整，仅 Linux，负值需 CAP_SYS_NICE)
+
+	IfaceFailover time.Duration `yaml:"iface_failover"` // 组播按 ifaces 顺序作为优先级，当前网卡无数据超过该时长切到下一块 (0 = 关闭)
+	IfaceFailback time.Duration `yaml:"iface_failback"` // 探测更高优先级网卡是否恢复的间隔，恢复后切回 (默认 30s)
+
+	SlateFile    string        `yaml:"slate_file"`    // 源中断时循环广播的 TS 垫片文件（黑场/提示画面），为空不启用
+	SlateAfter   time.Duration `yaml:"slate_after"`   // 源无数据超过该时长开始播放垫片 (默认 5s)
+	SlateBitrate int           `yaml:"slate_bitrate"` // 垫片发送码率 (kbps，默认 2000)
+
+	StrictIfaces bool `yaml:"strict_ifaces"` // 组播源配置的网卡全部不存在时拒绝创建 Hub（返回错误），默认回退普通 UDP 并在监控页告警
+}
+
+// ChannelConfig 频道路由配置，将固定的 HTTP 路径映射到组播源
+type ChannelConfig struct {
+	Path        string   `yaml:"path"`         // HTTP 路径，例如 /live/cctv1
+	UDPAddr     string   `yaml:"udp_addr"`     // 组播/单播源地址 ip:port
+	Ifaces      []string `yaml:"ifaces"`       // 监听网卡，为空时使用 server.multicast_ifaces
+	LocalAddr   string   `yaml:"local_addr"`   // 本地绑定地址，为空时使用 server.multicast_local_addr
+	ContentType string   `yaml:"content_type"` // 响应 Content-Type，默认 video/mp2t
+	Tags        []string `yaml:"tags"`         // 分组标签，例如 HD、SD、news，用于监控页面分组与筛选
+
+	MaxMbps    float64 `yaml:"max_mbps"`    // 每个客户端的输出速率上限 (Mbps，0 = 不限制)
+	RatePolicy string  `yaml:"rate_policy"` // 超出上限时的处理：pace（延迟输出，默认）/ drop（丢弃）
+
+	Mode string `yaml:"mode"` // 监听模式：空（默认，先加入组播，失败回退普通 UDP）/ unicast（只绑定端口接收单播）
+
+	Priority string `yaml:"priority"` // 接收优先级：空（默认）/ high（接收协程独占 OS 线程，可配合 stream.read_thread_nice）
+
+	Token       string `yaml:"token"`        // 访问令牌，非空时请求须通过查询参数或请求头携带，否则返回 403
+	TokenParam  string `yaml:"token_param"`  // 令牌查询参数名 (默认 token)
+	TokenHeader string `yaml:"token_header"` // 令牌请求头名 (默认 X-Channel-Token)
+
+	Preview PreviewConfig `yaml:"preview"` // 低码率预览变体（?variant=preview），由 ffmpeg 按需转码
+}
+
+// PreviewConfig 频道预览变体配置，只在有预览观众时运行 ffmpeg
+type PreviewConfig struct {
+	Enabled bool   `yaml:"enabled"` // 启用预览变体
+	Width   int    `yaml:"width"`   // 输出宽度，高度按比例 (默认 320)
+	Bitrate int    `yaml:"bitrate"` // 视频码率 (kbps，默认 300)
+	FFmpeg  string `yaml:"ffmpeg"`  // ffmpeg 可执行文件路径 (默认从 PATH 查找)
+}
+
+// ChannelPriorityHigh 高优先级频道：接收协程独占 OS 线程
+const ChannelPriorityHigh = "high"
+
+const (
+	// ListenModeUnicast 单播监听模式：只绑定端口，不加入组播
+	ListenModeUnicast = "unicast"
+	// UnicastScheme 单播源地址前缀，带该前缀的源地址（及 HubKey）表示单播监听
+	UnicastScheme = "unicast://"
+)
+
+// WithListenMode 按监听模式返回源地址：unicast 模式加上 unicast:// 前缀，其他模式原样返回
+func WithListenMode(addr, mode string) string {
+	if strings.EqualFold(strings.TrimSpace(mode), ListenModeUnicast) && !strings.HasPrefix(addr, UnicastScheme) &&
+		!strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		return UnicastScheme + addr
+	}
+	return addr
+}
+
+// SourceAddr 返回频道按监听模式处理后的源地址，即创建 Hub 使用的地址
+func (c *ChannelConfig) SourceAddr() string {
+	return WithListenMode(c.UDPAddr, c.Mode)
+}
+
+// TokenChannelForSource 返回以 addr 为源且配置了访问令牌的频道（忽略单播前缀），没有时返回 nil；
+// 同一个源无论经频道路由、/udp/ 还是 TCP 输出访问都受该令牌保护。调用方需持有 CfgMu
+func (c *Config) TokenChannelForSource(addr string) *ChannelConfig {
+	addr = strings.TrimPrefix(addr, UnicastScheme)
+	for _, ch := range c.Channels {
+		if ch != nil && ch.Token != "" && strings.TrimPrefix(ch.UDPAddr, UnicastScheme) == addr {
+			return ch
+		}
+	}
+	return nil
+}
+
+// MaintenanceConfig 维护模式配置，也可通过管理接口临时切换
+type MaintenanceConfig struct {
+	Enabled    bool          `yaml:"enabled"`     // 开启维护模式
+	Message    string        `yaml:"message"`     // 维护提示文字
+	RetryAfter time.Duration `yaml:"retry_after"` // 503 响应的 Retry-After (默认 5m)
+}
+
+// SignedURLConfig 限时签名链接配置，secret 为空时不校验
+type SignedURLConfig struct {
+	Secret     string        `yaml:"secret"`      // HMAC-SHA256 密钥
+	DefaultTTL time.Duration `yaml:"default_ttl"` // 管理端生成链接的默认有效期 (默认 1h)
+}
+
+// TCPOutputConfig 裸 TCP 输出配置，客户端连接监听端口后直接接收 TS 数据（无 HTTP 头）
+type TCPOutputConfig struct {
+	Listen    string   `yaml:"listen"`     // 监听地址，例如 :9001
+	Channel   string   `yaml:"channel"`    // 频道路由路径（channels 中的 path），优先于 udp_addr
+	UDPAddr   string   `yaml:"udp_addr"`   // 直接指定源地址
+	Ifaces    []string `yaml:"ifaces"`     // 监听网卡，为空时使用 server.multicast_ifaces
+	LocalAddr string   `yaml:"local_addr"` // 本地绑定地址，为空时使用 server.multicast_local_addr
+
+	Public bool `yaml:"public"` // 允许无凭据输出受频道令牌或签名链接保护的源（裸 TCP 无法携带凭据）
+}
+
 // DomainMapConfig 域名映射配置结构
 type DomainMapConfig struct {
 	Name          string            `yaml:"name"`           // 配置名称
@@ -170,6 +358,21 @@ type ProxyConfig struct {
 	Headers  map[string]string `yaml:"headers"`  // 添加自定义headers支持
 }
 
+// ProxyDNSConfig 代理服务器主机名解析缓存配置
+type ProxyDNSConfig struct {
+	Enabled  bool          `yaml:"enabled"`   // 启用解析缓存
+	TTL      time.Duration `yaml:"ttl"`       // 解析结果缓存时间
+	ErrorTTL time.Duration `yaml:"error_ttl"` // 解析失败后的重试间隔
+}
+
+// RecordingConfig 按需录制配置，录制由管理接口触发
+type RecordingConfig struct {
+	Dir         string        `yaml:"dir"`          // 录制文件目录
+	MaxDuration time.Duration `yaml:"max_duration"` // 单次录制最长时间，请求未指定时长时也使用该值
+	MaxFileMB   int64         `yaml:"max_file_mb"`  // 单个文件大小上限 (MB)，超过后切换到新文件
+	MaxFiles    int           `yaml:"max_files"`    // 每次录制最多保留的文件数，超过后删除最旧文件 (0 = 不限制)
+}
+
 // ProxyStats 代理统计信息
 type ProxyStats struct {
 	LastCheck     time.Time     // 仅测速时更新
@@ -177,8 +380,61 @@ type ProxyStats struct {
 	ResponseTime  time.Duration // 响应时间
 	Alive         bool          // 代理是否可用
 	FailCount     int           // 测速失败次数
+	LastSuccess   time.Time     // 最近一次测速/请求成功的时间
+	LastFailure   time.Time     // 最近一次测速/请求失败的时间
 	CooldownUntil time.Time     // 冷却时间，防止频繁重试
+	ActiveConns   atomic.Int64  `json:"-"` // 当前活跃连接数，见 ActiveConnCount
 	StatusCode          int           // 测试返回状态码（HTTP/自定义）
+
+	BytesTransferred atomic.Uint64 `json:"-"` // 经该代理转发的响应字节数，见 TransferredBytes
+}
+
+// ActiveConnCount 返回代理当前活跃连接数
+func (s *ProxyStats) ActiveConnCount() int64 {
+	return s.ActiveConns.Load()
+}
+
+// ProxyStatsSnapshot ProxyStats 的序列化形式，原子计数器以普通数值输出
+type ProxyStatsSnapshot struct {
+	LastCheck     time.Time
+	LastUsed      time.Time
+	ResponseTime  time.Duration
+	Alive         bool
+	FailCount     int
+	LastSuccess   time.Time
+	LastFailure   time.Time
+	CooldownUntil time.Time
+	ActiveConns   int64
+	StatusCode    int
+
+	BytesTransferred uint64
+}
+
+// JSONSnapshot 返回用于状态输出的快照，snake_case 输出据此展开字段
+func (s *ProxyStats) JSONSnapshot() any {
+	return ProxyStatsSnapshot{
+		LastCheck:     s.LastCheck,
+		LastUsed:      s.LastUsed,
+		ResponseTime:  s.ResponseTime,
+		Alive:         s.Alive,
+		FailCount:     s.FailCount,
+		LastSuccess:   s.LastSuccess,
+		LastFailure:   s.LastFailure,
+		CooldownUntil: s.CooldownUntil,
+		ActiveConns:   s.ActiveConnCount(),
+		StatusCode:    s.StatusCode,
+
+		BytesTransferred: s.TransferredBytes(),
+	}
+}
+
+func (s *ProxyStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.JSONSnapshot())
+}
+
+// TransferredBytes 返回经该代理转发的累计字节数
+func (s *ProxyStats) TransferredBytes() uint64 {
+	return s.BytesTransferred.Load()
 }
 
 // 全局定义测速结果结构体
@@ -283,6 +539,80 @@ func (c *Config) SetDefaults() {
 	if c.HTTP.MaxConnsPerHost == 0 {
 		c.HTTP.MaxConnsPerHost = 8
 	}
+
+	// 流转发默认值
+	if c.Stream.JoinRetryDelay <= 0 {
+		c.Stream.JoinRetryDelay = time.Second
+	}
+	if c.Stream.ReadDeadline == 0 {
+		c.Stream.ReadDeadline = 5 * time.Second
+	}
+	if c.Stream.FullChannelPolicy == "" {
+		c.Stream.FullChannelPolicy = "drop-newest"
+	}
+	if c.Stream.BlockTimeout <= 0 {
+		c.Stream.BlockTimeout = 20 * time.Millisecond
+	}
+	if c.Stream.ChecksumFrames <= 0 {
+		c.Stream.ChecksumFrames = 32
+	}
+	if c.Stream.WriteTimeout <= 0 {
+		c.Stream.WriteTimeout = 5 * time.Second
+	}
+	if c.Stream.IdleTimeout <= 0 {
+		c.Stream.IdleTimeout = 30 * time.Second
+	}
+	if c.Stream.ErrorBackoff <= 0 {
+		c.Stream.ErrorBackoff = 100 * time.Millisecond
+	} else if c.Stream.ErrorBackoff > 5*time.Second {
+		c.Stream.ErrorBackoff = 5 * time.Second
+	}
+	if c.Stream.SubscribeTimeout <= 0 {
+		c.Stream.SubscribeTimeout = 2 * time.Second
+	}
+
+	// 录制默认值
+	if c.Recording.Dir == "" {
+		c.Recording.Dir = "recordings"
+	}
+	if c.Recording.MaxDuration <= 0 {
+		c.Recording.MaxDuration = 2 * time.Hour
+	}
+	if c.Recording.MaxFileMB <= 0 {
+		c.Recording.MaxFileMB = 512
+	}
+
+	// 频道路由默认值
+	for _, ch := range c.Channels {
+		if ch == nil {
+			continue
+		}
+		if ch.ContentType == "" {
+			ch.ContentType = "video/mp2t"
+		}
+	}
+
+	// 监控默认值
+	if c.Monitor.FDWarnPercent <= 0 {
+		c.Monitor.FDWarnPercent = 80
+	}
+	if c.Monitor.MaxConcurrent == 0 {
+		c.Monitor.MaxConcurrent = 4
+	}
+	if c.Monitor.CacheControl == "" {
+		c.Monitor.CacheControl = "no-store"
+	}
+	if c.Monitor.BandwidthInterval <= 0 {
+		c.Monitor.BandwidthInterval = 10 * time.Second
+	} else if c.Monitor.BandwidthInterval < time.Second {
+		c.Monitor.BandwidthInterval = time.Second
+	}
+	if c.Monitor.MinRefreshInterval <= 0 {
+		c.Monitor.MinRefreshInterval = 3 * time.Second
+	}
+	if len(c.Monitor.RefreshIntervals) == 0 {
+		c.Monitor.RefreshIntervals = []time.Duration{3 * time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second}
+	}
 }
 
 // InitStartTime 初始化程序启动时间
@@ -291,3 +621,16 @@ func InitStartTime() {
 		StartTime = time.Now()
 	})
 }
+
+// loaded 配置文件首次成功加载并应用到 Cfg 后置位，加载失败不会置位
+var loaded atomic.Bool
+
+// MarkLoaded 标记配置已成功应用，由配置加载流程在替换 Cfg 之后调用
+func MarkLoaded() {
+	loaded.Store(true)
+}
+
+// Loaded 配置是否已完成首次成功加载；之前到达的请求应返回 503
+func Loaded() bool {
+	return loaded.Load()
+}
@@ -47,6 +47,7 @@ func LoadConfig(configPath string) error {
 
 	// 初始化统计结构
 	groupstats.InitProxyGroups()
+	config.MarkLoaded()
 
 	// 打印基本加载信息
 	logger.LogPrintf("✅ 配置文件已加载，代理组数量: %d", len(config.Cfg.ProxyGroups))
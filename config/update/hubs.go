@@ -5,25 +5,41 @@ import (
 	"time"
 
 	"github.com/qist/tvgate/logger"
+	"github.com/qist/tvgate/monitor"
 	"github.com/qist/tvgate/stream"
 )
 
-// UpdateHubsOnConfigChange 根据配置变更更新Hubs
+// UpdateHubsOnConfigChange 根据配置变更更新Hubs：使用全局默认网卡的 Hub 切换到 newIfaces，
+// 网卡取自频道的 Hub 按新的频道表重新解析，请求显式指定网卡的 Hub 保持不变。调用方需持有 config.CfgMu 读锁
 func UpdateHubsOnConfigChange(newIfaces []string) {
 	// logger.LogPrintf("✅ 配置文件重新加载完成")
 
+	// 先复制 Hub 列表再读取各 Hub 的网卡来源，避免持有 HubsMu 时获取 Hub 自身的锁
 	stream.HubsMu.Lock()
+	hubs := make(map[string]*stream.StreamHub, len(stream.Hubs))
+	for key, hub := range stream.Hubs {
+		hubs[key] = hub
+	}
+	stream.HubsMu.Unlock()
+
 	var pairs []struct {
 		oldKey string
 		oldHub *stream.StreamHub
 		addr   string
 		newKey string
+		ifaces []string
+		origin stream.HubIfaceOrigin
 	}
-	for key, hub := range stream.Hubs {
+	for key, hub := range hubs {
 		parts := strings.SplitN(key, "|", 2)
 		addr := parts[0]
-		newKey := stream.HubKey(addr, newIfaces)
+		ifaces, origin, ok := stream.ReresolveIfaces(addr, hub.IfaceOrigin(), newIfaces)
+		if !ok {
+			continue
+		}
+		newKey := stream.HubKey(addr, ifaces, hub.LocalAddr)
 		if key == newKey {
+			hub.SetIfaceOrigin(origin)
 			continue
 		}
 		pairs = append(pairs, struct {
@@ -31,23 +47,25 @@ func UpdateHubsOnConfigChange(newIfaces []string) {
 			oldHub *stream.StreamHub
 			addr   string
 			newKey string
-		}{key, hub, addr, newKey})
+			ifaces []string
+			origin stream.HubIfaceOrigin
+		}{key, hub, addr, newKey, ifaces, origin})
 	}
-	stream.HubsMu.Unlock()
 
 	for _, p := range pairs {
 		logger.LogPrintf("♻️ 零丢包更新组播监听：%s → %s", p.oldKey, p.newKey)
 
 		// 直接在旧Hub上更新网络接口，而不是创建新Hub
-		if err := p.oldHub.UpdateInterfaces(p.addr, newIfaces); err != nil {
+		if err := p.oldHub.UpdateInterfaces(p.addr, p.ifaces); err != nil {
 			logger.LogPrintf("❌ 更新网络接口失败: %v", err)
 			
 			// 如果更新失败，尝试创建新Hub并迁移客户端
-			newHub, err := stream.NewStreamHub(p.addr, newIfaces)
+			newHub, err := stream.NewStreamHub(p.addr, p.ifaces, p.oldHub.LocalAddr)
 			if err != nil {
 				logger.LogPrintf("❌ 创建新 Hub 失败: %v", err)
 				continue
 			}
+			newHub.SetIfaceOrigin(p.origin)
 
 			// 预热：等待新 Hub 收到第一帧
 			time.Sleep(500 * time.Millisecond)
@@ -64,6 +82,7 @@ func UpdateHubsOnConfigChange(newIfaces []string) {
 			delete(stream.Hubs, p.oldKey)
 			stream.Hubs[p.newKey] = newHub
 			stream.HubsMu.Unlock()
+			monitor.ActiveClients.RekeyHub(p.oldKey, p.newKey)
 
 			// 延迟关闭旧 Hub
 			go func(oldKey string, oldHub *stream.StreamHub) {
@@ -73,10 +92,12 @@ func UpdateHubsOnConfigChange(newIfaces []string) {
 			}(p.oldKey, p.oldHub)
 		} else {
 			// 更新成功，直接更新Hubs映射中的键
+			p.oldHub.SetIfaceOrigin(p.origin)
 			stream.HubsMu.Lock()
 			delete(stream.Hubs, p.oldKey)
 			stream.Hubs[p.newKey] = p.oldHub
 			stream.HubsMu.Unlock()
+			monitor.ActiveClients.RekeyHub(p.oldKey, p.newKey)
 			logger.LogPrintf("✅ 成功更新网络接口: %s", p.newKey)
 		}
 	}
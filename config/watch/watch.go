@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/qist/tvgate/logger"
 	"github.com/qist/tvgate/monitor"
 	"github.com/qist/tvgate/server"
+	"github.com/qist/tvgate/stream"
 	httpclient "github.com/qist/tvgate/utils/http"
 	"github.com/qist/tvgate/web"
 )
@@ -114,10 +116,15 @@ func WatchConfigFile(configPath string) {
 				return
 			}
 			logger.LogPrintf("✅ 配置文件重新加载完成")
+			// 重载期间客户端可能短暂断开重连，宽限期内保留无客户端的 Hub
+			stream.BeginReloadGrace()
+			monitor.SyncMaintenanceConfig()
 			// 平滑更新多播网卡监听（零丢包）
 			config.CfgMu.RLock()
 			update.UpdateHubsOnConfigChange(config.Cfg.Server.MulticastIfaces)
 			config.CfgMu.RUnlock()
+			// 裸 TCP 输出监听随配置增删
+			stream.StartTCPOutputs()
 			// 添加监控路径处理
 			// 平滑替换 HTTP 服务
 			muxMu.Lock()
@@ -140,6 +147,9 @@ func WatchConfigFile(configPath string) {
 			}
 			client := httpclient.NewHTTPClient(&config.Cfg, nil)
 			newMux.Handle(monitorPath, server.SecurityHeaders(http.HandlerFunc(monitor.HandleMonitor)))
+			if !strings.HasSuffix(monitorPath, "/") {
+				newMux.Handle(monitorPath+"/", server.SecurityHeaders(http.HandlerFunc(monitor.HandleMonitor)))
+			}
 			// jx 路径
 			jxPath := config.Cfg.JX.Path
 			if jxPath == "" {
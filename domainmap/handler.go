@@ -629,6 +629,7 @@ func (dm *DomainMapper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		IP:             clientIP,
 		URL:            targetURL.String(),
 		UserAgent:      r.UserAgent(),
+		ClientProtocol: monitor.RequestProtocol(r),
 		ConnectionType: strings.ToUpper(targetURL.Scheme),
 		ConnectedAt:    time.Now(),
 		LastActive:     time.Now(),
@@ -656,6 +657,17 @@ func (dm *DomainMapper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// 管理员可通过查询参数为本次请求强制指定负载均衡策略或代理（测试用），参数不转发给后端
+	lbOverride, overrideErr := lb.ParseOverride(r)
+	r.Header.Del(lb.AdminAuthHeader)
+	if overrideErr != nil {
+		monitor.WriteError(w, r, http.StatusForbidden, overrideErr.Error())
+		return
+	}
+	if !lbOverride.IsZero() {
+		targetReqURL.RawQuery = lb.StripOverrideQuery(targetReqURL.RawQuery)
+	}
+
 	// -------- 使用动态 HTTP 配置创建 Transport 和 Client ----------
 	httpCfg := config.Cfg.HTTP
 	config.Cfg.SetDefaults()
@@ -697,10 +709,14 @@ func (dm *DomainMapper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		for attempt := 0; attempt <= maxRetries; attempt++ {
 			forceTest := attempt > 0
-			selectedProxy := lb.SelectProxy(pg, targetReqURL.String(), forceTest)
+			selectedProxy := lb.SelectProxyOverride(pg, targetReqURL.String(), forceTest, lbOverride)
 
 			clientToUse := client
+			release := func() {}
 			if selectedProxy != nil {
+				// 活跃连接计数：失败重试前释放，成功时持续到响应转发结束
+				release = lb.AcquireProxy(pg, selectedProxy)
+				defer release()
 				if proxyDialer, dErr := proxy.CreateProxyDialer(*selectedProxy); dErr == nil {
 					baseTransport.DialContext = proxyDialer.DialContext
 					clientToUse = &http.Client{
@@ -729,8 +745,12 @@ func (dm *DomainMapper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 			resp, err = dm.doWithRedirect(clientToUse, targetReq, 10, frontendScheme, r.Host)
 			if err == nil {
+				if selectedProxy != nil {
+					resp.Body = lb.CountProxyBytes(pg, selectedProxy, resp.Body)
+				}
 				break
 			}
+			release()
 			if attempt == maxRetries {
 				http.Error(w, fmt.Sprintf("代理请求失败: %v", err), http.StatusBadGateway)
 				return
@@ -79,6 +79,7 @@ func RtspToHTTPHandler(w http.ResponseWriter, r *http.Request) {
 		IP:             clientIP,
 		URL:            rtspURL,
 		UserAgent:      r.UserAgent(),
+		ClientProtocol: monitor.RequestProtocol(r),
 		ConnectionType: "RTSP",
 		ConnectedAt:    time.Now(),
 		LastActive:     time.Now(),
@@ -112,6 +113,7 @@ func RtspToHTTPHandler(w http.ResponseWriter, r *http.Request) {
 			if selectedProxy != nil {
 				proxyDialer, err := proxy.CreateProxyDialer(*selectedProxy)
 				if err == nil {
+					defer lb.AcquireProxy(pg, selectedProxy)()
 					client.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
 						return proxyDialer.DialContext(ctx, network, addr)
 					}
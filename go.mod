@@ -12,6 +12,7 @@ require (
 	github.com/quic-go/quic-go v0.54.0
 	github.com/shirou/gopsutil/v3 v3.24.5
 	golang.org/x/net v0.44.0
+	golang.org/x/sys v0.36.0
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 	gopkg.in/yaml.v3 v3.0.1
 	h12.io/socks v1.0.3
@@ -38,7 +39,6 @@ require (
 	golang.org/x/crypto v0.42.0 // indirect
 	golang.org/x/mod v0.27.0 // indirect
 	golang.org/x/sync v0.17.0 // indirect
-	golang.org/x/sys v0.36.0 // indirect
 	golang.org/x/text v0.29.0 // indirect
 	golang.org/x/tools v0.36.0 // indirect
 )
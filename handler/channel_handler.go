@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/stream"
+)
+
+// lookupChannel 在频道路由表中查找与请求路径完全匹配的频道，返回配置副本
+func lookupChannel(path string) (config.ChannelConfig, bool) {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+
+	path = strings.TrimSuffix(path, "/")
+	for _, ch := range config.Cfg.Channels {
+		if ch == nil || ch.Path == "" || ch.UDPAddr == "" {
+			continue
+		}
+		if strings.TrimSuffix(ch.Path, "/") == path {
+			c := *ch
+			c.Ifaces = append([]string(nil), ch.Ifaces...)
+			return c, true
+		}
+	}
+	return config.ChannelConfig{}, false
+}
+
+// ChannelHandler 按频道路由表分发请求到对应的组播 Hub，未匹配时返回 false
+func ChannelHandler(w http.ResponseWriter, r *http.Request) bool {
+	ch, ok := lookupChannel(r.URL.Path)
+	if !ok {
+		return false
+	}
+	if !checkSignedURL(w, r) || !checkGlobalToken(w, r) {
+		return true
+	}
+	// ?variant=preview 订阅低码率预览变体（channels[].preview），完整流仍供普通观众
+	if r.URL.Query().Get("variant") == stream.VariantPreview {
+		if !ch.Preview.Enabled {
+			http.Error(w, "Preview not enabled for this channel", http.StatusNotFound)
+			return true
+		}
+		serveUDPHub(w, r, ch.Path, stream.PreviewSource(ch.Path), nil, true, "", "UDP", "video/mp2t")
+		return true
+	}
+	serveUDPHub(w, r, ch.Path, ch.SourceAddr(), ch.Ifaces, true, ch.LocalAddr, "UDP", ch.ContentType)
+	return true
+}
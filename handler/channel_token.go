@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/qist/tvgate/config"
+)
+
+const (
+	defaultChannelTokenParam  = "token"
+	defaultChannelTokenHeader = "X-Channel-Token"
+)
+
+// checkChannelToken 校验频道要求的访问令牌（channels[].token），在加入 Hub 之前调用；
+// 令牌可放在查询参数或请求头中，比较为常量时间，不匹配时返回 403
+func checkChannelToken(w http.ResponseWriter, r *http.Request, ch config.ChannelConfig) bool {
+	if ch.Token == "" {
+		return true
+	}
+	param, header := ch.TokenParam, ch.TokenHeader
+	if param == "" {
+		param = defaultChannelTokenParam
+	}
+	if header == "" {
+		header = defaultChannelTokenHeader
+	}
+	got := r.Header.Get(header)
+	if got == "" {
+		got = r.URL.Query().Get(param)
+	}
+	if subtle.ConstantTimeCompare([]byte(got), []byte(ch.Token)) == 1 {
+		return true
+	}
+	http.Error(w, "Forbidden", http.StatusForbidden)
+	return false
+}
+
+// tokenChannel 返回本次订阅需要满足其令牌的频道：经频道路由访问时为该频道，
+// 经 /udp/、/rtp/ 直接访问时按源地址匹配配置了令牌的频道，避免绕过频道令牌
+func tokenChannel(channel, addr string) (config.ChannelConfig, bool) {
+	if ch, ok := lookupChannel(channel); ok {
+		return ch, true
+	}
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	if ch := config.Cfg.TokenChannelForSource(addr); ch != nil {
+		return *ch, true
+	}
+	return config.ChannelConfig{}, false
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/qist/tvgate/monitor"
+)
+
+// ContentDetectedHeader 回显协商结果的响应头，值为实际使用的 Content-Type 及其来源，
+// 例如 "video/mp2t; source=accept"，便于排查播放器探测失败
+const ContentDetectedHeader = "X-TVGate-Content-Detected"
+
+// Content-Type 的决定来源
+const (
+	contentSourceQuery   = "query"   // ?content_type= 参数
+	contentSourceAccept  = "accept"  // Accept 请求头
+	contentSourceDefault = "default" // 频道配置或默认值
+)
+
+// 支持按客户端协商的流媒体 Content-Type
+var streamContentTypes = map[string]string{
+	"video/mp2t":               "video/mp2t",
+	"application/octet-stream": "application/octet-stream",
+	"ts":                       "video/mp2t",
+	"mp2t":                     "video/mp2t",
+	"octet":                    "application/octet-stream",
+	"binary":                   "application/octet-stream",
+}
+
+func init() {
+	seen := make(map[string]bool)
+	var types []string
+	for _, ct := range streamContentTypes {
+		if !seen[ct] {
+			seen[ct] = true
+			types = append(types, ct)
+		}
+	}
+	monitor.RegisterStreamContentTypes(types...)
+}
+
+// negotiateContentType 按单个客户端请求选择 Content-Type：
+// 优先 ?content_type= 参数，其次 Accept 头中第一个支持的类型，否则使用默认值。
+// 仅影响该客户端的响应头，同一 Hub 的其他客户端不受影响。第二个返回值为决定来源
+func negotiateContentType(r *http.Request, def string) (string, string) {
+	if v := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("content_type"))); v != "" {
+		if ct, ok := streamContentTypes[v]; ok {
+			return ct, contentSourceQuery
+		}
+	}
+
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		if !strings.Contains(mediaType, "/") {
+			continue
+		}
+		rejected := false
+		for _, p := range fields[1:] {
+			p = strings.ReplaceAll(strings.TrimSpace(p), " ", "")
+			if p == "q=0" || p == "q=0.0" || p == "q=0.00" || p == "q=0.000" {
+				rejected = true
+				break
+			}
+		}
+		if rejected {
+			continue
+		}
+		if ct, ok := streamContentTypes[mediaType]; ok {
+			return ct, contentSourceAccept
+		}
+	}
+	return def, contentSourceDefault
+}
@@ -66,6 +66,20 @@ func Handler(client *http.Client) http.HandlerFunc {
 			w.Write(config.FaviconFile)
 			return
 		}
+		// 配置尚未完成首次加载时，其余请求一律返回 503 initializing
+		if !config.Loaded() {
+			monitor.WriteInitializing(w, r)
+			return
+		}
+		// 维护模式：频道、组播、RTSP 及代理请求一律返回 503 + Retry-After（已建立的连接不受影响）
+		if monitor.InMaintenance() {
+			monitor.WriteMaintenance(w, r)
+			return
+		}
+		// 配置的频道路由优先
+		if ChannelHandler(w, r) {
+			return
+		}
 		switch {
 		case strings.HasPrefix(r.URL.Path, "/udp/"):
 			UdpRtpHandler(w, r, "/udp/")
@@ -77,6 +91,15 @@ func Handler(client *http.Client) http.HandlerFunc {
 			RtspToHTTPHandler(w, r)
 			return
 		}
+		// 管理员可通过查询参数为本次请求强制指定负载均衡策略或代理（测试用）；
+		// 覆盖参数与管理员凭据在构造目标 URL 和复制请求头之前删除，不转发给后端
+		lbOverride, err := lb.ParseOverride(r)
+		r.Header.Del(lb.AdminAuthHeader)
+		r.URL.RawQuery = lb.StripOverrideQuery(r.URL.RawQuery)
+		if err != nil {
+			monitor.WriteError(w, r, http.StatusForbidden, err.Error())
+			return
+		}
 		targetPath := stream.GetTargetPath(r)
 		targetURL := stream.GetTargetURL(r, targetPath)
 		parsedURL, err := url.Parse(targetURL)
@@ -184,6 +207,7 @@ func Handler(client *http.Client) http.HandlerFunc {
 			IP:             clientIP,
 			URL:            targetURL,
 			UserAgent:      r.UserAgent(),
+			ClientProtocol: monitor.RequestProtocol(r),
 			ConnectionType: strings.ToUpper(parsedURL.Scheme),
 			ConnectedAt:    time.Now(),
 			LastActive:     time.Now(),
@@ -224,7 +248,7 @@ func Handler(client *http.Client) http.HandlerFunc {
 				// 异步选择代理
 				proxyRes := make(chan *config.ProxyConfig, 1)
 				go func() {
-					proxyRes <- lb.SelectProxy(pg, targetURL, forceTest)
+					proxyRes <- lb.SelectProxyOverride(pg, targetURL, forceTest, lbOverride)
 				}()
 
 				var selectedProxy *config.ProxyConfig
@@ -250,8 +274,13 @@ func Handler(client *http.Client) http.HandlerFunc {
 					continue
 				}
 
+				// 活跃连接计数：重试前显式释放，其余退出路径由 defer 兜底
+				release := lb.AcquireProxy(pg, selectedProxy)
+				defer release()
+
 				proxyClient, err := proxy.CreateProxyClient(ctx, &config.Cfg, *selectedProxy, pg.IPv6)
 				if err != nil {
+					release()
 					markProxyResult(pg, selectedProxy, false)
 					continue
 				}
@@ -263,6 +292,7 @@ func Handler(client *http.Client) http.HandlerFunc {
 				}
 				reqCopy, err := http.NewRequest(r.Method, targetURL, proxyBody)
 				if err != nil {
+					release()
 					markProxyResult(pg, selectedProxy, false)
 					continue
 				}
@@ -273,6 +303,7 @@ func Handler(client *http.Client) http.HandlerFunc {
 				proxyResp, err := proxyClient.Do(reqCopy)
 				if err != nil {
 					logger.LogPrintf("⚠️ 代理请求网络错误（第 %d 次）：%v", attempt+1, err)
+					release()
 					markProxyResult(pg, selectedProxy, false)
 					if attempt == maxRetries {
 						http.Error(w, "代理请求失败："+err.Error(), http.StatusBadGateway)
@@ -284,6 +315,7 @@ func Handler(client *http.Client) http.HandlerFunc {
 
 				if proxyResp == nil {
 					logger.LogPrintf("⚠️ 代理请求无响应（第 %d 次）", attempt+1)
+					release()
 					markProxyResult(pg, selectedProxy, false)
 					if attempt == maxRetries {
 						http.Error(w, "代理无响应", http.StatusBadGateway)
@@ -294,13 +326,14 @@ func Handler(client *http.Client) http.HandlerFunc {
 				}
 
 				proxyResp.Body = &timeoutReadCloser{
-					ReadCloser: proxyResp.Body,
+					ReadCloser: lb.CountProxyBytes(pg, selectedProxy, proxyResp.Body),
 					timeout:    readTimeout,
 				}
 
 				if proxyResp.StatusCode >= 500 {
 					logger.LogPrintf("⚠️ 代理服务器错误状态码 %d（第 %d 次）", proxyResp.StatusCode, attempt+1)
 					proxyResp.Body.Close()
+					release()
 					markProxyResult(pg, selectedProxy, false)
 					if attempt == maxRetries {
 						http.Error(w, fmt.Sprintf("代理服务器错误状态码: %d", proxyResp.StatusCode), http.StatusBadGateway)
@@ -413,6 +446,11 @@ func markProxyResult(group *config.ProxyGroupConfig, proxy *config.ProxyConfig,
 	}
 
 	stats.Alive = alive
+	if alive {
+		stats.LastSuccess = time.Now()
+	} else {
+		stats.LastFailure = time.Now()
+	}
 	// stats.LastCheck = time.Now()
 	// 不一定每次都更新 TestURL 和 ResponseTime，可视需要添加
 }
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/lb"
+)
+
+// 配置首次加载完成之前，除首页与 favicon 外的请求（含 /healthz）都返回 503 而不是访问未初始化的 Cfg
+func TestHandlerBeforeConfigLoaded(t *testing.T) {
+	if config.Loaded() {
+		t.Skip("配置已被其他测试标记为已加载")
+	}
+	for _, target := range []string{"/healthz", "/udp/239.1.1.1:5000", "/example.com/live.m3u8"} {
+		rec := httptest.NewRecorder()
+		Handler(http.DefaultClient)(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: status = %d, want 503", target, rec.Code)
+		}
+	}
+}
+
+// 负载均衡覆盖参数与管理员凭据只供 TVGate 自身使用，直连后端时不得出现在上游请求中
+func TestHandlerStripsLBOverrideFromUpstream(t *testing.T) {
+	var gotQuery, gotAdmin string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		gotAdmin = r.Header.Get(lb.AdminAuthHeader)
+		w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	config.CfgMu.Lock()
+	config.Cfg.Web.Enabled = true
+	config.Cfg.Web.Username = "admin"
+	config.Cfg.Web.Password = "secret"
+	config.CfgMu.Unlock()
+	config.MarkLoaded()
+
+	base := "/" + strings.TrimPrefix(upstream.URL, "http://") + "/live.m3u8?a=1&"
+	// 参数名经百分号编码时 ParseOverride 仍会识别，同样不能转发给上游
+	encode := func(name string) string { return strings.ReplaceAll(name, "_", "%5F") }
+	for _, target := range []string{
+		base + lb.OverrideStrategyParam + "=fastest&b=2&" + lb.OverrideProxyParam + "=p1",
+		base + encode(lb.OverrideStrategyParam) + "=fastest&b=2&" + encode(lb.OverrideProxyParam) + "=p1",
+	} {
+		gotQuery, gotAdmin = "", ""
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set(lb.AdminAuthHeader, "admin:secret")
+		rec := httptest.NewRecorder()
+		Handler(upstream.Client())(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body = %q", target, rec.Code, rec.Body.String())
+		}
+		if gotQuery != "a=1&b=2" {
+			t.Errorf("%s: upstream query = %q, want %q", target, gotQuery, "a=1&b=2")
+		}
+		if gotAdmin != "" {
+			t.Errorf("%s: upstream saw %s = %q", target, lb.AdminAuthHeader, gotAdmin)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// 存活检查挂在监控路径下，根路径的 /healthz 按普通请求转发，不遮蔽后端同名接口
+func TestHandlerDoesNotShadowHealthz(t *testing.T) {
+	config.MarkLoaded()
+	var gotURL string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		gotURL = r.URL.String()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"text/plain"}},
+			Body:       io.NopCloser(strings.NewReader("upstream")),
+			Request:    r,
+		}, nil
+	})}
+
+	rec := httptest.NewRecorder()
+	Handler(client)(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if gotURL == "" || strings.Contains(rec.Body.String(), `"maintenance"`) {
+		t.Errorf("/healthz answered locally: status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+}
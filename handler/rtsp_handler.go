@@ -102,6 +102,7 @@ func RtspToHTTPHandler(w http.ResponseWriter, r *http.Request) {
 		IP:             clientIP,
 		URL:            rtspURL,
 		UserAgent:      r.UserAgent(),
+		ClientProtocol: monitor.RequestProtocol(r),
 		ConnectionType: "RTSP",
 		ConnectedAt:    time.Now(),
 		LastActive:     time.Now(),
@@ -124,6 +125,8 @@ func RtspToHTTPHandler(w http.ResponseWriter, r *http.Request) {
 	// ==== 新增：优先走代理组 ====
 	// ==== 新增：优先走代理组（异步选择） ====
 	pg := rules.ChooseProxyGroup(hostname, originalHost)
+	releaseProxy := func() {}
+	defer func() { releaseProxy() }()
 	if pg != nil {
 		selectedProxyChan := make(chan *config.ProxyConfig, 1)
 		go func() {
@@ -135,6 +138,7 @@ func RtspToHTTPHandler(w http.ResponseWriter, r *http.Request) {
 			if selectedProxy != nil {
 				proxyDialer, err := proxy.CreateProxyDialer(*selectedProxy)
 				if err == nil {
+					releaseProxy = lb.AcquireProxy(pg, selectedProxy)
 					client.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
 						return proxyDialer.DialContext(ctx, network, addr)
 					}
@@ -169,6 +173,8 @@ func RtspToHTTPHandler(w http.ResponseWriter, r *http.Request) {
 	// 如果已经有RTSP客户端在运行，则复用它，否则创建新的连接
 	if existingClient != nil {
 		client = existingClient
+		// 复用已有连接时本次请求没有经过所选代理，立即归还活跃连接计数
+		releaseProxy()
 
 		// 从hub中获取媒体信息
 		storedVideoMedia, storedVideoFormat, storedAudioMedia, storedAudioFormat := hub.GetMediaInfo()
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/qist/tvgate/auth"
+	"github.com/qist/tvgate/config"
+)
+
+// checkSignedURL 配置了 signed_url.secret 时校验流请求的签名链接，过期或被篡改返回 403
+func checkSignedURL(w http.ResponseWriter, r *http.Request) bool {
+	config.CfgMu.RLock()
+	secret := config.Cfg.SignedURL.Secret
+	config.CfgMu.RUnlock()
+	if secret == "" {
+		return true
+	}
+	if err := auth.VerifySignedURL(secret, r.URL, time.Now()); err != nil {
+		http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+		return false
+	}
+	return true
+}
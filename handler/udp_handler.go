@@ -6,42 +6,22 @@ import (
 	"github.com/qist/tvgate/logger"
 	"github.com/qist/tvgate/monitor"
 	"github.com/qist/tvgate/stream"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 func UdpRtpHandler(w http.ResponseWriter, r *http.Request, prefix string) {
-	// 注册活跃客户端
-	clientIP := monitor.GetClientIP(r)
-	connID := clientIP + "_" + strconv.FormatInt(time.Now().UnixNano(), 10)
-	// 全局token验证
-	if auth.GetGlobalTokenManager() != nil {
-		tokenParamName := "my_token" // 默认参数名
-		token := r.URL.Query().Get(tokenParamName)
-
-		// 获取客户端真实IP
-		clientIP := monitor.GetClientIP(r)
-
-		// 构造连接ID（IP+端口）
-		connID := clientIP + "_" + r.RemoteAddr
-
-		// 验证全局token
-		if !auth.GetGlobalTokenManager().ValidateToken(token, r.URL.Path, connID) {
-			// logger.LogPrintf("全局token验证失败: token=%s, path=%s, ip=%s", token, r.URL.Path, clientIP)
-			http.Error(w, "Forbidden", http.StatusForbidden)
-			return
-		}
-
-		// 更新全局token活跃状态
-		auth.GetGlobalTokenManager().KeepAlive(token, connID, clientIP, r.URL.Path)
-		// logger.LogPrintf("全局token验证成功: token=%s, path=%s, ip=%s", token, r.URL.Path, clientIP)
+	if !checkSignedURL(w, r) || !checkGlobalToken(w, r) {
+		return
 	}
 
-	// URL 形如 /rtp/239.0.0.1:5000?iface=eth0,eth1
+	// URL 形如 /rtp/239.0.0.1:5000?iface=eth0,eth1&laddr=192.168.1.10
 	addr := r.URL.Path[len(prefix):]
-	if addr == "" || !strings.Contains(addr, ":") {
+	if !validUDPAddr(addr) {
 		http.Error(w, "Address must be ip:port", http.StatusBadRequest)
 		return
 	}
@@ -55,37 +35,224 @@ func UdpRtpHandler(w http.ResponseWriter, r *http.Request, prefix string) {
 				ifaces = append(ifaces, n)
 			}
 		}
-	} else {
+	}
+
+	// 指定本地绑定地址，用于多网卡主机精确选择加入组播的地址
+	localAddr := strings.TrimSpace(r.URL.Query().Get("laddr"))
+
+	// 确定连接类型 (从 prefix 取 "/udp/" 或 "/rtp/")
+	connectionType := "UDP"
+	if strings.HasPrefix(prefix, "/rtp/") {
+		connectionType = "RTP"
+	}
+	// ?mode=unicast 显式单播监听：只绑定端口，不加入组播
+	source := config.WithListenMode(addr, r.URL.Query().Get("mode"))
+	serveUDPHub(w, r, addr, source, ifaces, false, localAddr, connectionType, "application/octet-stream")
+}
+
+// validUDPAddr 只接受 host:port 形式的源地址；preview:、unicast:// 等内部源前缀
+// 只能由频道路由构造，不能经 URL 直接创建 Hub
+func validUDPAddr(addr string) bool {
+	if stream.IsPreviewSource(addr) {
+		return false
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host == "" || (strings.ContainsAny(host, "/:") && net.ParseIP(host) == nil) {
+		return false
+	}
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// checkGlobalToken 全局token验证，失败时写入 403 并返回 false
+func checkGlobalToken(w http.ResponseWriter, r *http.Request) bool {
+	if auth.GetGlobalTokenManager() == nil {
+		return true
+	}
+	tokenParamName := "my_token" // 默认参数名
+	token := r.URL.Query().Get(tokenParamName)
+
+	// 获取客户端真实IP
+	clientIP := monitor.GetClientIP(r)
+
+	// 构造连接ID（IP+端口）
+	connID := clientIP + "_" + r.RemoteAddr
+
+	// 验证全局token
+	if !auth.GetGlobalTokenManager().ValidateToken(token, r.URL.Path, connID) {
+		// logger.LogPrintf("全局token验证失败: token=%s, path=%s, ip=%s", token, r.URL.Path, clientIP)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+
+	// 更新全局token活跃状态
+	auth.GetGlobalTokenManager().KeepAlive(token, connID, clientIP, r.URL.Path)
+	// logger.LogPrintf("全局token验证成功: token=%s, path=%s, ip=%s", token, r.URL.Path, clientIP)
+	return true
+}
+
+// serveUDPHub 加入（或复用）组播 Hub 并向客户端转发数据；
+// channel 为监控中展示的频道名，ifaces/localAddr 为空时使用 server 段的全局配置；
+// channelIfaces 表示 ifaces 取自频道 channel 的配置（而非请求指定），配置重载时按新的频道表切换网卡
+func serveUDPHub(w http.ResponseWriter, r *http.Request, channel, addr string, ifaces []string, channelIfaces bool, localAddr, connectionType, contentType string) {
+	// 频道令牌在所有入口统一校验（频道路由、/udp/、/rtp/ 与预览变体）
+	if ch, ok := tokenChannel(channel, addr); ok && !checkChannelToken(w, r, ch) {
+		return
+	}
+
+	// 注册活跃客户端
+	clientIP := monitor.GetClientIP(r)
+	connID := clientIP + "_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	ifacesChannel := ""
+	if channelIfaces {
+		ifacesChannel = channel
+	}
+	config.CfgMu.RLock()
+	ifaces, origin := stream.ResolveIfaces(ifacesChannel, ifaces)
+	config.CfgMu.RUnlock()
+	if localAddr == "" {
 		config.CfgMu.RLock()
-		ifaces = append(ifaces, config.Cfg.Server.MulticastIfaces...)
+		localAddr = config.Cfg.Server.MulticastLocalAddr
 		config.CfgMu.RUnlock()
 	}
 
-	hub, err := stream.GetOrCreateHub(addr, ifaces)
+	// 总出口带宽已达上限时拒绝新连接，优先保障已有连接
+	if stream.OverBandwidthCap() {
+		logger.LogPrintf("🚫 出口带宽已达上限，拒绝客户端 %s 访问 %s", clientIP, addr)
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "Service Unavailable: bandwidth limit reached", http.StatusServiceUnavailable)
+		return
+	}
+
+	// 内存超过上限时拒绝新连接，避免 OOM（日志由 OverMemoryLimit 限频输出）
+	if stream.OverMemoryLimit() {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "Service Unavailable: memory limit reached", http.StatusServiceUnavailable)
+		return
+	}
+
+	// 单 IP 并发连接数限制，在加入 Hub 之前检查
+	config.CfgMu.RLock()
+	maxPerIP := config.Cfg.Stream.MaxConnsPerIP
+	config.CfgMu.RUnlock()
+	releaseIP, ok := monitor.AcquireIPConn(clientIP, maxPerIP)
+	if !ok {
+		logger.LogPrintf("🚫 客户端 %s 连接数已达上限 %d，拒绝访问 %s", clientIP, maxPerIP, addr)
+		w.Header().Set("Retry-After", "10")
+		http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		return
+	}
+	defer releaseIP()
+
+	hub, err := stream.GetOrCreateHubWithOrigin(addr, ifaces, localAddr, origin)
 	if err != nil {
 		http.Error(w, "Failed to listen UDP: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// 确定连接类型 (从 prefix 取 "/udp/" 或 "/rtp/")
-	connectionType := "UDP"
-	if strings.HasPrefix(prefix, "/rtp/") {
-		connectionType = "RTP"
-	}
+	hubKey := stream.HubKey(addr, ifaces, localAddr)
+	connectedAt := time.Now()
 	monitor.ActiveClients.Register(connID, &monitor.ClientConnection{
 		IP:             clientIP,
 		URL:            addr,
 		UserAgent:      r.UserAgent(),
+		ClientProtocol: monitor.RequestProtocol(r),
 		ConnectionType: connectionType,
-		ConnectedAt:    time.Now(),
+		HubKey:         hubKey,
+		Channel:        channel,
+		Variant:        hub.Variant(),
+		ConnectedAt:    connectedAt,
 		LastActive:     time.Now(),
 	})
 	defer monitor.ActiveClients.Unregister(connID, connectionType)
 
+	// 统计本次会话发送的字节数，断开时记录会话摘要
+	counter := &countingWriter{ResponseWriter: w}
+	w = counter
+
+	// 调试：按客户端维护滚动校验值，便于对比同频道客户端收到的数据
+	config.CfgMu.RLock()
+	checksumEnabled := config.Cfg.Stream.ClientChecksum
+	checksumFrames := config.Cfg.Stream.ChecksumFrames
+	config.CfgMu.RUnlock()
+	var cw *stream.ChecksumWriter
+	if checksumEnabled {
+		cw = stream.NewChecksumWriter(w, checksumFrames)
+		w = cw
+	}
+
+	// 频道输出限速：按客户端令牌桶限制写入速率
+	var (
+		rl        *stream.RateLimitWriter
+		rateLimit string
+	)
+	if ch, ok := lookupChannel(channel); ok && ch.MaxMbps > 0 {
+		rl = stream.NewRateLimitWriter(w, ch.MaxMbps, ch.RatePolicy)
+		w = rl
+		rateLimit = rl.Describe()
+		monitor.ActiveClients.UpdateThrottle(connID, rateLimit, false, 0)
+	}
+
 	// 定义更新活跃时间的回调
 	updateActive := func() {
 		monitor.ActiveClients.UpdateLastActive(connID, time.Now())
+		if cw != nil {
+			monitor.ActiveClients.UpdateChecksum(connID, cw.Sum())
+		}
+		if rl != nil {
+			monitor.ActiveClients.UpdateThrottle(connID, rateLimit, rl.Throttled(), rl.Dropped())
+		}
 	}
 	logger.LogRequestAndResponse(r, addr, &http.Response{StatusCode: http.StatusOK})
-	hub.ServeHTTP(w, r, "application/octet-stream", updateActive)
+	contentType, source := negotiateContentType(r, contentType)
+	w.Header().Set(ContentDetectedHeader, contentType+"; source="+source)
+	// 记录首帧来自秒开缓存还是实时包，便于确认秒开是否生效
+	var firstFrame string
+	onFirstFrame := func(source string) {
+		firstFrame = source
+		monitor.ActiveClients.UpdateFirstFrame(connID, source)
+	}
+	hub.ServeHTTP(w, r, contentType, updateActive, onFirstFrame)
+
+	now := time.Now()
+	duration := now.Sub(connectedAt).Round(time.Second)
+	sent := counter.written.Load()
+	logger.LogPrintf("👋 客户端 %s 离开 %s，时长 %v，发送 %s", clientIP, addr, duration, monitor.FormatBytes(sent))
+	monitor.RecordSession(monitor.SessionRecord{
+		IP:             clientIP,
+		Channel:        channel,
+		HubKey:         hubKey,
+		UserAgent:      r.UserAgent(),
+		ConnectionType: connectionType,
+		ConnectedAt:    connectedAt,
+		DisconnectedAt: now,
+		Duration:       duration,
+		Bytes:          sent,
+		FirstFrame:     firstFrame,
+	})
+}
+
+// countingWriter 统计写入客户端的字节数
+type countingWriter struct {
+	http.ResponseWriter
+	written atomic.Uint64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.ResponseWriter.Write(p)
+	c.written.Add(uint64(n))
+	return n, err
+}
+
+// Flush 透传 http.Flusher，保证逐帧推送
+func (c *countingWriter) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (c *countingWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
 }
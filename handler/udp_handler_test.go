@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qist/tvgate/config"
+)
+
+// 频道令牌保护的源经 /udp/、/rtp/ 直接访问时同样需要令牌，校验在加入 Hub 之前完成
+func TestUdpRtpHandlerRequiresChannelToken(t *testing.T) {
+	config.CfgMu.Lock()
+	saved := config.Cfg.Channels
+	config.Cfg.Channels = []*config.ChannelConfig{{Path: "/live/secret", UDPAddr: "239.9.9.9:5000", Token: "s3cret"}}
+	config.CfgMu.Unlock()
+	defer func() {
+		config.CfgMu.Lock()
+		config.Cfg.Channels = saved
+		config.CfgMu.Unlock()
+	}()
+
+	for _, target := range []string{
+		"/udp/239.9.9.9:5000",
+		"/rtp/239.9.9.9:5000",
+		"/udp/239.9.9.9:5000?mode=unicast",
+		"/udp/239.9.9.9:5000?token=wrong",
+	} {
+		prefix := target[:5]
+		rec := httptest.NewRecorder()
+		UdpRtpHandler(rec, httptest.NewRequest(http.MethodGet, target, nil), prefix)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want 403", target, rec.Code)
+		}
+	}
+}
+
+// 预览等内部源前缀只能由频道路由构造，/udp/ 请求不能借此创建转码 Hub
+func TestUdpRtpHandlerRejectsInternalSources(t *testing.T) {
+	for _, target := range []string{
+		"/udp/preview:/live/cctv1",
+		"/udp/unicast://0.0.0.0:5000",
+		"/udp/http://example.com/live.ts",
+		"/udp/239.1.1.1",
+		"/udp/239.1.1.1:0",
+	} {
+		rec := httptest.NewRecorder()
+		UdpRtpHandler(rec, httptest.NewRequest(http.MethodGet, target, nil), "/udp/")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", target, rec.Code)
+		}
+	}
+	for _, addr := range []string{"239.1.1.1:5000", "[ff3e::1]:5000", "mcast.example.com:5000"} {
+		if !validUDPAddr(addr) {
+			t.Errorf("validUDPAddr(%q) = false", addr)
+		}
+	}
+}
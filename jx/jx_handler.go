@@ -76,6 +76,7 @@ func (h *JXHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		IP:             clientIP,
 		URL:            r.URL.Path,
 		UserAgent:      r.UserAgent(),
+		ClientProtocol: monitor.RequestProtocol(r),
 		ConnectionType: strings.ToUpper(r.URL.Scheme),
 		ConnectedAt:    time.Now(),
 		LastActive:     time.Now(),
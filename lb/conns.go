@@ -0,0 +1,52 @@
+package lb
+
+import (
+	"io"
+	"sync"
+
+	"github.com/qist/tvgate/config"
+)
+
+// proxyStats 返回代理的统计项，不存在时创建
+func proxyStats(group *config.ProxyGroupConfig, proxy *config.ProxyConfig) *config.ProxyStats {
+	group.Stats.Lock()
+	defer group.Stats.Unlock()
+	stats, ok := group.Stats.ProxyStats[proxy.Name]
+	if !ok {
+		stats = &config.ProxyStats{}
+		group.Stats.ProxyStats[proxy.Name] = stats
+	}
+	return stats
+}
+
+// AcquireProxy 代理活跃连接数 +1，返回的释放函数需在连接结束时调用（重复调用安全）
+func AcquireProxy(group *config.ProxyGroupConfig, proxy *config.ProxyConfig) (release func()) {
+	stats := proxyStats(group, proxy)
+
+	// ProxyStats 在配置重载时会被新代理组复用，因此计数使用原子操作而非组锁
+	stats.ActiveConns.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			stats.ActiveConns.Add(-1)
+		})
+	}
+}
+
+// CountProxyBytes 包装经代理获取的响应体，读取时累计到该代理的转发字节数
+func CountProxyBytes(group *config.ProxyGroupConfig, proxy *config.ProxyConfig, body io.ReadCloser) io.ReadCloser {
+	return &countingBody{ReadCloser: body, stats: proxyStats(group, proxy)}
+}
+
+type countingBody struct {
+	io.ReadCloser
+	stats *config.ProxyStats
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.stats.BytesTransferred.Add(uint64(n))
+	}
+	return n, err
+}
@@ -9,10 +9,7 @@ import (
 
 // 异步写入所有测速结果
 func ConsumeRemainingResults(ch chan config.TestResult, count int, group *config.ProxyGroupConfig, now time.Time) {
-	interval := group.Interval
-	if interval == 0 {
-		interval = 60 * time.Second
-	}
+	interval := testInterval(group)
 
 	for i := 0; i < count; i++ {
 		res := <-ch
@@ -28,13 +25,16 @@ func ConsumeRemainingResults(ch chan config.TestResult, count int, group *config
 			res.ResponseTime > 0 {
 			stats.Alive = true
 			stats.ResponseTime = res.ResponseTime
+			observeTestResult(res)
 			stats.StatusCode = res.StatusCode
 			stats.FailCount = 0
+			stats.LastSuccess = now
 			stats.CooldownUntil = time.Time{}
 			logger.LogPrintf("✅ 异步：代理 %s 测速成功: %v（已写入缓存）", res.Proxy.Name, res.ResponseTime)
 		} else {
 			stats.Alive = false
 			stats.FailCount++
+			stats.LastFailure = now
 			var cooldown time.Duration
 			if stats.FailCount >= 15 {
 				cooldown = 2 * time.Hour
@@ -16,12 +16,7 @@ func SelectFastestProxy(group *config.ProxyGroupConfig, targetURL string, forceT
 	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultDialTimeout)
 	defer cancel()
 	now := time.Now()
-	interval := group.Interval
-	if interval == 0 {
-		interval = 60 * time.Second
-	}
-	maxAcceptableRT := 3 * time.Second
-	// minAcceptableRT := 100 * time.Microsecond
+	interval := testInterval(group)
 
 	group.Stats.Lock()
 	n := len(group.Proxies)
@@ -30,28 +25,12 @@ func SelectFastestProxy(group *config.ProxyGroupConfig, targetURL string, forceT
 		return nil
 	}
 
-	needCheck := forceTest // 如果强制测速，直接需要测速
-	if !forceTest {
-		allNoRT := true
-
-		for _, proxy := range group.Proxies {
-			stats, ok := group.Stats.ProxyStats[proxy.Name]
-			if ok && now.Sub(stats.LastCheck) <= interval && stats.ResponseTime > 0 {
-				// 缓存有效且测速过，认为是“可用的”
-				allNoRT = false
-			}
-		}
-
-		// 至少有一个可用代理，就不测速
-		// 全部未测速成功（或缓存过期），才触发测速
-		needCheck = allNoRT
-	}
+	// 强制测速时直接测速；否则至少有一个代理缓存有效且测速过就不测速，全部未测速成功（或缓存过期）才触发测速
+	needCheck := forceTest || !cacheFresh(group, now, interval)
 
 	// ===== 缓存优先使用（非强制测速时）=====
 	if !needCheck {
 		logger.LogPrintf("🌀 当前代理组缓存状态：")
-		var fastest *config.ProxyConfig
-		minTime := time.Hour
 		for _, proxy := range group.Proxies {
 			stats := group.Stats.ProxyStats[proxy.Name]
 			if stats == nil {
@@ -83,25 +62,10 @@ func SelectFastestProxy(group *config.ProxyGroupConfig, targetURL string, forceT
 			)
 		}
 
-		for i := range group.Proxies {
-			proxy := group.Proxies[i]
-			stats, ok := group.Stats.ProxyStats[proxy.Name]
-			if !ok {
-				continue
-			}
-			if now.Before(stats.CooldownUntil) || !stats.Alive || stats.ResponseTime > maxAcceptableRT {
-				continue
-			}
-			if stats.ResponseTime < minTime && stats.ResponseTime > 0 {
-				minTime = stats.ResponseTime
-				fastest = proxy
-			}
-		}
-
-		if fastest != nil {
+		if fastest := pickFastest(group, now); fastest != nil {
 			stats := group.Stats.ProxyStats[fastest.Name]
 			group.Stats.Unlock()
-			logger.LogPrintf("⚡ 使用缓存数据选择最快代理: %s，响应时间: %v，上次测速已过: %v, 最小测速间隔: %v", fastest.Name, minTime,
+			logger.LogPrintf("⚡ 使用缓存数据选择最快代理: %s，响应时间: %v，上次测速已过: %v, 最小测速间隔: %v", fastest.Name, stats.ResponseTime,
 				now.Sub(stats.LastCheck).Truncate(time.Second), interval)
 			return fastest
 		}
@@ -189,8 +153,10 @@ loop:
 				res.ResponseTime >= 0 && res.StatusCode < 500 {
 				stats.Alive = true
 				stats.ResponseTime = res.ResponseTime
+				observeTestResult(res)
 				stats.StatusCode = res.StatusCode
 				stats.FailCount = 0
+				stats.LastSuccess = now
 				stats.CooldownUntil = time.Time{}
 				group.Stats.Unlock()
 
@@ -216,6 +182,7 @@ loop:
 				stats.Alive = false
 				stats.ResponseTime = 0
 				stats.FailCount++
+				stats.LastFailure = now
 				if stats.FailCount >= 3 {
 					stats.CooldownUntil = now.Add(interval)
 					logger.LogPrintf("❌ 代理 %s 连续失败 %d 次，进入冷却 %v", res.Proxy.Name, stats.FailCount, interval)
@@ -0,0 +1,24 @@
+package lb
+
+import (
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+)
+
+// SelectLeastConnProxy 在测速缓存有效的可用代理中选择活跃连接数最少的代理（相同时取响应更快者），
+// 无可用缓存时回退为轮询策略（会触发测速）
+func SelectLeastConnProxy(group *config.ProxyGroupConfig, targetURL string, forceTest bool) *config.ProxyConfig {
+	if !forceTest {
+		group.Stats.Lock()
+		best, conns, rt := pickLeastConn(group, time.Now(), testInterval(group))
+		group.Stats.Unlock()
+
+		if best != nil {
+			logger.LogPrintf("🔗 最少连接代理: %s 活跃连接: %d 响应: %v", best.Name, conns, rt)
+			return best
+		}
+	}
+	return SelectRoundRobinProxy(group, targetURL, forceTest)
+}
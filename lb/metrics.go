@@ -0,0 +1,15 @@
+package lb
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/monitor"
+)
+
+// observeTestResult 将成功的测速结果计入代理响应时间指标
+func observeTestResult(res config.TestResult) {
+	server := net.JoinHostPort(res.Proxy.Server, strconv.Itoa(res.Proxy.Port))
+	monitor.ObserveProxyResponseTime(res.Proxy.Name, server, res.ResponseTime)
+}
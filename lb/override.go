@@ -0,0 +1,110 @@
+package lb
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+)
+
+const (
+	// OverrideStrategyParam 强制本次请求使用的负载均衡策略：round-robin / fastest / least-conn
+	OverrideStrategyParam = "tvgate_lb"
+	// OverrideProxyParam 强制本次请求使用代理组中指定名称的代理
+	OverrideProxyParam = "tvgate_proxy"
+	// AdminAuthHeader 使用覆盖参数时必须携带的管理员凭据，格式 username:password（web 管理账号）
+	AdminAuthHeader = "X-TVGate-Admin"
+)
+
+// Override 单次请求的负载均衡覆盖，仅用于测试新策略或指定代理，不影响其他请求
+type Override struct {
+	Strategy string
+	Proxy    string
+}
+
+// IsZero 是否未指定任何覆盖
+func (o Override) IsZero() bool {
+	return o.Strategy == "" && o.Proxy == ""
+}
+
+// ParseOverride 从请求中解析覆盖参数；未携带参数时返回零值，携带参数但管理员认证失败或策略无效时返回错误
+func ParseOverride(r *http.Request) (Override, error) {
+	q := r.URL.Query()
+	o := Override{
+		Strategy: strings.ToLower(strings.TrimSpace(q.Get(OverrideStrategyParam))),
+		Proxy:    strings.TrimSpace(q.Get(OverrideProxyParam)),
+	}
+	if o.IsZero() {
+		return o, nil
+	}
+	if !adminAuthorized(r.Header.Get(AdminAuthHeader)) {
+		logger.LogPrintf("⛔ 拒绝负载均衡覆盖请求（管理员认证失败）: %s %s", r.RemoteAddr, r.URL.Path)
+		return Override{}, errors.New("负载均衡覆盖参数需要管理员认证")
+	}
+	switch o.Strategy {
+	case "", "fastest", "round-robin", "roundrobin", "least-conn", "least_conn", "leastconn":
+	default:
+		return Override{}, errors.New("无效的负载均衡策略: " + o.Strategy)
+	}
+	logger.LogPrintf("🧪 负载均衡覆盖: %s %s 策略=%q 代理=%q", r.RemoteAddr, r.URL.Path, o.Strategy, o.Proxy)
+	return o, nil
+}
+
+// adminAuthorized 校验 username:password 是否与 web 管理账号一致，未启用 web 管理时一律拒绝
+func adminAuthorized(value string) bool {
+	user, pass, ok := strings.Cut(value, ":")
+	if !ok {
+		return false
+	}
+	config.CfgMu.RLock()
+	web := config.Cfg.Web
+	config.CfgMu.RUnlock()
+	if !web.Enabled || web.Password == "" {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(web.Username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(web.Password)) == 1
+	return userOK && passOK
+}
+
+// StripOverrideQuery 从原始查询串中删除覆盖参数，其余参数保持原样；
+// 参数名按 ParseOverride 的方式解码后比较，tvgate%5Flb= 这类编码写法同样删除
+func StripOverrideQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	parts := make([]string, 0, 4)
+	for _, kv := range strings.Split(rawQuery, "&") {
+		key, _, _ := strings.Cut(kv, "=")
+		if name, err := url.QueryUnescape(key); err == nil && (name == OverrideStrategyParam || name == OverrideProxyParam) {
+			continue
+		}
+		parts = append(parts, kv)
+	}
+	return strings.Join(parts, "&")
+}
+
+// SelectProxyOverride 按覆盖项选择代理：指定代理名时直接使用该代理，指定策略时替代代理组配置的策略，
+// 未指定时等同于 SelectProxy
+func SelectProxyOverride(group *config.ProxyGroupConfig, targetURL string, forceTest bool, o Override) *config.ProxyConfig {
+	if o.Proxy != "" {
+		config.LogConfigMutex.Lock()
+		defer config.LogConfigMutex.Unlock()
+		for _, p := range group.Proxies {
+			if p.Name == o.Proxy {
+				logger.LogPrintf("覆盖指定代理: %s", p.Name)
+				return p
+			}
+		}
+		logger.LogPrintf("⚠️ 代理组中没有名为 %s 的代理", o.Proxy)
+		return nil
+	}
+	if o.Strategy != "" {
+		return selectProxy(group, o.Strategy, targetURL, forceTest)
+	}
+	return SelectProxy(group, targetURL, forceTest)
+}
@@ -0,0 +1,101 @@
+package lb
+
+import (
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// 基于测速缓存的选择逻辑：只读取缓存、不加锁、不测速、不修改任何状态，
+// 由各策略的 Select*Proxy 与 PreviewProxy 共用，调用方需持有 group.Stats 锁
+
+const (
+	defaultTestInterval = 60 * time.Second       // 未配置 interval 时测速缓存的有效期
+	defaultMaxRT        = 800 * time.Millisecond // 未配置 max_rt 时轮询策略的响应时间阈值
+	minAcceptableRT     = 100 * time.Microsecond // 低于该值的测速结果视为异常
+	maxAcceptableRT     = 3 * time.Second        // fastest 策略可接受的最大响应时间
+)
+
+// testInterval 代理组测速缓存的有效期
+func testInterval(group *config.ProxyGroupConfig) time.Duration {
+	if group.Interval == 0 {
+		return defaultTestInterval
+	}
+	return group.Interval
+}
+
+// rtThreshold 轮询策略认为响应足够快的阈值
+func rtThreshold(group *config.ProxyGroupConfig) time.Duration {
+	if group.MaxRT == 0 {
+		return defaultMaxRT
+	}
+	return group.MaxRT
+}
+
+// cacheFresh 是否至少有一个代理的测速缓存仍在有效期内；全部过期或未测速时需要先测速
+func cacheFresh(group *config.ProxyGroupConfig, now time.Time, interval time.Duration) bool {
+	for _, proxy := range group.Proxies {
+		stats, ok := group.Stats.ProxyStats[proxy.Name]
+		if ok && now.Sub(stats.LastCheck) <= interval && stats.ResponseTime > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// pickFastest 返回存活、未冷却且响应不超过 maxAcceptableRT 的代理中响应最快者，没有时返回 nil
+func pickFastest(group *config.ProxyGroupConfig, now time.Time) *config.ProxyConfig {
+	var fastest *config.ProxyConfig
+	minTime := time.Hour
+	for _, proxy := range group.Proxies {
+		stats, ok := group.Stats.ProxyStats[proxy.Name]
+		if !ok || now.Before(stats.CooldownUntil) || !stats.Alive || stats.ResponseTime > maxAcceptableRT {
+			continue
+		}
+		if stats.ResponseTime < minTime && stats.ResponseTime > 0 {
+			minTime, fastest = stats.ResponseTime, proxy
+		}
+	}
+	return fastest
+}
+
+// pickRoundRobin 从当前轮询位置开始返回第一个响应在阈值内的存活代理；
+// 没有时返回第一个有测速结果的次优代理（fallback 为 true），都没有时返回 nil。
+// idx 为选中代理的下标，调用方据此推进轮询位置
+func pickRoundRobin(group *config.ProxyGroupConfig, now time.Time, threshold time.Duration) (proxy *config.ProxyConfig, idx int, fallback bool) {
+	n := len(group.Proxies)
+	idx = -1
+	for i := 0; i < n; i++ {
+		j := (group.Stats.RoundRobinIndex + i) % n
+		p := group.Proxies[j]
+		stats, ok := group.Stats.ProxyStats[p.Name]
+		if !ok || !stats.Alive || now.Before(stats.CooldownUntil) {
+			continue
+		}
+		if stats.ResponseTime >= minAcceptableRT && stats.ResponseTime <= threshold {
+			return p, j, false
+		}
+		if proxy == nil && stats.ResponseTime > 0 {
+			proxy, idx = p, j
+		}
+	}
+	return proxy, idx, proxy != nil
+}
+
+// pickLeastConn 返回测速缓存有效的可用代理中活跃连接数最少者（相同时取响应更快者），没有时返回 nil
+func pickLeastConn(group *config.ProxyGroupConfig, now time.Time, interval time.Duration) (best *config.ProxyConfig, conns int64, rt time.Duration) {
+	for _, proxy := range group.Proxies {
+		stats, ok := group.Stats.ProxyStats[proxy.Name]
+		if !ok || !stats.Alive || now.Before(stats.CooldownUntil) || stats.ResponseTime <= 0 {
+			continue
+		}
+		if now.Sub(stats.LastCheck) > interval {
+			continue
+		}
+		c := stats.ActiveConnCount()
+		if best == nil || c < conns || (c == conns && stats.ResponseTime < rt) {
+			best, conns, rt = proxy, c, stats.ResponseTime
+		}
+	}
+	return best, conns, rt
+}
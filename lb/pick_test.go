@@ -0,0 +1,76 @@
+package lb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// testGroup 构造测速缓存全部有效的代理组：rts 为各代理的响应时间，0 表示不可用
+func testGroup(now time.Time, rts ...time.Duration) *config.ProxyGroupConfig {
+	g := &config.ProxyGroupConfig{Stats: &config.GroupStats{ProxyStats: make(map[string]*config.ProxyStats)}}
+	for i, rt := range rts {
+		name := string(rune('a' + i))
+		g.Proxies = append(g.Proxies, &config.ProxyConfig{Name: name})
+		g.Stats.ProxyStats[name] = &config.ProxyStats{Alive: rt > 0, ResponseTime: rt, LastCheck: now}
+	}
+	return g
+}
+
+// 预演与实际选择共用同一套缓存选择逻辑：预演结果与随后的实际选择一致，且预演不推进轮询位置
+func TestPreviewMatchesSelectors(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name     string
+		strategy string
+		rts      []time.Duration
+		conns    []int64
+		rrIndex  int
+		want     string
+		selectFn func(*config.ProxyGroupConfig, string, bool) *config.ProxyConfig
+	}{
+		{"round-robin", "round-robin", []time.Duration{200 * time.Millisecond, 0, 300 * time.Millisecond}, nil, 1, "c", SelectRoundRobinProxy},
+		{"round-robin fallback", "round-robin", []time.Duration{2 * time.Second, 0, time.Second}, nil, 1, "c", SelectRoundRobinProxy},
+		{"fastest", "fastest", []time.Duration{400 * time.Millisecond, 100 * time.Millisecond, 4 * time.Second}, nil, 0, "b", SelectFastestProxy},
+		{"least-conn", "least-conn", []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 200 * time.Millisecond}, []int64{3, 1, 1}, 0, "c", SelectLeastConnProxy},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := testGroup(now, tc.rts...)
+			g.Stats.RoundRobinIndex = tc.rrIndex
+			for i, n := range tc.conns {
+				g.Stats.ProxyStats[g.Proxies[i].Name].ActiveConns.Store(n)
+			}
+
+			p := PreviewProxy("g", g, tc.strategy, "", now)
+			if p.Proxy != tc.want || p.NeedsTest {
+				t.Fatalf("preview = %q (needs test %v, %s), want %q", p.Proxy, p.NeedsTest, p.Reason, tc.want)
+			}
+			if g.Stats.RoundRobinIndex != tc.rrIndex {
+				t.Errorf("preview moved RoundRobinIndex to %d", g.Stats.RoundRobinIndex)
+			}
+			if got := tc.selectFn(g, "http://example.invalid/", false); got == nil || got.Name != p.Proxy {
+				t.Errorf("selector picked %v, preview %q", got, p.Proxy)
+			}
+		})
+	}
+}
+
+func TestPickRoundRobin(t *testing.T) {
+	now := time.Now()
+	g := testGroup(now, 50*time.Microsecond, time.Second, 500*time.Millisecond, 0)
+	g.Stats.ProxyStats["c"].CooldownUntil = now.Add(time.Minute)
+
+	// a 低于 minAcceptableRT、b 超过阈值、c 冷却中、d 不可用：回退为轮询位置之后第一个有测速结果的代理
+	g.Stats.RoundRobinIndex = 1
+	if p, idx, fallback := pickRoundRobin(g, now, defaultMaxRT); p == nil || p.Name != "b" || idx != 1 || !fallback {
+		t.Errorf("pickRoundRobin = %v, %d, %v; want b, 1, true", p, idx, fallback)
+	}
+	g.Stats.ProxyStats["c"].CooldownUntil = time.Time{}
+	if p, idx, fallback := pickRoundRobin(g, now, defaultMaxRT); p == nil || p.Name != "c" || idx != 2 || fallback {
+		t.Errorf("pickRoundRobin = %v, %d, %v; want c, 2, false", p, idx, fallback)
+	}
+	if !cacheFresh(g, now, defaultTestInterval) || cacheFresh(g, now.Add(2*defaultTestInterval), defaultTestInterval) {
+		t.Error("cacheFresh does not follow the test interval")
+	}
+}
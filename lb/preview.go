@@ -0,0 +1,122 @@
+package lb
+
+import (
+	"strings"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// PreviewCandidate 预演时单个代理的状态
+type PreviewCandidate struct {
+	Name         string        `json:"name"`
+	Eligible     bool          `json:"eligible"` // 可参与缓存选择（存活、未冷却、测速缓存有效）
+	Alive        bool          `json:"alive"`
+	Cooldown     bool          `json:"cooldown"`
+	ResponseTime time.Duration `json:"response_time"`
+	ActiveConns  int64         `json:"active_conns"`
+	LastCheck    time.Time     `json:"last_check"`
+
+	BytesTransferred uint64 `json:"bytes_transferred"` // 经该代理转发的累计字节数
+}
+
+// Preview 负载均衡选择预演：按当前测速缓存给出下一次请求会选中的代理，不测速、不推进轮询位置、不修改任何状态
+type Preview struct {
+	Group      string             `json:"group"`
+	Strategy   string             `json:"strategy"`
+	ClientIP   string             `json:"client_ip,omitempty"` // 模拟的客户端 IP；现有策略均不按客户端 IP 选择，结果与其无关
+	Proxy      string             `json:"proxy,omitempty"`     // 将被选中的代理；NeedsTest 为 true 或无可用代理时为空
+	NeedsTest  bool               `json:"needs_test"`          // 实际请求会先并发测速，结果取决于测速
+	Reason     string             `json:"reason"`
+	Candidates []PreviewCandidate `json:"candidates"`
+}
+
+// normalizeStrategy 统一策略名，未知策略按轮询处理（与 selectProxy 一致）
+func normalizeStrategy(strategy string) string {
+	switch strings.ToLower(strategy) {
+	case "fastest":
+		return "fastest"
+	case "least-conn", "least_conn", "leastconn":
+		return "least-conn"
+	default:
+		return "round-robin"
+	}
+}
+
+// PreviewProxy 预演代理组在指定策略（为空使用组配置）下的选择结果，仅读取测速缓存
+func PreviewProxy(name string, group *config.ProxyGroupConfig, strategy, clientIP string, now time.Time) Preview {
+	if strategy == "" {
+		strategy = group.LoadBalance
+	}
+	p := Preview{Group: name, Strategy: normalizeStrategy(strategy), ClientIP: clientIP}
+
+	interval := testInterval(group)
+	if len(group.Proxies) == 0 {
+		p.Reason = "代理组中没有代理"
+		return p
+	}
+	if group.Stats == nil {
+		p.NeedsTest, p.Reason = true, "尚无测速数据，实际请求会先测速"
+		for _, proxy := range group.Proxies {
+			p.Candidates = append(p.Candidates, PreviewCandidate{Name: proxy.Name})
+		}
+		return p
+	}
+
+	group.Stats.Lock()
+	defer group.Stats.Unlock()
+
+	for _, proxy := range group.Proxies {
+		c := PreviewCandidate{Name: proxy.Name}
+		if stats, ok := group.Stats.ProxyStats[proxy.Name]; ok {
+			c.Alive = stats.Alive
+			c.Cooldown = now.Before(stats.CooldownUntil)
+			c.ResponseTime = stats.ResponseTime
+			c.ActiveConns = stats.ActiveConnCount()
+			c.LastCheck = stats.LastCheck
+			c.BytesTransferred = stats.TransferredBytes()
+			c.Eligible = c.Alive && !c.Cooldown && now.Sub(stats.LastCheck) <= interval && stats.ResponseTime > 0
+		}
+		p.Candidates = append(p.Candidates, c)
+	}
+
+	if p.Strategy == "least-conn" {
+		if best, _, _ := pickLeastConn(group, now, interval); best != nil {
+			p.Proxy, p.Reason = best.Name, "活跃连接最少（相同时响应更快）"
+			return p
+		}
+		// 与 SelectLeastConnProxy 一致：无可用缓存时回退为轮询
+		p.Reason = "无有效缓存，回退为轮询；"
+	}
+
+	if !cacheFresh(group, now, interval) {
+		p.NeedsTest = true
+		p.Reason += "测速缓存已全部过期，实际请求会先测速"
+		return p
+	}
+
+	if p.Strategy == "fastest" {
+		fastest := pickFastest(group, now)
+		if fastest == nil {
+			p.NeedsTest = true
+			p.Reason = "缓存中无可用代理，实际请求会先测速"
+			return p
+		}
+		p.Proxy, p.Reason = fastest.Name, "缓存中响应最快"
+		return p
+	}
+
+	// 轮询：与 SelectRoundRobinProxy 的缓存路径一致，从当前轮询位置开始取第一个响应在阈值内的代理
+	proxy, _, fallback := pickRoundRobin(group, now, rtThreshold(group))
+	switch {
+	case proxy == nil:
+		p.Reason += "无可用缓存代理，实际请求返回无可用代理"
+	case fallback:
+		p.Proxy = proxy.Name
+		p.Reason += "没有响应在阈值内的代理，使用次优代理"
+	default:
+		p.Proxy = proxy.Name
+		p.Reason += "轮询位置之后第一个响应在阈值内的代理"
+	}
+	return p
+}
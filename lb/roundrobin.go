@@ -17,17 +17,8 @@ func SelectRoundRobinProxy(group *config.ProxyGroupConfig, targetURL string, for
 	defer cancel()
 	now := time.Now()
 
-	interval := group.Interval
-	if interval == 0 {
-		interval = 60 * time.Second
-	}
-
-	threshold := group.MaxRT
-	if threshold == 0 {
-		threshold = 800 * time.Millisecond
-	}
-
-	minAcceptableRT := 100 * time.Microsecond
+	interval := testInterval(group)
+	threshold := rtThreshold(group)
 
 	group.Stats.Lock()
 	n := len(group.Proxies)
@@ -37,28 +28,12 @@ func SelectRoundRobinProxy(group *config.ProxyGroupConfig, targetURL string, for
 	}
 
 	// ===== 是否需要测速 =====
-	needCheck := forceTest
-	if !forceTest {
-		allNoRT := true
-
-		for _, proxy := range group.Proxies {
-			stats, ok := group.Stats.ProxyStats[proxy.Name]
-			if ok && now.Sub(stats.LastCheck) <= interval && stats.ResponseTime > 0 {
-				// 缓存有效且测速过，认为是“可用的”
-				allNoRT = false
-			}
-		}
-
-		// 至少有一个可用代理，就不测速
-		// 全部未测速成功（或缓存过期），才触发测速
-		needCheck = allNoRT
-	}
+	// 至少有一个代理缓存有效且测速过，就不测速；全部未测速成功（或缓存过期），才触发测速
+	needCheck := forceTest || !cacheFresh(group, now, interval)
 
 	// 使用缓存选择代理
 	if !needCheck {
 		logger.LogPrintf("🌀 当前代理组缓存状态：")
-		start := group.Stats.RoundRobinIndex
-		var fallback *config.ProxyConfig
 
 		for _, proxy := range group.Proxies {
 			stats := group.Stats.ProxyStats[proxy.Name]
@@ -91,31 +66,16 @@ func SelectRoundRobinProxy(group *config.ProxyGroupConfig, targetURL string, for
 			)
 		}
 
-		for i := 0; i < n; i++ {
-			idx := (start + i) % n
-			proxy := group.Proxies[idx]
-			stats, ok := group.Stats.ProxyStats[proxy.Name]
-			if !ok || !stats.Alive || now.Before(stats.CooldownUntil) {
-				continue
-			}
-
-			if stats.ResponseTime >= minAcceptableRT && stats.ResponseTime <= threshold {
-				group.Stats.RoundRobinIndex = (idx + 1) % n
-				group.Stats.Unlock()
-				logger.LogPrintf("🌀 使用缓存代理: %s 响应: %v", proxy.Name, stats.ResponseTime)
-				return proxy
-			}
-
-			if fallback == nil && stats.ResponseTime > 0 {
-				fallback = proxy
-				group.Stats.RoundRobinIndex = (idx + 1) % n
-			}
-		}
-
-		if fallback != nil {
+		if proxy, idx, fallback := pickRoundRobin(group, now, threshold); proxy != nil {
+			group.Stats.RoundRobinIndex = (idx + 1) % n
+			rt := group.Stats.ProxyStats[proxy.Name].ResponseTime
 			group.Stats.Unlock()
-			logger.LogPrintf("🌀 没有快速代理，使用次优缓存代理: %s", fallback.Name)
-			return fallback
+			if fallback {
+				logger.LogPrintf("🌀 没有快速代理，使用次优缓存代理: %s", proxy.Name)
+			} else {
+				logger.LogPrintf("🌀 使用缓存代理: %s 响应: %v", proxy.Name, rt)
+			}
+			return proxy
 		}
 
 		logger.LogPrintf("🚫 没有触发测速条件，也无可用缓存代理，返回 nil")
@@ -202,8 +162,10 @@ LOOP:
 			if res.Err == nil && res.ResponseTime >= minAcceptableRT && res.StatusCode < 500 {
 				stats.Alive = true
 				stats.ResponseTime = res.ResponseTime
+				observeTestResult(res)
 				stats.StatusCode = res.StatusCode
 				stats.FailCount = 0
+				stats.LastSuccess = now
 				stats.CooldownUntil = time.Time{}
 				group.Stats.Unlock()
 
@@ -238,6 +200,7 @@ LOOP:
 				stats.Alive = false
 				stats.ResponseTime = 0
 				stats.FailCount++
+				stats.LastFailure = now
 				if stats.FailCount >= 3 {
 					stats.CooldownUntil = now.Add(interval)
 					logger.LogPrintf("❌ 代理 %s 连续失败 %d 次，进入冷却 %v", res.Proxy.Name, stats.FailCount, interval)
@@ -6,8 +6,13 @@ import (
 	"strings"
 )
 
-// selectProxy 根据策略选择代理
+// SelectProxy 按代理组配置的策略选择代理
 func SelectProxy(group *config.ProxyGroupConfig, targetURL string, forceTest bool) *config.ProxyConfig {
+	return selectProxy(group, group.LoadBalance, targetURL, forceTest)
+}
+
+// selectProxy 按指定策略选择代理
+func selectProxy(group *config.ProxyGroupConfig, strategy string, targetURL string, forceTest bool) *config.ProxyConfig {
 	config.LogConfigMutex.Lock()
 	defer config.LogConfigMutex.Unlock()
 
@@ -23,13 +28,19 @@ func SelectProxy(group *config.ProxyGroupConfig, targetURL string, forceTest boo
 		}
 	}
 
-	switch strings.ToLower(group.LoadBalance) {
+	switch strings.ToLower(strategy) {
 	case "fastest":
 		proxy := SelectFastestProxy(group, targetURL, forceTest)
 		if proxy != nil {
 			logger.LogPrintf("选择最快的代理: %s", proxy.Name)
 		}
 		return proxy
+	case "least-conn", "least_conn", "leastconn":
+		proxy := SelectLeastConnProxy(group, targetURL, forceTest)
+		if proxy != nil {
+			logger.LogPrintf("最少连接选择代理: %s", proxy.Name)
+		}
+		return proxy
 	default:
 		proxy := SelectRoundRobinProxy(group, targetURL, forceTest)
 		if proxy != nil {
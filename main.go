@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/qist/tvgate/auth"
@@ -20,6 +21,7 @@ import (
 	"github.com/qist/tvgate/logger"
 	"github.com/qist/tvgate/monitor"
 	"github.com/qist/tvgate/server"
+	"github.com/qist/tvgate/stream"
 	httpclient "github.com/qist/tvgate/utils/http"
 	"github.com/qist/tvgate/web"
 )
@@ -93,7 +95,11 @@ func main() {
 	}()
 	go monitor.ActiveClients.StartCleaner(30*time.Second, 20*time.Second)
 
-	go monitor.StartSystemStatsUpdater(10 * time.Second)
+	go monitor.StartSystemStatsUpdater(config.Cfg.Monitor.BandwidthInterval)
+	monitor.StartStatePersistence()
+	monitor.SyncMaintenanceConfig()
+	stream.StartBandwidthGuard()
+	stream.StartTCPOutputs()
 
 	stopCleaner := make(chan struct{})
 	go clear.StartRedirectChainCleaner(10*time.Minute, 30*time.Minute, stopCleaner)
@@ -126,6 +132,9 @@ func main() {
 		monitorPath = "/status"
 	}
 	mux.Handle(monitorPath, server.SecurityHeaders(http.HandlerFunc(monitor.HandleMonitor)))
+	if !strings.HasSuffix(monitorPath, "/") {
+		mux.Handle(monitorPath+"/", server.SecurityHeaders(http.HandlerFunc(monitor.HandleMonitor)))
+	}
 	// jx 路径
 	jxPath := config.Cfg.JX.Path
 	if jxPath == "" {
@@ -186,6 +195,7 @@ func main() {
 	}()
 
 	<-config.ServerCtx.Done()
+	monitor.SaveState()
 	// 收到退出信号，通知清理任务退出
 	close(stopCleaner)
 	close(stopAccessCleaner)
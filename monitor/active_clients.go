@@ -1,6 +1,9 @@
 package monitor
 
 import (
+	"crypto/tls"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 )
@@ -11,17 +14,48 @@ type ClientConnection struct {
 	IP             string
 	URL            string
 	UserAgent      string
+	PlayerCategory string // 由 UserAgent 归类的播放器类型（VLC/FFmpeg/Browser/STB 等）
 	Referer        string
-	ConnectionType string // RTSP/HTTP/UDP/HTTPS
+	ConnectionType string // RTSP/HTTP/UDP/HTTPS/TCP
+	HubKey         string // 所属 UDP/组播 Hub 的标识，其他类型连接为空
+	Channel        string // 频道名：频道路由路径或组播地址，其他类型连接为空
+	Variant        string // 订阅的流变体：VariantPreview（低码率预览），完整流为空
+	Checksum       string // 最近 N 帧的滚动 CRC32@已发送帧数（stream.client_checksum 开启时）
+	RateLimit      string // 频道输出限速描述（如 4.0 Mbps/pace），未限速为空
+	Throttled      bool   // 最近是否触发限速
+	DroppedBytes   uint64 // drop 限速策略丢弃的字节数
+	FirstFrame     string // 首帧来源：fast-start（秒开缓存回放）/ live（直接收到实时包），首帧送达前为空
 	IsMobile       bool
 	ConnectedAt    time.Time
 	LastActive     time.Time
+	ClientProtocol // 订阅时的 HTTP 协议版本与 TLS 信息
+}
+
+// ClientProtocol 客户端连接的协议与 TLS 信息，用于安全审计
+type ClientProtocol struct {
+	Proto      string // HTTP/1.1、HTTP/2.0 等，非 HTTP 连接为空
+	TLSVersion string // TLS 1.3 等，明文连接为空
+	TLSCipher  string // 协商的加密套件
+	LegacyTLS  bool   // TLS 版本低于 1.2
+}
+
+// RequestProtocol 从请求中提取协议与 TLS 信息
+func RequestProtocol(r *http.Request) ClientProtocol {
+	p := ClientProtocol{Proto: r.Proto}
+	if r.TLS != nil {
+		p.TLSVersion = tls.VersionName(r.TLS.Version)
+		p.TLSCipher = tls.CipherSuiteName(r.TLS.CipherSuite)
+		p.LegacyTLS = r.TLS.Version < tls.VersionTLS12
+	}
+	return p
 }
 
 // ActiveConnectionsManager 管理活跃客户端
 type ActiveConnectionsManager struct {
-	conns map[string]*ClientConnection
-	mu    sync.RWMutex
+	conns  map[string]*ClientConnection
+	mu     sync.RWMutex
+	peak   int       // 历史最大并发客户端数
+	peakAt time.Time // 达到峰值的时间
 }
 
 // 全局活跃客户端管理器
@@ -35,19 +69,27 @@ func (m *ActiveConnectionsManager) Register(connID string, conn *ClientConnectio
 	defer m.mu.Unlock()
 
 	conn.ID = connID
+	conn.PlayerCategory = ClassifyUserAgent(conn.UserAgent)
 	if existing, ok := m.conns[connID]; ok {
 		// 已存在，更新
 		existing.URL = conn.URL
 		existing.UserAgent = conn.UserAgent
+		existing.PlayerCategory = conn.PlayerCategory
 		existing.Referer = conn.Referer
 		existing.ConnectionType = conn.ConnectionType
+		existing.HubKey = conn.HubKey
+		existing.Channel = conn.Channel
 		existing.IsMobile = conn.IsMobile
+		existing.ClientProtocol = conn.ClientProtocol
 		existing.LastActive = time.Now()
 	} else {
 		// 新连接
 		conn.ConnectedAt = time.Now()
 		conn.LastActive = conn.ConnectedAt
 		m.conns[connID] = conn
+		if len(m.conns) > m.peak {
+			m.peak, m.peakAt = len(m.conns), conn.ConnectedAt
+		}
 	}
 }
 
@@ -57,8 +99,8 @@ func (m *ActiveConnectionsManager) Unregister(connID string, connType string) {
 	defer m.mu.Unlock()
 
 	if conn, ok := m.conns[connID]; ok {
-		if connType == "RTSP" || connType == "UDP" {
-			// RTSP/UDP → 立即删除
+		if connType == "RTSP" || connType == "UDP" || connType == "TCP" {
+			// RTSP/UDP/TCP → 立即删除
 			delete(m.conns, connID)
 		} else {
 			// HTTP/HTTPS → 更新最后活跃，等待 Cleaner 清理
@@ -93,6 +135,35 @@ func (m *ActiveConnectionsManager) GetConnectionsByIP(ip string) []*ClientConnec
 	return list
 }
 
+// GetByHub 获取指定 Hub 的客户端连接，参数可为完整 HubKey、频道名或组播地址（别名）
+func (m *ActiveConnectionsManager) GetByHub(keyOrAddr string) []*ClientConnection {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]*ClientConnection, 0)
+	for _, c := range m.conns {
+		if c.HubKey == "" {
+			continue
+		}
+		if c.HubKey == keyOrAddr || c.Channel == keyOrAddr || strings.SplitN(c.HubKey, "|", 2)[0] == keyOrAddr {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// RekeyHub Hub 标识变更（如网卡配置更新）后同步客户端的 HubKey
+func (m *ActiveConnectionsManager) RekeyHub(oldKey, newKey string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.conns {
+		if c.HubKey == oldKey {
+			c.HubKey = newKey
+		}
+	}
+}
+
 // UpdateLastActive 更新客户端最后活跃时间
 func (m *ActiveConnectionsManager) UpdateLastActive(connID string, t time.Time) {
 	m.mu.Lock()
@@ -103,6 +174,63 @@ func (m *ActiveConnectionsManager) UpdateLastActive(connID string, t time.Time)
 	}
 }
 
+// 首帧来源
+const (
+	FirstFrameFastStart = "fast-start" // 加入时回放的秒开缓存（含 PAT/PMT 缓存）
+	FirstFrameLive      = "live"       // 加入后收到的下一个实时包
+)
+
+// VariantPreview 低码率预览变体（channels[].preview，?variant=preview）
+const VariantPreview = "preview"
+
+// UpdateFirstFrame 记录客户端首帧的来源
+func (m *ActiveConnectionsManager) UpdateFirstFrame(connID string, source string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.conns[connID]; ok {
+		c.FirstFrame = source
+	}
+}
+
+// UpdateChecksum 更新客户端的滚动校验值
+func (m *ActiveConnectionsManager) UpdateChecksum(connID string, sum string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.conns[connID]; ok {
+		c.Checksum = sum
+	}
+}
+
+// UpdateThrottle 更新客户端的限速配置与状态
+func (m *ActiveConnectionsManager) UpdateThrottle(connID string, limit string, throttled bool, dropped uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.conns[connID]; ok {
+		c.RateLimit = limit
+		c.Throttled = throttled
+		c.DroppedBytes = dropped
+	}
+}
+
+// Peak 返回历史最大并发客户端数及达到的时间
+func (m *ActiveConnectionsManager) Peak() (int, time.Time) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.peak, m.peakAt
+}
+
+// restorePeak 从持久化状态恢复峰值，仅在大于当前峰值时生效
+func (m *ActiveConnectionsManager) restorePeak(n int, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n > m.peak {
+		m.peak, m.peakAt = n, at
+	}
+}
+
 // CleanInactiveConnections 清理不活跃连接
 func (m *ActiveConnectionsManager) CleanInactiveConnections(timeout time.Duration) {
 	m.mu.Lock()
@@ -0,0 +1,136 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// 告警级别
+const (
+	AlarmCritical = "critical"
+	AlarmWarning  = "warning"
+)
+
+const (
+	diskAlarmPercent    = 90   // 分区使用率告警阈值 (%)
+	diskCriticalPercent = 95   // 分区使用率严重阈值 (%)
+	fillAlarmRatio      = 0.95 // 客户端通道持续接近满（即将丢帧）的填充度阈值
+	dropAlarmRate       = 10.0 // 网卡接收丢包告警阈值 (包/秒)
+	dropSampleInterval  = 10 * time.Second
+)
+
+// Alarm 由现有监控信号汇总出的活动告警
+type Alarm struct {
+	Severity string // critical / warning
+	Kind     string // no_data / fallback / proxy_dead / proxy_group_down / disk / drops / slow_clients / fd
+	Target   string // 告警对象：HubKey、代理组/代理名、挂载点、网卡名等
+	Message  string
+}
+
+// buildAlarms 汇总源无数据、组播回退、代理失效、磁盘使用率、丢包等信号，严重告警排在前面
+func buildAlarms(hubs []HubStatus, proxyGroups map[string]*config.ProxyGroupConfig, ts *TrafficStats, fdWarning bool) []Alarm {
+	var alarms []Alarm
+	add := func(severity, kind, target, format string, args ...any) {
+		alarms = append(alarms, Alarm{Severity: severity, Kind: kind, Target: target, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, h := range hubs {
+		if h.ClientCount > 0 && h.Bitrate == 0 {
+			add(AlarmCritical, "no_data", h.Key, "源 %s 有 %d 个观众但未收到数据", h.Addr, h.ClientCount)
+		}
+		if h.IfacesLost {
+			add(AlarmCritical, "fallback", h.Key, "组播 %s 配置的网卡 %s 均不存在，已回退为普通 UDP 监听", h.Addr, strings.Join(h.Ifaces, ","))
+		} else if h.Source == "udp" && !h.IsMulticast && isMulticastAddr(h.Addr) {
+			add(AlarmWarning, "fallback", h.Key, "组播 %s 加入失败，已回退为普通 UDP 监听", h.Addr)
+		}
+		if h.HasFill && h.FillMax >= fillAlarmRatio {
+			add(AlarmWarning, "slow_clients", h.Key, "源 %s 的客户端通道接近满（最大填充 %.0f%%），可能正在丢帧", h.Addr, h.FillMax*100)
+		}
+	}
+
+	now := time.Now()
+	for name, group := range proxyGroups {
+		if len(group.Proxies) == 0 {
+			continue
+		}
+		dead := 0
+		for _, p := range group.Proxies {
+			stats := group.Stats.ProxyStats[p.Name]
+			// 尚未测速的代理不视为失效
+			if stats == nil || stats.LastCheck.IsZero() {
+				continue
+			}
+			if !stats.Alive || now.Before(stats.CooldownUntil) {
+				dead++
+				add(AlarmWarning, "proxy_dead", name+"/"+p.Name, "代理组 %s 的代理 %s 不可用（连续失败 %d 次）", name, p.Name, stats.FailCount)
+			}
+		}
+		if dead == len(group.Proxies) {
+			add(AlarmCritical, "proxy_group_down", name, "代理组 %s 的全部 %d 个代理均不可用", name, dead)
+		}
+	}
+
+	if ts != nil {
+		for _, p := range ts.DiskPartitions {
+			switch {
+			case p.UsedPercent >= diskCriticalPercent:
+				add(AlarmCritical, "disk", p.MountPoint, "分区 %s 使用率 %.1f%%", p.MountPoint, p.UsedPercent)
+			case p.UsedPercent >= diskAlarmPercent:
+				add(AlarmWarning, "disk", p.MountPoint, "分区 %s 使用率 %.1f%%", p.MountPoint, p.UsedPercent)
+			}
+		}
+		for name, rate := range ifaceDropRates(ts.NetworkInterfaces, now) {
+			if rate >= dropAlarmRate {
+				add(AlarmWarning, "drops", name, "网卡 %s 接收丢包 %.0f 包/秒", name, rate)
+			}
+		}
+	}
+
+	if fdWarning {
+		add(AlarmWarning, "fd", "", "文件描述符使用率接近上限")
+	}
+
+	sort.SliceStable(alarms, func(i, j int) bool {
+		if alarms[i].Severity != alarms[j].Severity {
+			return alarms[i].Severity == AlarmCritical
+		}
+		if alarms[i].Kind != alarms[j].Kind {
+			return alarms[i].Kind < alarms[j].Kind
+		}
+		return alarms[i].Target < alarms[j].Target
+	})
+	return alarms
+}
+
+// 网卡丢包计数为自启动以来的累计值，按至少 dropSampleInterval 的采样间隔换算为速率
+var (
+	dropSampleMu  sync.Mutex
+	dropSample    map[string]uint64
+	dropSampledAt time.Time
+	lastDropRates map[string]float64
+)
+
+// ifaceDropRates 返回各网卡最近一个采样间隔内的接收丢包速率 (包/秒)
+func ifaceDropRates(ifaces []NetworkInterfaceInfo, now time.Time) map[string]float64 {
+	dropSampleMu.Lock()
+	defer dropSampleMu.Unlock()
+	elapsed := now.Sub(dropSampledAt)
+	if dropSample != nil && elapsed < dropSampleInterval {
+		return lastDropRates
+	}
+	cur := make(map[string]uint64, len(ifaces))
+	rates := make(map[string]float64)
+	for _, ni := range ifaces {
+		cur[ni.Name] = ni.DropsRecv
+		if prev, ok := dropSample[ni.Name]; ok && ni.DropsRecv > prev {
+			rates[ni.Name] = float64(ni.DropsRecv-prev) / elapsed.Seconds()
+		}
+	}
+	dropSample, dropSampledAt, lastDropRates = cur, now, rates
+	return rates
+}
@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// APIError JSON 错误响应体：{"error":{"code":...,"message":...}}
+type APIError struct {
+	Error APIErrorBody `json:"error"`
+}
+
+// APIErrorBody 错误详情，code 为由状态码得到的 snake_case 标识（如 too_many_requests）
+type APIErrorBody struct {
+	Code    string `json:"code"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// errorCode 由 HTTP 状态码生成错误标识：429 → too_many_requests
+func errorCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(strings.ReplaceAll(text, "-", " ")), " ", "_")
+}
+
+// WantsJSON 请求是否期望 JSON 响应（Accept 包含 application/json 或 ?format=json）
+func WantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json") || r.URL.Query().Get("format") == "json"
+}
+
+// WriteJSONError 输出结构化 JSON 错误，用于始终返回 JSON 的接口（管理接口等）
+func WriteJSONError(w http.ResponseWriter, status int, message string) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(APIError{Error: APIErrorBody{Code: errorCode(status), Status: status, Message: message}})
+}
+
+// WriteError 按请求协商错误格式：期望 JSON 时输出结构化 JSON，否则输出便于阅读的纯文本
+func WriteError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if WantsJSON(r) {
+		WriteJSONError(w, status, message)
+		return
+	}
+	http.Error(w, message, status)
+}
+
+// WriteInitializing 配置尚未完成首次加载时返回 503（带 Retry-After），避免访问未初始化的配置
+func WriteInitializing(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("server", "TVGate")
+	w.Header().Set("Retry-After", "1")
+	WriteError(w, r, http.StatusServiceUnavailable, "TVGate 正在初始化，请稍后重试")
+}
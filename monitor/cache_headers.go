@@ -0,0 +1,23 @@
+package monitor
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/qist/tvgate/config"
+)
+
+// setCacheHeaders 设置监控响应的缓存控制头，避免前置缓存返回过期数据或错误格式；
+// vary 列出影响响应内容的请求头
+func setCacheHeaders(w http.ResponseWriter, vary ...string) {
+	config.CfgMu.RLock()
+	cc := config.Cfg.Monitor.CacheControl
+	config.CfgMu.RUnlock()
+	if cc == "" {
+		cc = "no-store"
+	}
+	w.Header().Set("Cache-Control", cc)
+	if len(vary) > 0 {
+		w.Header().Set("Vary", strings.Join(vary, ", "))
+	}
+}
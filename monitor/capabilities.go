@@ -0,0 +1,142 @@
+package monitor
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/qist/tvgate/config"
+)
+
+// StatusFormat 状态接口支持的一种输出格式及选择方式
+type StatusFormat struct {
+	Name   string // html / json / text / prometheus
+	URL    string
+	Select string // 选择方式，如 ?format=json 或 Accept 头
+}
+
+// ChannelOutput 频道的一种输出方式
+type ChannelOutput struct {
+	Mode string // http / tcp
+	URL  string // HTTP 访问路径
+	Addr string // TCP 监听地址
+}
+
+// ChannelCapabilities 单个频道可用的输出方式
+type ChannelCapabilities struct {
+	Path        string
+	Source      string // udp / http
+	ContentType string
+	Outputs     []ChannelOutput
+}
+
+// Capabilities 供客户端发现的能力清单
+type Capabilities struct {
+	Version            string
+	StatusFormats      []StatusFormat
+	StreamContentTypes []string // 流响应可协商的 Content-Type（?content_type= 或 Accept）
+	SourceTypes        []string // 支持的源类型
+	OutputModes        []string // 当前配置可用的输出方式
+	Channels           []ChannelCapabilities
+}
+
+var (
+	streamContentTypes   []string
+	streamContentTypesMu sync.RWMutex
+)
+
+// RegisterStreamContentTypes 由流转发模块注册可协商的 Content-Type
+func RegisterStreamContentTypes(types ...string) {
+	streamContentTypesMu.Lock()
+	defer streamContentTypesMu.Unlock()
+	streamContentTypes = append([]string(nil), types...)
+	sort.Strings(streamContentTypes)
+}
+
+// buildCapabilities 根据当前配置汇总能力清单
+func buildCapabilities() Capabilities {
+	base := monitorBasePath()
+	statusURL := base
+	if statusURL == "" {
+		statusURL = "/"
+	}
+
+	streamContentTypesMu.RLock()
+	types := append([]string(nil), streamContentTypes...)
+	streamContentTypesMu.RUnlock()
+
+	caps := Capabilities{
+		Version: config.Version,
+		StatusFormats: []StatusFormat{
+			{Name: "html", URL: statusURL, Select: "默认"},
+			{Name: "json", URL: statusURL, Select: "?format=json 或 Accept: application/json"},
+			{Name: "text", URL: statusURL, Select: "?format=text 或 Accept: text/plain"},
+			{Name: "msgpack", URL: statusURL, Select: "?format=msgpack 或 Accept: application/msgpack（4 字节大端长度前缀 + MessagePack）"},
+			{Name: "prometheus", URL: base + "/metrics", Select: "固定路径"},
+		},
+		StreamContentTypes: types,
+		SourceTypes:        []string{"udp", "rtp", "http"},
+		OutputModes:        []string{"http"},
+	}
+
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+
+	// 频道路径 / 源地址 → TCP 输出监听地址
+	tcpByChannel := make(map[string][]string)
+	tcpByAddr := make(map[string][]string)
+	for _, o := range config.Cfg.TCPOutputs {
+		if o == nil || o.Listen == "" {
+			continue
+		}
+		if o.Channel != "" {
+			tcpByChannel[o.Channel] = append(tcpByChannel[o.Channel], o.Listen)
+		} else if o.UDPAddr != "" {
+			tcpByAddr[o.UDPAddr] = append(tcpByAddr[o.UDPAddr], o.Listen)
+		}
+	}
+	if len(tcpByChannel)+len(tcpByAddr) > 0 {
+		caps.OutputModes = append(caps.OutputModes, "tcp")
+	}
+	if config.Cfg.Web.Enabled {
+		caps.OutputModes = append(caps.OutputModes, "recording")
+	}
+
+	for _, ch := range config.Cfg.Channels {
+		if ch == nil || ch.Path == "" {
+			continue
+		}
+		cc := ChannelCapabilities{
+			Path:        ch.Path,
+			Source:      "udp",
+			ContentType: ch.ContentType,
+			Outputs:     []ChannelOutput{{Mode: "http", URL: ch.Path}},
+		}
+		if strings.HasPrefix(ch.UDPAddr, "http://") || strings.HasPrefix(ch.UDPAddr, "https://") {
+			cc.Source = "http"
+		}
+		if cc.ContentType == "" {
+			cc.ContentType = "video/mp2t"
+		}
+		for _, listen := range tcpByChannel[ch.Path] {
+			cc.Outputs = append(cc.Outputs, ChannelOutput{Mode: "tcp", Addr: listen})
+		}
+		for _, listen := range tcpByAddr[ch.UDPAddr] {
+			cc.Outputs = append(cc.Outputs, ChannelOutput{Mode: "tcp", Addr: listen})
+		}
+		caps.Channels = append(caps.Channels, cc)
+	}
+	if caps.Channels == nil {
+		caps.Channels = []ChannelCapabilities{}
+	}
+	return caps
+}
+
+// HandleCapabilities 输出机器可读的能力清单：状态接口格式、流 Content-Type 与各频道的输出方式
+func HandleCapabilities(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("server", "TVGate")
+	w.Header().Set("Content-Type", "application/json")
+	setCacheHeaders(w)
+	encodeStatusJSON(w, buildCapabilities(), jsonNaming(r))
+}
@@ -0,0 +1,45 @@
+package monitor
+
+import "sync"
+
+var (
+	channelBytesMu sync.Mutex
+	// channelBytes 已关闭 Hub 的累计接收字节，按源地址汇总（含持久化恢复的历史值）
+	channelBytes = make(map[string]uint64)
+)
+
+// AddChannelBytes 将 Hub 的累计接收字节并入频道总量（由 stream 在 Hub 关闭时调用）
+func AddChannelBytes(addr string, n uint64) {
+	if n == 0 {
+		return
+	}
+	channelBytesMu.Lock()
+	channelBytes[addr] += n
+	channelBytesMu.Unlock()
+}
+
+// ChannelTotalBytes 返回各源地址的累计接收字节：历史总量 + 当前 Hub 的接收量
+func ChannelTotalBytes() map[string]uint64 {
+	hubs := GetHubStatuses()
+
+	channelBytesMu.Lock()
+	totals := make(map[string]uint64, len(channelBytes)+len(hubs))
+	for addr, n := range channelBytes {
+		totals[addr] = n
+	}
+	channelBytesMu.Unlock()
+
+	for _, h := range hubs {
+		totals[h.Addr] += h.TotalBytes
+	}
+	return totals
+}
+
+// restoreChannelBytes 从持久化状态恢复历史总量
+func restoreChannelBytes(saved map[string]uint64) {
+	channelBytesMu.Lock()
+	defer channelBytesMu.Unlock()
+	for addr, n := range saved {
+		channelBytes[addr] += n
+	}
+}
@@ -0,0 +1,184 @@
+package monitor
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/qist/tvgate/config"
+)
+
+// untaggedGroup 未配置标签的频道所在分组
+const untaggedGroup = "未分组"
+
+// 频道运行状态
+const (
+	ChannelActive = "active" // 存在 Hub 且正常接收
+	ChannelIdle   = "idle"   // 无观众，未创建 Hub
+	ChannelError  = "error"  // 存在 Hub 但组播加入失败回退，或有观众却收不到源数据
+)
+
+// ChannelStatus 频道路由表中单个频道的状态
+type ChannelStatus struct {
+	Path    string
+	UDPAddr string
+	Tags    []string
+	Viewers int
+	// 预览变体（?variant=preview）订阅数，不计入 Viewers
+	PreviewViewers int
+
+	Status  string // active / idle / error
+	Error   string // error 状态的原因
+	HubKey  string // 对应的 Hub，idle 时为空
+	Bitrate uint64 // 源入流码率 (bytes/s)
+}
+
+// ChannelGroup 按标签分组的频道（一个频道有多个标签时出现在多个分组中）
+type ChannelGroup struct {
+	Tag      string
+	Channels []ChannelStatus
+}
+
+// buildChannelInventory 根据频道配置、当前 Hub 与观看人数生成频道列表、标签分组及全部标签；
+// tag 非空时仅保留带该标签的频道
+func buildChannelInventory(viewers, previews map[string]int, hubs []HubStatus, tag string) ([]ChannelStatus, []ChannelGroup, []string) {
+	config.CfgMu.RLock()
+	defIfaces := config.Cfg.Server.MulticastIfaces
+	defLocalAddr := config.Cfg.Server.MulticastLocalAddr
+	var channels []ChannelStatus
+	tagSet := make(map[string]struct{})
+	for _, ch := range config.Cfg.Channels {
+		if ch == nil || ch.Path == "" {
+			continue
+		}
+		for _, t := range ch.Tags {
+			tagSet[t] = struct{}{}
+		}
+		if tag != "" && !hasTag(ch.Tags, tag) {
+			continue
+		}
+		cs := ChannelStatus{
+			Path:    ch.Path,
+			UDPAddr: ch.UDPAddr,
+			Tags:    append([]string(nil), ch.Tags...),
+			Viewers: viewers[ch.Path],
+			Status:  ChannelIdle,
+
+			PreviewViewers: previews[ch.Path],
+		}
+		ifaces, localAddr := ch.Ifaces, ch.LocalAddr
+		if len(ifaces) == 0 {
+			ifaces = defIfaces
+		}
+		if localAddr == "" {
+			localAddr = defLocalAddr
+		}
+		if hub, ok := findChannelHub(hubs, ch.SourceAddr(), ifaces, localAddr); ok {
+			cs.HubKey = hub.Key
+			cs.Bitrate = hub.Bitrate
+			cs.Status = ChannelActive
+			switch {
+			case hub.Source == "udp" && !hub.IsMulticast && isMulticastAddr(ch.UDPAddr):
+				cs.Status, cs.Error = ChannelError, "组播加入失败，已回退为普通 UDP 监听"
+			case hub.ClientCount > 0 && hub.Bitrate == 0:
+				cs.Status, cs.Error = ChannelError, "有观众但未收到源数据"
+			}
+		}
+		channels = append(channels, cs)
+	}
+	config.CfgMu.RUnlock()
+
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Path < channels[j].Path })
+
+	allTags := make([]string, 0, len(tagSet))
+	for t := range tagSet {
+		allTags = append(allTags, t)
+	}
+	sort.Strings(allTags)
+
+	byTag := make(map[string][]ChannelStatus)
+	for _, ch := range channels {
+		if len(ch.Tags) == 0 {
+			byTag[untaggedGroup] = append(byTag[untaggedGroup], ch)
+			continue
+		}
+		for _, t := range ch.Tags {
+			if tag != "" && t != tag {
+				continue
+			}
+			byTag[t] = append(byTag[t], ch)
+		}
+	}
+	groups := make([]ChannelGroup, 0, len(byTag))
+	for _, t := range allTags {
+		if chs, ok := byTag[t]; ok {
+			groups = append(groups, ChannelGroup{Tag: t, Channels: chs})
+		}
+	}
+	if chs, ok := byTag[untaggedGroup]; ok {
+		groups = append(groups, ChannelGroup{Tag: untaggedGroup, Channels: chs})
+	}
+	return channels, groups, allTags
+}
+
+// findChannelHub 按源地址、网卡与本地地址查找频道对应的 Hub
+func findChannelHub(hubs []HubStatus, addr string, ifaces []string, localAddr string) (HubStatus, bool) {
+	for _, h := range hubs {
+		if h.Source == "http" && h.Addr == addr {
+			// HTTP 拉流不区分网卡与本地地址
+			return h, true
+		}
+		if h.Source == "unicast" && h.Addr == addr && h.LocalAddr == localAddr {
+			// 单播不区分网卡
+			return h, true
+		}
+		if h.Addr == addr && h.LocalAddr == localAddr && strings.Join(h.Ifaces, ",") == strings.Join(ifaces, ",") {
+			return h, true
+		}
+	}
+	return HubStatus{}, false
+}
+
+func isMulticastAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsMulticast()
+}
+
+// HandleChannels 以 JSON 返回全部已配置频道（包括当前无 Hub 的空闲频道）及其状态；
+// ?tag= 按标签过滤，?status= 按 active/idle/error 过滤
+func HandleChannels(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("server", "TVGate")
+	w.Header().Set("Content-Type", "application/json")
+	setCacheHeaders(w)
+
+	q := r.URL.Query()
+	clients := ActiveClients.GetAll()
+	channels, _, _ := buildChannelInventory(countChannelViewers(clients), countPreviewViewers(clients), GetHubStatuses(), strings.TrimSpace(q.Get("tag")))
+	if status := strings.TrimSpace(q.Get("status")); status != "" {
+		filtered := channels[:0]
+		for _, ch := range channels {
+			if ch.Status == status {
+				filtered = append(filtered, ch)
+			}
+		}
+		channels = filtered
+	}
+	if channels == nil {
+		channels = []ChannelStatus{}
+	}
+	encodeStatusJSON(w, channels, jsonNaming(r))
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package monitor
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/qist/tvgate/config"
+)
+
+// defaultClientIPHeaders 未配置 server.client_ip_headers 时的查找顺序
+var defaultClientIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+
+// GetClientIP 识别客户端 IP：来源地址可信时按 server.client_ip_headers 顺序查找请求头，
+// 取第一个合法 IP（列表型请求头取最左侧），均无效时回退为连接的来源地址
+func GetClientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+
+	config.CfgMu.RLock()
+	headers := config.Cfg.Server.ClientIPHeaders
+	trusted := config.Cfg.Server.TrustedProxies
+	config.CfgMu.RUnlock()
+	if len(headers) == 0 {
+		headers = defaultClientIPHeaders
+	}
+
+	if !isTrustedProxy(remote, trusted) {
+		return remote
+	}
+	for _, name := range headers {
+		v := r.Header.Get(name)
+		if v == "" {
+			continue
+		}
+		if ip := parseHeaderIP(strings.Split(v, ",")[0]); ip != "" {
+			return ip
+		}
+	}
+	return remote
+}
+
+// parseHeaderIP 解析请求头中的 IP，允许带端口或 IPv6 方括号，非法时返回空串
+func parseHeaderIP(v string) string {
+	v = strings.TrimSpace(v)
+	if host, _, err := net.SplitHostPort(v); err == nil {
+		v = host
+	}
+	v = strings.Trim(v, "[]")
+	if ip := net.ParseIP(v); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// isTrustedProxy 来源地址是否属于可信代理列表（IP 或 CIDR），列表为空时全部可信
+func isTrustedProxy(remote string, trusted []string) bool {
+	if len(trusted) == 0 {
+		return true
+	}
+	ip := net.ParseIP(remote)
+	if ip == nil {
+		return false
+	}
+	for _, t := range trusted {
+		if _, ipNet, err := net.ParseCIDR(t); err == nil {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		} else if tip := net.ParseIP(t); tip != nil && tip.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
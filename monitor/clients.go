@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// countChannelViewers 按频道统计完整流观看人数（仅统计已关联频道的客户端，不含预览订阅）
+func countChannelViewers(clients []*ClientConnection) map[string]int {
+	return countChannelVariant(clients, "")
+}
+
+// countPreviewViewers 按频道统计预览变体订阅数
+func countPreviewViewers(clients []*ClientConnection) map[string]int {
+	return countChannelVariant(clients, VariantPreview)
+}
+
+func countChannelVariant(clients []*ClientConnection, variant string) map[string]int {
+	counts := make(map[string]int)
+	for _, c := range clients {
+		if c.Channel != "" && c.Variant == variant {
+			counts[c.Channel]++
+		}
+	}
+	return counts
+}
+
+// HandleClients 以 JSON 返回活跃客户端；?hub= 指定 HubKey、频道名或组播地址时仅返回该频道的客户端
+func HandleClients(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("server", "TVGate")
+	w.Header().Set("Content-Type", "application/json")
+	setCacheHeaders(w)
+
+	var clients []*ClientConnection
+	if hub := strings.TrimSpace(r.URL.Query().Get("hub")); hub != "" {
+		clients = ActiveClients.GetByHub(hub)
+	} else {
+		clients = ActiveClients.GetAll()
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ConnectedAt.Before(clients[j].ConnectedAt)
+	})
+
+	encodeStatusJSON(w, clients, jsonNaming(r))
+}
@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// CollectorError 系统统计采集器的失败信息，采集恢复后自动清除
+type CollectorError struct {
+	Collector string // cpu/mem/disk/load/host/net/process
+	Error     string
+	Since     time.Time // 首次失败时间（连续失败期间不变）
+}
+
+var (
+	collectorErrs   = make(map[string]CollectorError)
+	collectorErrsMu sync.Mutex
+)
+
+// recordCollector 记录一次采集结果，err 为 nil 时清除该采集器的失败状态
+func recordCollector(name string, err error) {
+	collectorErrsMu.Lock()
+	defer collectorErrsMu.Unlock()
+	if err == nil {
+		delete(collectorErrs, name)
+		return
+	}
+	ce, ok := collectorErrs[name]
+	if !ok {
+		ce = CollectorError{Collector: name, Since: time.Now()}
+	}
+	ce.Error = err.Error()
+	collectorErrs[name] = ce
+}
+
+// DegradedCollectors 返回当前失败的采集器，按名称排序；为空表示系统统计完整
+func DegradedCollectors() []CollectorError {
+	collectorErrsMu.Lock()
+	defer collectorErrsMu.Unlock()
+	if len(collectorErrs) == 0 {
+		return nil
+	}
+	list := make([]CollectorError, 0, len(collectorErrs))
+	for _, ce := range collectorErrs {
+		list = append(list, ce)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Collector < list[j].Collector })
+	return list
+}
@@ -0,0 +1,54 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	// fastStartFrames 以秒开方式（缓存回放、迁移衔接帧）发送给客户端的帧数
+	fastStartFrames atomic.Uint64
+	// hubJoins 客户端加入 Hub 的次数（含迁移到新 Hub），与 fastStartFrames 对比可得秒开命中情况
+	hubJoins atomic.Uint64
+)
+
+// RecordFastStartFrames 记录一次加入或迁移时成功投递的秒开帧数
+func RecordFastStartFrames(n int) {
+	if n > 0 {
+		fastStartFrames.Add(uint64(n))
+	}
+}
+
+// RecordHubJoin 记录一次客户端加入 Hub
+func RecordHubJoin() {
+	hubJoins.Add(1)
+}
+
+// FastStartFrameCount 返回累计秒开帧数
+func FastStartFrameCount() uint64 {
+	return fastStartFrames.Load()
+}
+
+// HubJoinCount 返回累计加入 Hub 次数
+func HubJoinCount() uint64 {
+	return hubJoins.Load()
+}
+
+// writeCounter 输出单个 counter 指标；OpenMetrics 的指标族名不带 _total 后缀
+func writeCounter(w io.Writer, name, help string, value uint64, openMetrics bool) {
+	family := name
+	if openMetrics {
+		family = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", family, help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", family)
+	fmt.Fprintf(w, "%s %d\n", name, value)
+}
+
+// writeFastStartMetrics 输出秒开帧与加入次数计数
+func writeFastStartMetrics(w io.Writer, openMetrics bool) {
+	writeCounter(w, "tvgate_fast_start_frames_total", "Frames replayed to clients as fast-start on join or hub transfer.", FastStartFrameCount(), openMetrics)
+	writeCounter(w, "tvgate_hub_joins_total", "Client joins to channel hubs, including hub transfers.", HubJoinCount(), openMetrics)
+}
@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/qist/tvgate/config"
+)
+
+// BuildInfo 运行中实例的构建信息
+type BuildInfo struct {
+	Version   string
+	GoVersion string
+	GOOS      string
+	GOARCH    string
+	Revision  string // VCS 提交（go build 自动嵌入，不可用时为空）
+	Modified  bool   // 构建时工作区是否有未提交修改
+}
+
+// FeatureState 单项能力的编译与启用状态
+type FeatureState struct {
+	Compiled bool   // 当前构建/平台是否支持
+	Enabled  bool   // 当前配置是否启用
+	Detail   string // 补充说明，如 metrics 路径、h2c
+}
+
+// getBuildInfo 读取版本号及 Go 嵌入的构建信息
+func getBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   config.Version,
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.Revision = s.Value
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// getFeatures 汇总各项能力的编译与配置状态，便于远程排查
+func getFeatures() map[string]FeatureState {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	c := &config.Cfg
+
+	tls := c.Server.CertFile != "" && c.Server.KeyFile != ""
+	h2Detail := ""
+	if !tls && c.Server.H2C {
+		h2Detail = "h2c"
+	}
+	previews := 0
+	for _, ch := range c.Channels {
+		if ch != nil && ch.Preview.Enabled {
+			previews++
+		}
+	}
+	return map[string]FeatureState{
+		"tls":             {Compiled: true, Enabled: tls},
+		"http2":           {Compiled: true, Enabled: tls || c.Server.H2C, Detail: h2Detail},
+		"http3":           {Compiled: true, Enabled: tls},
+		"metrics":         {Compiled: true, Enabled: c.Monitor.Path != "", Detail: c.Monitor.Path + "/metrics"},
+		"web":             {Compiled: true, Enabled: c.Web.Enabled},
+		"global_auth":     {Compiled: true, Enabled: c.GlobalAuth.TokensEnabled},
+		"jx":              {Compiled: true, Enabled: c.JX.Path != ""},
+		"domainmap":       {Compiled: true, Enabled: len(c.DomainMap) > 0},
+		"proxy_groups":    {Compiled: true, Enabled: len(c.ProxyGroups) > 0},
+		"channels":        {Compiled: true, Enabled: len(c.Channels) > 0},
+		"rx_timestamps":   {Compiled: runtime.GOOS == "linux", Enabled: runtime.GOOS == "linux"},
+		"dscp":            {Compiled: true, Enabled: c.Server.DSCP > 0},
+		"jitter_buffer":   {Compiled: true, Enabled: c.Stream.JitterBufferFrames > 0},
+		"keepalive":       {Compiled: true, Enabled: c.Stream.KeepaliveInterval > 0},
+		"psi_replay":      {Compiled: true, Enabled: c.Stream.PSIReplay},
+		"client_checksum": {Compiled: true, Enabled: c.Stream.ClientChecksum},
+		"preview":         {Compiled: true, Enabled: previews > 0, Detail: "ffmpeg"},
+		"transcode":       {Compiled: false, Enabled: false},
+	}
+}
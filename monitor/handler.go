@@ -1,10 +1,9 @@
 package monitor
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
 	"html/template"
-	"net"
 	"net/http"
 	"runtime"
 	"strings"
@@ -15,38 +14,127 @@ import (
 
 // 页面数据结构
 type StatusData struct {
-	Timestamp     time.Time
-	Uptime        time.Duration
-	Version       string
+	Timestamp   time.Time
+	Uptime      time.Duration
+	UptimeHuman string
+	Version     string
+	Build       BuildInfo
+	// 各项能力的编译/启用状态（tls、metrics、transcode 等）
+	Features      map[string]FeatureState
 	Goroutines    int
 	MemoryStats   runtime.MemStats
 	ProxyGroups   map[string]*config.ProxyGroupConfig
 	TrafficStats  *TrafficStats
 	ClientIP      string
 	ActiveClients []*ClientConnection
-	WebPath       string
+	// 最近结束的客户端会话（时长与发送字节数）
+	RecentSessions []SessionRecord
+	// 历史最大并发客户端数及时间（启用 state_file 时跨重启保留）
+	PeakClients   int
+	PeakClientsAt time.Time
+	// 各源地址的累计接收字节
+	ChannelBytes map[string]uint64
+	// 并发流连接数最多的客户端 IP
+	TopClientIPs []IPConnCount
+	// 各播放器类型的客户端数量
+	PlayerCategories map[string]int
+	// 各频道的观看人数
+	ChannelViewers map[string]int
+	// 各频道预览变体（?variant=preview）订阅数，不计入 ChannelViewers
+	PreviewViewers map[string]int
+	// 所有频道 Hub 上的观看人数总和
+	TotalViewers int
+	// 频道路由表（含标签），?tag= 筛选后的结果
+	Channels []ChannelStatus
+	// 按标签分组的频道、全部标签及当前筛选标签（仅 HTML 使用）
+	ChannelGroups []ChannelGroup `json:"-"`
+	ChannelTags   []string       `json:"-"`
+	ChannelTag    string         `json:"-"`
+	Hubs          []HubStatus
+	// 正在进行的按需录制
+	Recordings []RecordingStatus
+	// 代理主机名解析缓存（proxy_dns 启用时）
+	ProxyDNS  []ProxyDNSStatus
+	FDWarning bool // 文件描述符使用率超过告警阈值
+	// 因内存超过 stream.max_memory_mb 而拒绝的新流连接数
+	MemoryShed uint64
+	// 累计秒开帧数与加入 Hub 次数，用于评估秒开命中率
+	FastStartFrames uint64
+	HubJoins        uint64
+	// 采集失败的系统统计子系统，非空时页面显示降级提示
+	Degraded []CollectorError
+	// 活动告警（源无数据、组播回退、代理失效、磁盘、丢包等），非空时页面顶部显示横幅
+	Alarms  []Alarm
+	WebPath string
+	// 静态页面：不输出自动刷新脚本与控件（无障碍/外部工具自行轮询）
+	Static bool `json:"-"`
+	// 自动刷新间隔选项及服务端强制的最小间隔
+	RefreshOptions []time.Duration `json:"-"`
+	MinRefresh     time.Duration   `json:"-"`
 }
 
-// HTTP 处理入口
+// HTTP 处理入口（受 monitor.max_concurrent 并发限制）
 func HandleMonitor(w http.ResponseWriter, r *http.Request) {
+	if !config.Loaded() {
+		WriteInitializing(w, r)
+		return
+	}
+	limitMonitor(dispatchMonitor)(w, r)
+}
+
+// dispatchMonitor 监控路径下的子路径路由
+func dispatchMonitor(w http.ResponseWriter, r *http.Request) {
+	sub := strings.TrimPrefix(r.URL.Path, monitorBasePath())
+	if sub == "/" {
+		sub = ""
+	}
+	for _, ep := range monitorEndpoints {
+		if ep.Path == sub {
+			if !ep.allows(r.Method) {
+				handleMethodNotAllowed(w, r, ep)
+				return
+			}
+			ep.handler(w, r)
+			return
+		}
+	}
+	handleMonitorNotFound(w, r)
+}
+
+// handleStatusPage 状态页面（HTML / JSON / MessagePack / 纯文本）
+func handleStatusPage(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("server", "TVGate")
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// 同一路径按 Accept/语言返回 HTML、JSON、MessagePack 或纯文本，缓存必须区分
+	setCacheHeaders(w, "Accept", "Accept-Language")
+	r = withServerTiming(r)
+	if wantsMsgpack(r) {
+		handleMsgpackRequest(w, r)
+		return
+	}
 	if r.Header.Get("Accept") == "application/json" || r.URL.Query().Get("format") == "json" {
 		handleJSONRequest(w, r)
 		return
 	}
+	if wantsTextSummary(r) {
+		handleTextRequest(w, r)
+		return
+	}
 	handleHTMLRequest(w, r)
 }
 
 func handleJSONRequest(w http.ResponseWriter, r *http.Request) {
 	data := prepareStatusData(r)
+	fillHumanFields(&data)
 	w.Header().Set("server", "TVGate")
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(data)
+	timingOf(r).write(w)
+	encodeStatusJSON(w, data, jsonNaming(r))
 }
 
 func handleHTMLRequest(w http.ResponseWriter, r *http.Request) {
 	data := prepareStatusData(r)
+	renderStart := time.Now()
 
 	tmpl := `<!DOCTYPE html>
 <html>
@@ -130,18 +218,34 @@ body {
 <p>更新时间: {{.Timestamp.Format "2006-01-02 15:04:05"}}</p>
 </div>
 
+{{if .Alarms}}
+<div class="card" style="border-left: 4px solid #f44336; margin-bottom: 15px;">
+<strong class="status-dead">🚨 活动告警 ({{len .Alarms}})</strong>
+<ul style="margin: 8px 0 0 0;">
+{{range .Alarms}}<li><span class="{{if eq .Severity "critical"}}status-dead{{else}}status-cooldown{{end}}">{{if eq .Severity "critical"}}严重{{else}}警告{{end}}</span> {{.Message}}{{if .Target}} <small style="color:#aaa;">{{.Target}}</small>{{end}}</li>
+{{end}}</ul>
+</div>
+{{end}}
+
+{{if .Degraded}}
+<div class="card" style="border-left: 4px solid #ff9800; margin-bottom: 15px;">
+<strong class="status-cooldown">⚠️ 部分系统统计采集失败，以下数据可能缺失或过期：</strong>
+<ul style="margin: 8px 0 0 0;">
+{{range .Degraded}}<li><strong>{{.Collector}}</strong>: {{.Error}}（自 {{.Since.Format "15:04:05"}}）</li>
+{{end}}</ul>
+</div>
+{{end}}
+
+{{if not .Static}}
 <div class="refresh-controls">
 <button id="toggleRefresh" class="refresh-btn">⟳ 自动刷新</button>
 <label for="interval">间隔:</label>
 <select id="interval">
-<option value="1000">1s</option>
-<option value="3000">3s</option>
-<option value="5000">5s</option>
-<option value="10000">10s</option>
-<option value="30000">30s</option>
-</select>
+{{range .RefreshOptions}}<option value="{{.Milliseconds}}">{{.}}</option>
+{{end}}</select>
 <button id="toggleTheme" class="theme-btn">🌓 切换主题</button>
 </div>
+{{end}}
 
 <h2>系统信息</h2>
 <div style="display: grid; grid-template-columns: repeat(auto-fit, minmax(250px, 1fr)); gap: 15px; margin-bottom: 20px;">
@@ -173,6 +277,7 @@ body {
       <li><strong>出口流量:</strong> {{FormatBytes .TrafficStats.OutboundBytes}}</li>
       <li><strong>实时总带宽(入):</strong> {{FormatNetworkBandwidth .TrafficStats.InboundBandwidth}}</li>
       <li><strong>实时总带宽(出):</strong> {{FormatNetworkBandwidth .TrafficStats.OutboundBandwidth}}</li>
+      {{if not .TrafficStats.CountersResetAt.IsZero}}<li><small style="color:#aaa;">累计流量清零于 {{.TrafficStats.CountersResetAt.Format "2006-01-02 15:04:05"}}</small></li>{{end}}
     </ul>
   </div>
   
@@ -192,7 +297,9 @@ body {
     <h3>TVGate监控</h3>
     <ul style="list-style: none; padding: 0;">
       <li><strong>CPU:</strong> {{printf "%.2f%%" .TrafficStats.App.CPUPercent}} <small style="color:#aaa; font-size:10px;">（多核 CPU 时可能超过 100%）</small></li>
-      <li><strong>内存:</strong> {{FormatBytes .TrafficStats.App.MemoryUsage}}</li>
+      <li><strong>内存:</strong> {{FormatBytes .TrafficStats.App.MemoryUsage}}{{if .MemoryShed}} <span class="status-dead" title="内存超过 stream.max_memory_mb 时拒绝的新流连接">⚠️ 已拒绝 {{.MemoryShed}} 个连接</span>{{end}}</li>
+      <li><strong>观看人数:</strong> {{.TotalViewers}}{{if .HubJoins}} <small title="累计加入 Hub 次数（含迁移）/ 以秒开方式发送的帧数">（加入 {{.HubJoins}} 次，秒开帧 {{.FastStartFrames}}）</small>{{end}}</li>
+      {{if gt .TrafficStats.App.MaxFDs 0}}<li><strong>文件描述符:</strong> {{.TrafficStats.App.OpenFDs}} / {{.TrafficStats.App.MaxFDs}}{{if .FDWarning}} <span class="status-dead">⚠️ 接近上限</span>{{end}}</li>{{end}}
     </ul>
   </div>
 </div>
@@ -238,6 +345,7 @@ body {
       <thead>
         <tr>
           <th>网卡</th>
+          <th>地址</th>
           <th>接收</th>
           <th>发送</th>
           <th>接收带宽</th>
@@ -248,6 +356,7 @@ body {
         {{range .TrafficStats.NetworkInterfaces}}
         <tr>
           <td>{{.Name}}</td>
+          <td>{{range $i, $a := .Addrs}}{{if $i}}<br>{{end}}{{$a}}{{else}}-{{end}}</td>
           <td>{{FormatBytes .BytesRecv}}</td>
           <td>{{FormatBytes .BytesSent}}</td>
           <td>{{FormatNetworkBandwidth .RecvBandwidth}}</td>
@@ -261,12 +370,19 @@ body {
 </div>
 
 <h2>活跃客户端连接</h2>
+{{if .PeakClients}}<p>峰值并发: {{.PeakClients}}（{{.PeakClientsAt.Format "2006-01-02 15:04:05"}}）</p>{{end}}
+{{if .PlayerCategories}}<p>{{range $cat, $n := .PlayerCategories}}<span style="margin-right:12px;"><strong>{{$cat}}:</strong> {{$n}}</span>{{end}}</p>{{end}}
+{{if .TopClientIPs}}<p>多连接 IP: {{range .TopClientIPs}}<span style="margin-right:12px;"><strong>{{.IP}}:</strong> {{.Count}}</span>{{end}}</p>{{end}}
+{{if .ChannelViewers}}<p>频道观众: {{range $ch, $n := .ChannelViewers}}<span style="margin-right:12px;"><strong>{{$ch}}:</strong> {{$n}}</span>{{end}}</p>{{end}}
+{{if .PreviewViewers}}<p>预览订阅: {{range $ch, $n := .PreviewViewers}}<span style="margin-right:12px;"><strong>{{$ch}}:</strong> {{$n}}</span>{{end}}</p>{{end}}
 <table class="table">
 <tr>
 <th style="width: 300px;">IP</th>
 <th style="width: 400px;">URL</th>
 <th style="width: 80px;">类型</th>
+<th style="width: 120px;">频道</th>
 <th style="width: 150px;">UA</th>
+<th style="width: 90px;">播放器</th>
 <th style="text-align:center; width: 80px;">连接时间</th>
 <th style="text-align:center; width: 80px;">最后活跃</th>
 </tr>
@@ -274,15 +390,154 @@ body {
 <tr>
 <td style="word-break: break-all;">{{.IP}}</td>
 <td class="url-cell" style="word-break: break-all;" title="{{.URL}}">{{.URL}}</td>
-<td>{{.ConnectionType}}</td>
+<td>{{.ConnectionType}}{{if .Proto}}<br><small style="color:{{if .LegacyTLS}}#e67e22{{else}}#aaa{{end}};" title="{{if .TLSCipher}}{{.TLSCipher}}{{else}}明文连接{{end}}">{{.Proto}}{{if .TLSVersion}} · {{.TLSVersion}}{{end}}{{if .LegacyTLS}} ⚠️{{end}}</small>{{end}}</td>
+<td title="{{.HubKey}}">{{if .Channel}}{{.Channel}}{{else}}-{{end}}{{if .Checksum}}<br><small style="color:#aaa;" title="最近 N 帧滚动 CRC32@已发送帧数">{{.Checksum}}</small>{{end}}{{if .RateLimit}}<br><small style="color:{{if .Throttled}}#e67e22{{else}}#aaa{{end}};" title="频道输出限速 max_mbps/rate_policy">限速 {{.RateLimit}}{{if .Throttled}} · 限速中{{end}}{{if .DroppedBytes}} · 丢弃 {{FormatBytes .DroppedBytes}}{{end}}</small>{{end}}</td>
 <td class="ua-cell" style="word-break: break-word;" title="{{.UserAgent}}">{{.UserAgent}}</td>
+<td>{{.PlayerCategory}}</td>
 <td style="text-align:center;">{{.ConnectedAt.Format "15:04:05"}}</td>
 <td style="text-align:center;">{{.LastActive.Format "15:04:05"}}</td>
 </tr>
 {{end}}
 </table>
 
+{{if .RecentSessions}}
+<details>
+<summary><strong>最近结束的会话</strong> ({{len .RecentSessions}})</summary>
+<table class="table">
+<tr>
+<th style="width: 200px;">IP</th>
+<th style="width: 200px;">频道</th>
+<th style="width: 80px;">类型</th>
+<th style="width: 150px;">UA</th>
+<th style="text-align:center; width: 80px;">连接时间</th>
+<th style="text-align:center; width: 80px;">断开时间</th>
+<th style="width: 100px;">时长</th>
+<th style="width: 100px;">发送</th>
+<th style="width: 80px;">首帧</th>
+</tr>
+{{range .RecentSessions}}
+<tr>
+<td style="word-break: break-all;">{{.IP}}</td>
+<td title="{{.HubKey}}">{{if .Channel}}{{.Channel}}{{else}}-{{end}}</td>
+<td>{{.ConnectionType}}</td>
+<td class="ua-cell" style="word-break: break-word;" title="{{.UserAgent}}">{{.UserAgent}}</td>
+<td style="text-align:center;">{{.ConnectedAt.Format "15:04:05"}}</td>
+<td style="text-align:center;">{{.DisconnectedAt.Format "15:04:05"}}</td>
+<td>{{.Duration}}</td>
+<td>{{FormatBytes .Bytes}}</td>
+<td>{{if eq .FirstFrame "fast-start"}}<span class="status-alive" title="首帧来自加入时回放的秒开缓存">秒开</span>{{else if eq .FirstFrame "live"}}<span title="首帧为加入后收到的实时包（无缓存或已关闭秒开）">实时</span>{{else}}-{{end}}</td>
+</tr>
+{{end}}
+</table>
+</details>
+{{end}}
+
+{{if or .Channels .ChannelTags}}{{$cur := .ChannelTag}}
+<h2>频道</h2>
+{{if .ChannelTags}}<p>标签筛选: <a href="?tag=" style="margin-right:10px;{{if not $cur}} font-weight:bold;{{end}}">全部</a>{{range .ChannelTags}}<a href="?tag={{.}}" style="margin-right:10px;{{if eq . $cur}} font-weight:bold;{{end}}">{{.}}</a>{{end}}</p>{{end}}
+{{range .ChannelGroups}}
+<details class="channel-group" data-tag="{{.Tag}}" open>
+<summary><strong>{{.Tag}}</strong> ({{len .Channels}})</summary>
+<table class="table">
+<tr>
+<th style="width: 300px;">路径</th>
+<th style="width: 300px;">源地址</th>
+<th>标签</th>
+<th style="text-align:center; width: 80px;">观众</th>
+<th style="width: 120px;">状态</th>
+</tr>
+{{range .Channels}}
+<tr>
+<td style="word-break: break-all;">{{.Path}}</td>
+<td>{{.UDPAddr}}</td>
+<td>{{range $i, $t := .Tags}}{{if $i}}, {{end}}{{$t}}{{end}}</td>
+<td style="text-align:center;">{{.Viewers}}</td>
+<td>{{if eq .Status "active"}}<span class="status-alive">活跃</span>{{else if eq .Status "error"}}<span class="status-dead" title="{{.Error}}">❌ 异常</span>{{else}}<span class="status-unknown">空闲</span>{{end}}</td>
+</tr>
+{{end}}
+</table>
+</details>
+{{end}}
+<script>
+// 记住折叠状态，自动刷新后保持
+document.querySelectorAll('details.channel-group').forEach(d => {
+    const key = 'channelGroupClosed:' + d.dataset.tag;
+    if(localStorage.getItem(key) === 'true') d.open = false;
+    d.addEventListener('toggle', () => { localStorage.setItem(key, !d.open); });
+});
+</script>
+{{end}}
+
+<h2>组播频道</h2>
+<table class="table">
+<tr>
+<th style="width: 300px;">地址</th>
+<th style="width: 200px;">网卡</th>
+<th style="width: 200px;">监听模式</th>
+<th style="text-align:center; width: 80px;">客户端</th>
+<th style="width: 120px;">源码率</th>
+<th style="width: 180px;" title="内核接收时间戳统计的包到达抖动，最近 10s">抖动 min/avg/max</th>
+<th style="width: 120px;" title="客户端通道填充度 len/cap，最近 10s；持续偏高说明客户端过慢或缓冲过小">缓冲填充 avg/max</th>
+</tr>
+{{range .Hubs}}
+<tr>
+<td style="word-break: break-all;">{{.Addr}}</td>
+<td>{{if .LocalAddr}}{{.LocalAddr}}{{else if .Ifaces}}{{range $i, $n := .Ifaces}}{{if $i}}, {{end}}{{$n}}{{end}}{{else}}默认{{end}}{{if .IfaceRx}}<br><small style="color:#aaa;" title="多网卡接收：包数（被去重的重复包）">{{range .IfaceRx}}{{.Name}}: {{.Packets}} ({{.Duplicates}})<br>{{end}}</small>{{end}}</td>
+<td>{{if eq .Source "http"}}<span class="status-alive">HTTP 拉流</span>{{else if eq .Source "preview"}}<span class="status-alive" title="ffmpeg 低码率预览转码，订阅完整流 Hub（在其观众数中计 1）">预览转码</span>{{else if eq .Source "unicast"}}<span class="status-alive" title="显式单播监听，不加入组播">单播</span>{{else if .IsMulticast}}<span class="status-alive">组播</span>{{else if .IfacesLost}}<span class="status-dead" title="配置的网卡全部不存在，已回退为普通 UDP 监听，组播源很可能收不到数据（stream.strict_ifaces 可改为直接报错）">❌ 网卡均不存在</span>{{else}}<span class="status-cooldown" title="组播加入失败，已回退为普通 UDP 监听，组播源可能收不到数据">⚠️ 回退普通UDP</span>{{end}}{{if .Pinned}} <span title="高优先级频道：接收协程独占 OS 线程">📌</span>{{end}}{{if .ActiveIface}}<br><small title="按 ifaces 优先级故障切换，切换记录见源切换历史">当前网卡: {{.ActiveIface}}</small>{{end}}{{if .HasSlate}}<br><small title="源中断垫片：累计时长（次数）">{{if .SlateActive}}<span class="status-cooldown">🎬 垫片中</span> {{end}}垫片 {{.SlateTime}} ({{.SlateCount}})</small>{{end}}</td>
+<td style="text-align:center;">{{.ClientCount}}{{if .SilentCount}} <span class="status-cooldown" title="静默订阅者（探测），不计入观看人数">+{{.SilentCount}}</span>{{end}}</td>
+<td>{{FormatNetworkBandwidth .Bitrate}}{{if .HasReorder}}<br><small style="color:#aaa;" title="RTP 重排：重排输出 / 迟到丢弃 / 超时跳过的包数">重排 {{.ReorderedPkts}} / {{.ReorderLate}} / {{.ReorderSkipped}}</small>{{end}}</td>
+<td>{{if .HasJitter}}{{.JitterMin}} / {{.JitterAvg}} / {{.JitterMax}}{{else}}-{{end}}</td>
+<td>{{if .HasFill}}{{printf "%.0f" (mulFloat64 .FillAvg 100)}}% / {{printf "%.0f" (mulFloat64 .FillMax 100)}}%{{else}}-{{end}}</td>
+</tr>
+{{end}}
+</table>
+
+{{if .Recordings}}
+<h2>录制</h2>
+<table class="table">
+<tr>
+<th style="width: 80px;">ID</th>
+<th style="width: 200px;">频道</th>
+<th>当前文件</th>
+<th style="text-align:center; width: 80px;">分段</th>
+<th style="width: 120px;">已写入</th>
+<th style="width: 180px;">开始 / 结束</th>
+</tr>
+{{range .Recordings}}
+<tr>
+<td>{{.ID}}</td>
+<td style="word-break: break-all;">{{if .Channel}}{{.Channel}}{{else}}{{.Addr}}{{end}}</td>
+<td style="word-break: break-all;">{{if .File}}{{.File}}{{else}}-{{end}}</td>
+<td style="text-align:center;">{{.Files}}</td>
+<td>{{FormatBytes .Bytes}}</td>
+<td>{{.StartedAt.Format "15:04:05"}} / {{.Until.Format "15:04:05"}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+
 <h2>代理组状态</h2>
+{{if .ProxyDNS}}
+<h3>代理 DNS 缓存</h3>
+<table class="table">
+<tr>
+<th style="width: 250px;">主机名</th>
+<th>解析结果</th>
+<th style="width: 180px;">最近使用</th>
+<th style="width: 120px;">解析时间</th>
+<th>错误</th>
+</tr>
+{{range .ProxyDNS}}
+<tr>
+<td>{{.Host}}</td>
+<td>{{range $i, $ip := .IPs}}{{if $i}}, {{end}}{{$ip}}{{else}}-{{end}}</td>
+<td>{{if .LastIP}}{{.LastIP}}{{else}}-{{end}}</td>
+<td>{{if .ResolvedAt.IsZero}}-{{else}}{{.ResolvedAt.Format "15:04:05"}}{{end}}</td>
+<td>{{if .Error}}<span class="status-dead" title="{{.ErrorAt.Format "15:04:05"}}">{{.Error}}</span>{{else}}-{{end}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
 {{range $name, $group := .ProxyGroups}}
 <h3>{{$name}} (负载均衡: {{$group.LoadBalance}})</h3>
 <table class="table">
@@ -292,6 +547,9 @@ body {
 <th>类型 <span class="toggle-column" data-column="2" data-group="{{$name}}">👁</span></th>
 <th>服务器 <span class="toggle-column" data-column="3" data-group="{{$name}}">👁</span></th>
 <th>HTTP状态</th>
+<th>活跃连接</th>
+<th>转发流量</th>
+<th>最近成功 / 失败</th>
 <th>状态</th>
 </tr>
 {{range $proxy := $group.Proxies}}
@@ -304,6 +562,9 @@ body {
     {{ $stats := index $group.Stats.ProxyStats $proxy.Name }}
     {{if $stats}}{{if gt $stats.StatusCode 0}}{{$stats.StatusCode}}{{else}}-{{end}}{{else}}-{{end}}
   </td>
+<td>{{ $stats := index $group.Stats.ProxyStats $proxy.Name }}{{if $stats}}{{$stats.ActiveConnCount}}{{else}}0{{end}}</td>
+<td>{{ $stats := index $group.Stats.ProxyStats $proxy.Name }}{{if $stats}}{{FormatBytes $stats.TransferredBytes}}{{else}}-{{end}}</td>
+<td>{{ $stats := index $group.Stats.ProxyStats $proxy.Name }}{{if $stats}}{{FormatAgo $stats.LastSuccess $.Timestamp}} / {{FormatAgo $stats.LastFailure $.Timestamp}}{{else}}-{{end}}</td>
 <td>
 {{ $stats := index $group.Stats.ProxyStats $proxy.Name }}
 {{if $stats}}
@@ -319,13 +580,17 @@ body {
 </table>
 {{end}}
 
+{{if not .Static}}
 <script>
-let refreshMs = parseInt(localStorage.getItem('refreshMs')) || 3000;
+const minRefreshMs = {{.MinRefresh.Milliseconds}};
 let auto = localStorage.getItem('autoRefresh') !== 'false';
 let timer = null;
 const toggleBtn = document.getElementById('toggleRefresh');
 const intervalSelect = document.getElementById('interval');
-if(intervalSelect.querySelector('option[value="'+refreshMs+'"]')) intervalSelect.value = refreshMs;
+// 服务端限定了可选间隔，本地保存的值不在列表中或低于最小间隔时使用第一个选项
+let refreshMs = parseInt(localStorage.getItem('refreshMs')) || 0;
+if(refreshMs < minRefreshMs || !intervalSelect.querySelector('option[value="'+refreshMs+'"]')) refreshMs = parseInt(intervalSelect.options[0].value);
+intervalSelect.value = refreshMs;
 
 function applyButtonUI(){
     if(auto){
@@ -475,6 +740,7 @@ toggleThemeBtn.addEventListener('click', () => {
     });
 })();
 </script>
+{{end}}
 
 </body>
 </html>`
@@ -511,20 +777,29 @@ toggleThemeBtn.addEventListener('click', () => {
 			return 0
 		},
 		"float64ToInt64":         func(a float64) int64 { return int64(a) },
+		"FormatAgo":              FormatAgo,
+		"mulFloat64":             func(a, b float64) float64 { return a * b },
 		"FormatBytes":            FormatBytes,
 		"FormatBytesPerSec":      FormatBytesPerSec,
 		"FormatNetworkBandwidth": FormatNetworkBandwidth,
-		"ge": func(a, b float64) bool { return a >= b }, // 添加ge函数用于温度比较
+		"ge":                     func(a, b float64) bool { return a >= b }, // 添加ge函数用于温度比较
 	}).Parse(tmpl)
 
 	if err != nil {
-		http.Error(w, "模板解析错误: "+err.Error(), http.StatusInternalServerError)
+		WriteError(w, r, http.StatusInternalServerError, "模板解析错误: "+err.Error())
 		return
 	}
 
-	if err := t.Execute(w, data); err != nil {
-		http.Error(w, "模板执行错误: "+err.Error(), http.StatusInternalServerError)
+	// 先渲染到缓冲区，执行失败时还能返回完整的错误响应而不是半截页面
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "模板执行错误: "+err.Error())
+		return
 	}
+	timing := timingOf(r)
+	timing.since("render", "Template render", renderStart)
+	timing.write(w)
+	w.Write(buf.Bytes())
 }
 
 // 字节格式化
@@ -552,14 +827,19 @@ func FormatNetworkBandwidth(bytes uint64) string {
 }
 
 func prepareStatusData(r *http.Request) StatusData {
+	timing := timingOf(r)
+
 	// 内存统计
+	start := time.Now()
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
+	timing.since("memstats", "runtime.ReadMemStats", start)
 
 	// 获取客户端 IP
 	clientIP := GetClientIP(r)
 
 	// 复制 ProxyGroups
+	start = time.Now()
 	config.CfgMu.RLock()
 	proxyGroups := make(map[string]*config.ProxyGroupConfig)
 	for name, group := range config.Cfg.ProxyGroups {
@@ -590,34 +870,77 @@ func prepareStatusData(r *http.Request) StatusData {
 		proxyGroups[name] = groupCopy
 	}
 	config.CfgMu.RUnlock()
+	timing.since("config", "Config copy", start)
 
 	// 获取系统与应用流量统计（深拷贝）
+	start = time.Now()
 	trafficStats := GlobalTrafficStats.GetTrafficStats()
+	timing.since("gopsutil", "System stats (gopsutil snapshot)", start)
 
-	return StatusData{
-		Timestamp:     time.Now(),
-		Uptime:        time.Since(config.StartTime),
-		Version:       config.Version,
-		Goroutines:    runtime.NumGoroutine(),
-		MemoryStats:   memStats,
-		ProxyGroups:   proxyGroups,
-		TrafficStats:  trafficStats, // 包含系统统计 + 应用统计
-		ClientIP:      clientIP,
-		ActiveClients: ActiveClients.GetAll(),
-		WebPath:       config.Cfg.Web.Path, // 注入动态 Web.Path
-	}
-}
-
-func GetClientIP(r *http.Request) string {
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		return strings.TrimSpace(strings.Split(xff, ",")[0])
+	// 文件描述符使用率告警
+	config.CfgMu.RLock()
+	fdWarnPercent := config.Cfg.Monitor.FDWarnPercent
+	static := config.Cfg.Monitor.DisableAutoRefresh
+	config.CfgMu.RUnlock()
+	minRefresh, refreshOptions := refreshSettings()
+	switch r.URL.Query().Get("static") {
+	case "1", "true":
+		static = true
+	case "0", "false":
+		static = false
 	}
-	if xr := r.Header.Get("X-Real-IP"); xr != "" {
-		return xr
+	fdWarning := false
+	if trafficStats.App.MaxFDs > 0 && fdWarnPercent > 0 {
+		fdWarning = float64(trafficStats.App.OpenFDs)/float64(trafficStats.App.MaxFDs)*100 >= fdWarnPercent
 	}
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
+
+	start = time.Now()
+	activeClients := ActiveClients.GetAll()
+	peakClients, peakClientsAt := ActiveClients.Peak()
+	channelViewers := countChannelViewers(activeClients)
+	channelTag := strings.TrimSpace(r.URL.Query().Get("tag"))
+	hubs := GetHubStatuses()
+	previewViewers := countPreviewViewers(activeClients)
+	channels, channelGroups, channelTags := buildChannelInventory(channelViewers, previewViewers, hubs, channelTag)
+	timing.since("streams", "Clients and hubs", start)
+
+	return StatusData{
+		Timestamp:        time.Now(),
+		Uptime:           time.Since(config.StartTime),
+		Version:          config.Version,
+		Build:            getBuildInfo(),
+		Features:         getFeatures(),
+		Goroutines:       runtime.NumGoroutine(),
+		MemoryStats:      memStats,
+		ProxyGroups:      proxyGroups,
+		TrafficStats:     trafficStats, // 包含系统统计 + 应用统计
+		ClientIP:         clientIP,
+		ActiveClients:    activeClients,
+		RecentSessions:   RecentSessions(),
+		PlayerCategories: countPlayerCategories(activeClients),
+		PeakClients:      peakClients,
+		PeakClientsAt:    peakClientsAt,
+		ChannelBytes:     ChannelTotalBytes(),
+		TopClientIPs:     TopIPConns(10),
+		ChannelViewers:   channelViewers,
+		PreviewViewers:   previewViewers,
+		TotalViewers:     GetTotalViewers(),
+		Channels:         channels,
+		ChannelGroups:    channelGroups,
+		ChannelTags:      channelTags,
+		ChannelTag:       channelTag,
+		Hubs:             hubs,
+		Recordings:       GetRecordings(),
+		ProxyDNS:         GetProxyDNSStatuses(),
+		FDWarning:        fdWarning,
+		MemoryShed:       MemoryShedCount(),
+		FastStartFrames:  FastStartFrameCount(),
+		HubJoins:         HubJoinCount(),
+		Degraded:         DegradedCollectors(),
+		Alarms:           buildAlarms(hubs, proxyGroups, trafficStats, fdWarning),
+		WebPath:          config.Cfg.Web.Path, // 注入动态 Web.Path
+		Static:           static,
+		RefreshOptions:   refreshOptions,
+		MinRefresh:       minRefresh,
 	}
-	return ip
-}
\ No newline at end of file
+}
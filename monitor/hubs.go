@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// SourceSwitchEvent 记录一次 Hub 源切换（网卡变更、Hub 迁移等）
+type SourceSwitchEvent struct {
+	Time   time.Time
+	From   string
+	To     string
+	Reason string
+}
+
+// HubStatus 表示一个 UDP/组播频道 Hub 的运行状态
+type HubStatus struct {
+	Key          string
+	Addr         string
+	Ifaces       []string
+	LocalAddr    string // 指定的本地绑定 IP
+	IsMulticast  bool   // false 表示组播加入失败，已回退为普通 UDP 监听
+	IfacesLost   bool   // 配置的网卡全部无法解析，回退的普通 UDP 监听很可能收不到组播
+	Pinned       bool   // 高优先级频道：接收协程独占 OS 线程（channels[].priority: high）
+	ActiveIface  string // 网卡故障切换启用时当前接收组播的网卡（stream.iface_failover）
+	Source       string // 源类型：udp（监听 UDP/组播）、unicast（显式单播监听）、http（HTTP 拉流）或 preview（频道预览转码）
+	ClientCount  int
+	SilentCount  int    // 静默订阅者（探测）数量，不计入 ClientCount
+	Bitrate      uint64 // 源入流码率估算 (bytes/s)，与客户端分发带宽无关
+	BitrateHuman string // 格式化字段（仅用于 JSON 输出）
+	TotalBytes   uint64 // 本 Hub 创建以来累计接收字节数
+
+	// 包到达抖动（基于内核接收时间戳，最近 10s 窗口），平台不支持时 HasJitter 为 false
+	HasJitter bool
+	JitterMin time.Duration
+	JitterAvg time.Duration
+	JitterMax time.Duration
+
+	// 客户端通道填充度（len/cap，最近 10s 窗口），持续接近 1 表示客户端过慢或缓冲过小
+	HasFill bool
+	FillAvg float64
+	FillMax float64
+
+	// RTP 序号重排（stream.rtp_reorder_depth），未启用时 HasReorder 为 false
+	HasReorder     bool
+	ReorderedPkts  uint64 // 乱序到达后被重排输出的包
+	ReorderLate    uint64 // 迟到被丢弃的包
+	ReorderSkipped uint64 // 等待超时被跳过的缺口包数
+
+	SwitchEvents []SourceSwitchEvent // 最近的源切换记录（有上限）
+
+	// 源中断垫片（stream.slate_file），未启用时 HasSlate 为 false
+	HasSlate    bool
+	SlateActive bool          // 正在播放垫片
+	SlateTime   time.Duration // 累计播放垫片时长（含当前一段）
+	SlateCount  uint64        // 进入垫片的次数
+
+	// 多网卡同时接收时各入口网卡的接收计数（stream.all_interfaces）
+	IfaceRx []IfaceRxStat
+}
+
+// IfaceRxStat 多网卡接收模式下单个网卡的接收统计
+type IfaceRxStat struct {
+	Name       string
+	Packets    uint64
+	Bytes      uint64
+	Duplicates uint64 // 被去重丢弃的包数（其他网卡已先收到）
+}
+
+var (
+	hubStatusFunc    func() []HubStatus
+	totalViewersFunc func() int
+	hubStatusMu      sync.RWMutex
+)
+
+// RegisterHubStatusFunc 注册频道状态采集函数（由 stream 包在初始化时注册，避免循环依赖）
+func RegisterHubStatusFunc(fn func() []HubStatus) {
+	hubStatusMu.Lock()
+	defer hubStatusMu.Unlock()
+	hubStatusFunc = fn
+}
+
+// RegisterTotalViewersFunc 注册全局观看人数统计函数（由 stream 包注册）
+func RegisterTotalViewersFunc(fn func() int) {
+	hubStatusMu.Lock()
+	defer hubStatusMu.Unlock()
+	totalViewersFunc = fn
+}
+
+// GetTotalViewers 获取所有频道 Hub 上的观看人数总和
+func GetTotalViewers() int {
+	hubStatusMu.RLock()
+	fn := totalViewersFunc
+	hubStatusMu.RUnlock()
+	if fn == nil {
+		return 0
+	}
+	return fn()
+}
+
+// GetHubStatuses 获取所有频道 Hub 的状态，按 Key 排序
+func GetHubStatuses() []HubStatus {
+	hubStatusMu.RLock()
+	fn := hubStatusFunc
+	hubStatusMu.RUnlock()
+	if fn == nil {
+		return nil
+	}
+
+	list := fn()
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+)
+
+// FormatDuration 将时长格式化为 “x天x小时x分x秒”，与状态页显示保持一致
+func FormatDuration(d time.Duration) string {
+	total := int64(d.Seconds())
+	days := total / 86400
+	hours := total % 86400 / 3600
+	minutes := total % 3600 / 60
+	seconds := total % 60
+
+	s := ""
+	if days > 0 {
+		s += fmt.Sprintf("%d天", days)
+	}
+	if hours > 0 {
+		s += fmt.Sprintf("%d小时", hours)
+	}
+	if minutes > 0 {
+		s += fmt.Sprintf("%d分", minutes)
+	}
+	return s + fmt.Sprintf("%d秒", seconds)
+}
+
+// FormatAgo 将时间格式化为相对 now 的 “x分钟前”，零值返回 “从未”
+func FormatAgo(t, now time.Time) string {
+	if t.IsZero() {
+		return "从未"
+	}
+	d := now.Sub(t)
+	switch {
+	case d < 5*time.Second:
+		return "刚刚"
+	case d < time.Minute:
+		return fmt.Sprintf("%d秒前", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%d分钟前", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%d小时前", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d天前", int(d.Hours()/24))
+	}
+}
+
+// fillHumanFields 为 JSON 输出填充格式化后的字符串字段，轻量前端无需自行实现格式化
+// 原始数值字段保持不变，便于绘图
+func fillHumanFields(data *StatusData) {
+	data.UptimeHuman = FormatDuration(data.Uptime)
+
+	if ts := data.TrafficStats; ts != nil {
+		ts.TotalBytesHuman = FormatBytes(ts.TotalBytes)
+		ts.InboundBytesHuman = FormatBytes(ts.InboundBytes)
+		ts.OutboundBytesHuman = FormatBytes(ts.OutboundBytes)
+		ts.InboundBandwidthHuman = FormatNetworkBandwidth(ts.InboundBandwidth)
+		ts.OutboundBandwidthHuman = FormatNetworkBandwidth(ts.OutboundBandwidth)
+		ts.MemoryUsageHuman = FormatBytes(ts.MemoryUsage)
+		ts.MemoryTotalHuman = FormatBytes(ts.MemoryTotal)
+		ts.App.MemoryUsageHuman = FormatBytes(ts.App.MemoryUsage)
+
+		for i := range ts.NetworkInterfaces {
+			ni := &ts.NetworkInterfaces[i]
+			ni.BytesRecvHuman = FormatBytes(ni.BytesRecv)
+			ni.BytesSentHuman = FormatBytes(ni.BytesSent)
+			ni.RecvBandwidthHuman = FormatNetworkBandwidth(ni.RecvBandwidth)
+			ni.SendBandwidthHuman = FormatNetworkBandwidth(ni.SendBandwidth)
+		}
+	}
+
+	for i := range data.Hubs {
+		data.Hubs[i].BitrateHuman = FormatNetworkBandwidth(data.Hubs[i].Bitrate)
+	}
+}
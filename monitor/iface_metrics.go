@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/qist/tvgate/config"
+)
+
+// excludedIfacePatterns 读取需要从网卡列表与指标中排除的网卡名模式
+func excludedIfacePatterns() []string {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	return append([]string(nil), config.Cfg.Monitor.ExcludeIfaces...)
+}
+
+// ifaceExcluded 判断网卡名是否匹配排除列表（path.Match 通配符，非法模式按字面比较）
+func ifaceExcluded(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, err := path.Match(p, name); ok || (err != nil && p == name) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeIfaceCounter 输出按网卡打标签的 counter 指标；OpenMetrics 的指标族名不带 _total 后缀
+func writeIfaceCounter(w io.Writer, name, help string, openMetrics bool, ifaces []NetworkInterfaceInfo, value func(NetworkInterfaceInfo) uint64) {
+	family := name
+	if openMetrics {
+		family = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", family, help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", family)
+	for _, ni := range ifaces {
+		fmt.Fprintf(w, "%s{iface=\"%s\"} %s\n", name, escapeLabelValue(ni.Name), strconv.FormatUint(value(ni), 10))
+	}
+}
+
+// writeIfaceMetrics 输出各网卡（已按 monitor.exclude_ifaces 过滤）的收发字节与丢包计数，便于按 VLAN/网卡告警
+func writeIfaceMetrics(w io.Writer, openMetrics bool) {
+	ifaces := GlobalTrafficStats.GetTrafficStats().NetworkInterfaces
+	if len(ifaces) == 0 {
+		return
+	}
+	writeIfaceCounter(w, "tvgate_iface_recv_bytes_total", "Bytes received per network interface.", openMetrics, ifaces,
+		func(ni NetworkInterfaceInfo) uint64 { return ni.BytesRecv })
+	writeIfaceCounter(w, "tvgate_iface_sent_bytes_total", "Bytes sent per network interface.", openMetrics, ifaces,
+		func(ni NetworkInterfaceInfo) uint64 { return ni.BytesSent })
+	writeIfaceCounter(w, "tvgate_iface_drop_total", "Incoming packets dropped per network interface.", openMetrics, ifaces,
+		func(ni NetworkInterfaceInfo) uint64 { return ni.DropsRecv })
+}
@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"sort"
+	"sync"
+)
+
+// IPConnCount 单个客户端 IP 的当前流连接数
+type IPConnCount struct {
+	IP    string
+	Count int
+}
+
+var (
+	ipConnsMu sync.Mutex
+	ipConns   = make(map[string]int)
+)
+
+// AcquireIPConn 为客户端 IP 占用一个流连接名额；max <= 0 表示不限制
+// 超出上限时返回 false，成功时返回的 release 必须在连接结束时调用
+func AcquireIPConn(ip string, max int) (release func(), ok bool) {
+	ipConnsMu.Lock()
+	defer ipConnsMu.Unlock()
+
+	if max > 0 && ipConns[ip] >= max {
+		return nil, false
+	}
+	ipConns[ip]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ipConnsMu.Lock()
+			defer ipConnsMu.Unlock()
+			if ipConns[ip] <= 1 {
+				delete(ipConns, ip)
+			} else {
+				ipConns[ip]--
+			}
+		})
+	}, true
+}
+
+// TopIPConns 返回当前流连接数最多的 n 个客户端 IP（仅包含连接数大于 1 的 IP）
+func TopIPConns(n int) []IPConnCount {
+	ipConnsMu.Lock()
+	list := make([]IPConnCount, 0, len(ipConns))
+	for ip, c := range ipConns {
+		if c > 1 {
+			list = append(list, IPConnCount{IP: ip, Count: c})
+		}
+	}
+	ipConnsMu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].IP < list[j].IP
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
@@ -0,0 +1,188 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/qist/tvgate/config"
+)
+
+// 状态 JSON 字段命名风格
+const (
+	JSONNamingLegacy = "legacy" // Go 字段名（PascalCase），当前默认，弃用过渡期内保留
+	JSONNamingSnake  = "snake"  // snake_case，计划在过渡期结束后成为默认
+)
+
+// jsonNaming 返回本次请求使用的字段命名风格：?naming= 优先，其次 monitor.json_naming 配置
+func jsonNaming(r *http.Request) string {
+	naming := strings.ToLower(r.URL.Query().Get("naming"))
+	if naming == "" {
+		config.CfgMu.RLock()
+		naming = strings.ToLower(config.Cfg.Monitor.JSONNaming)
+		config.CfgMu.RUnlock()
+	}
+	switch naming {
+	case JSONNamingSnake, "snake_case":
+		return JSONNamingSnake
+	default:
+		return JSONNamingLegacy
+	}
+}
+
+// encodeStatusJSON 按命名风格输出 JSON；snake 模式下仅转换结构体字段名，map 的键（代理名、网卡名等）保持原样
+func encodeStatusJSON(w http.ResponseWriter, v any, naming string) error {
+	w.Header().Set("X-TVGate-JSON-Naming", naming)
+	if naming != JSONNamingSnake {
+		return json.NewEncoder(w).Encode(v)
+	}
+	return json.NewEncoder(w).Encode(snakeTree(reflect.ValueOf(v)))
+}
+
+// orderedObject 保持结构体字段顺序的 JSON 对象
+type orderedObject []orderedField
+
+type orderedField struct {
+	key   string
+	value any
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// jsonSnapshotter 由含原子计数器等不可直接序列化字段的类型实现，返回其可序列化快照；
+// 这类类型的 MarshalJSON 输出同一快照，snake_case 输出展开快照字段而不是原样使用 MarshalJSON
+type jsonSnapshotter interface {
+	JSONSnapshot() any
+}
+
+// snakeTree 将值转换为字段名为 snake_case 的可序列化结构，规则与 encoding/json 保持一致：
+// 忽略未导出字段与 json:"-"，有 json 标签时使用标签名，匿名结构体字段展开
+func snakeTree(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.CanInterface() {
+		if s, ok := v.Interface().(jsonSnapshotter); ok {
+			if v.Kind() == reflect.Pointer && v.IsNil() {
+				return nil
+			}
+			return snakeTree(reflect.ValueOf(s.JSONSnapshot()))
+		}
+	}
+	if v.Type().Implements(jsonMarshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return snakeTree(v.Elem())
+	case reflect.Struct:
+		obj := orderedObject{}
+		appendSnakeFields(&obj, v)
+		return obj
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = snakeTree(iter.Value())
+		}
+		return m
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = snakeTree(v.Index(i))
+		}
+		return list
+	default:
+		return v.Interface()
+	}
+}
+
+func appendSnakeFields(obj *orderedObject, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && tag == "" {
+			appendSnakeFields(obj, v.Field(i))
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = snakeCase(sf.Name)
+		}
+		*obj = append(*obj, orderedField{key: name, value: snakeTree(v.Field(i))})
+	}
+}
+
+// snakeCase 将 Go 字段名转换为 snake_case，连续大写视为缩写：
+// ClientIP → client_ip，CPUPercent → cpu_percent，OpenFDs → open_fds，IPv6 → ipv6
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if !unicode.IsUpper(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// 缩写复数（FDs、IDs）与版本后缀（IPv6）不拆分
+			acronymSuffix := nextLower && ((runes[i+1] == 's' && (i+2 == len(runes) || unicode.IsUpper(runes[i+2]))) ||
+				(runes[i+1] == 'v' && i+2 < len(runes) && unicode.IsDigit(runes[i+2])))
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower && !acronymSuffix) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
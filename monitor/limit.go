@@ -0,0 +1,63 @@
+package monitor
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// 监控请求排队等待空闲名额的最长时间，超时返回 503
+const monitorQueueTimeout = 2 * time.Second
+
+var (
+	monitorSemMu   sync.Mutex
+	monitorSem     chan struct{}
+	monitorSemSize int
+)
+
+// monitorSemaphore 返回与当前配置匹配的信号量，nil 表示不限制；配置变更后重建
+func monitorSemaphore() chan struct{} {
+	config.CfgMu.RLock()
+	limit := config.Cfg.Monitor.MaxConcurrent
+	config.CfgMu.RUnlock()
+
+	monitorSemMu.Lock()
+	defer monitorSemMu.Unlock()
+	if limit <= 0 {
+		monitorSem, monitorSemSize = nil, 0
+		return nil
+	}
+	if monitorSem == nil || monitorSemSize != limit {
+		monitorSem, monitorSemSize = make(chan struct{}, limit), limit
+	}
+	return monitorSem
+}
+
+// limitMonitor 限制监控接口的并发执行数，超出时短暂排队，仍无名额则返回 503 并附带 Retry-After
+func limitMonitor(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sem := monitorSemaphore()
+		if sem == nil {
+			next(w, r)
+			return
+		}
+
+		timer := time.NewTimer(monitorQueueTimeout)
+		defer timer.Stop()
+		select {
+		case sem <- struct{}{}:
+		case <-timer.C:
+			w.Header().Set("server", "TVGate")
+			w.Header().Set("Retry-After", "1")
+			WriteError(w, r, http.StatusServiceUnavailable, "监控请求过多，请稍后重试")
+			return
+		case <-r.Context().Done():
+			return
+		}
+		defer func() { <-sem }()
+
+		next(w, r)
+	}
+}
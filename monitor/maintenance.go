@@ -0,0 +1,170 @@
+package monitor
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+)
+
+const (
+	defaultMaintenanceMessage    = "服务维护中，请稍后再试"
+	defaultMaintenanceRetryAfter = 5 * time.Minute
+)
+
+// MaintenanceStatus 维护模式当前状态
+type MaintenanceStatus struct {
+	Enabled    bool      `json:"enabled"`
+	Message    string    `json:"message,omitempty"`
+	Since      time.Time `json:"since,omitempty"`
+	Source     string    `json:"source,omitempty"` // config（配置文件）或 admin（管理接口）
+	RetryAfter int       `json:"retry_after"`      // 秒
+}
+
+var (
+	maintenanceOn atomic.Bool // 请求路径上的快速判断
+	maintenanceMu sync.Mutex
+	maintenance   MaintenanceStatus
+	// maintenanceConfigured 最近一次应用的配置值，配置重载时只有该值变化才覆盖管理接口的切换
+	maintenanceConfigured bool
+)
+
+// InMaintenance 是否处于维护模式
+func InMaintenance() bool {
+	return maintenanceOn.Load()
+}
+
+// Maintenance 返回维护模式状态
+func Maintenance() MaintenanceStatus {
+	maintenanceMu.Lock()
+	defer maintenanceMu.Unlock()
+	return maintenance
+}
+
+// SyncMaintenanceConfig 在启动及配置重载时调用：配置中的 maintenance.enabled 变化时切换维护模式，
+// 未变化时保留管理接口的临时切换
+func SyncMaintenanceConfig() {
+	config.CfgMu.RLock()
+	cfg := config.Cfg.Maintenance
+	config.CfgMu.RUnlock()
+
+	maintenanceMu.Lock()
+	changed := cfg.Enabled != maintenanceConfigured
+	maintenanceConfigured = cfg.Enabled
+	maintenanceMu.Unlock()
+	if changed {
+		SetMaintenance(cfg.Enabled, cfg.Message, "config")
+	}
+}
+
+// SetMaintenance 开启或关闭维护模式，source 标明来源（config / admin），状态变化时记录日志
+func SetMaintenance(enabled bool, message, source string) {
+	config.CfgMu.RLock()
+	cfg := config.Cfg.Maintenance
+	config.CfgMu.RUnlock()
+	if message == "" {
+		message = cfg.Message
+	}
+	if message == "" {
+		message = defaultMaintenanceMessage
+	}
+	retry := cfg.RetryAfter
+	if retry <= 0 {
+		retry = defaultMaintenanceRetryAfter
+	}
+
+	maintenanceMu.Lock()
+	was := maintenance.Enabled
+	maintenance = MaintenanceStatus{Enabled: enabled, Source: source, RetryAfter: int(retry / time.Second)}
+	if enabled {
+		maintenance.Message = message
+		maintenance.Since = time.Now()
+	}
+	maintenanceOn.Store(enabled)
+	maintenanceMu.Unlock()
+
+	switch {
+	case enabled && !was:
+		logger.LogPrintf("🚧 维护模式已开启（%s）：%s", source, message)
+	case enabled:
+		logger.LogPrintf("🚧 维护模式提示已更新（%s）：%s", source, message)
+	case was:
+		logger.LogPrintf("✅ 维护模式已关闭（%s）", source)
+	}
+}
+
+var maintenanceTmpl = template.Must(template.New("maintenance").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<title>维护中 - TVGate</title>
+<style>
+body { font-family: 'Segoe UI', sans-serif; max-width:800px; margin:40px auto; background:#121212; color:#e0e0e0; }
+.header { background:#1f1f1f; padding:20px; border-radius:10px; margin-bottom:20px; }
+.header h1 { margin:0; }
+small { color:#aaa; }
+</style>
+</head>
+<body>
+<div class="header">
+<h1>🚧 服务维护中</h1>
+<p>{{.Message}}</p>
+<small>开始于 {{.Since.Format "2006-01-02 15:04:05"}}，预计 {{.RetryAfter}} 秒后重试</small>
+</div>
+</body>
+</html>`))
+
+// WriteMaintenance 输出 503 维护响应（带 Retry-After）：期望 JSON 时返回结构化错误（附维护状态），否则返回维护提示页
+func WriteMaintenance(w http.ResponseWriter, r *http.Request) {
+	st := Maintenance()
+	h := w.Header()
+	h.Set("server", "TVGate")
+	h.Set("Retry-After", strconv.Itoa(st.RetryAfter))
+	h.Set("Cache-Control", "no-store")
+	if WantsJSON(r) {
+		h.Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": APIErrorBody{
+				Code:    "maintenance",
+				Status:  http.StatusServiceUnavailable,
+				Message: st.Message,
+			},
+			"maintenance": st,
+		})
+		return
+	}
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	maintenanceTmpl.Execute(w, st)
+}
+
+// duringMaintenance 维护模式下以维护响应替代接口输出
+func duringMaintenance(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if InMaintenance() {
+			WriteMaintenance(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// HandleHealthz 存活检查：进程正常即返回 200，维护模式不影响结果，只在响应中如实标明
+func HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("server", "TVGate")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":      "ok",
+		"version":     config.Version,
+		"uptime":      int64(time.Since(config.StartTime).Seconds()),
+		"maintenance": Maintenance(),
+	})
+}
@@ -0,0 +1,40 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// memoryShed 因内存超过上限而拒绝的新流连接数
+var memoryShed atomic.Uint64
+
+// RecordMemoryShed 记录一次因内存压力拒绝的新连接
+func RecordMemoryShed() {
+	memoryShed.Add(1)
+}
+
+// MemoryShedCount 返回因内存压力拒绝的新连接总数
+func MemoryShedCount() uint64 {
+	return memoryShed.Load()
+}
+
+// AppMemoryUsage 最近一次采样的进程常驻内存 (RSS)，未采样时为 0
+func (ts *TrafficStats) AppMemoryUsage() uint64 {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.App.MemoryUsage
+}
+
+// writeMemoryShedMetric 输出内存保护拒绝计数；OpenMetrics 的指标族名不带 _total 后缀
+func writeMemoryShedMetric(w io.Writer, openMetrics bool) {
+	const name = "tvgate_memory_shed_total"
+	family := name
+	if openMetrics {
+		family = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(w, "# HELP %s New stream connections rejected because memory exceeded stream.max_memory_mb.\n", family)
+	fmt.Fprintf(w, "# TYPE %s counter\n", family)
+	fmt.Fprintf(w, "%s %d\n", name, MemoryShedCount())
+}
@@ -0,0 +1,157 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// exemplar OpenMetrics 样本示例，将某个桶关联到具体的观测来源
+type exemplar struct {
+	labels string // 已格式化的标签集，如 proxy="hk1",server="1.2.3.4:1080"
+	value  float64
+	ts     time.Time
+}
+
+// histogram 简单的 Prometheus 累积直方图
+type histogram struct {
+	mu        sync.Mutex
+	buckets   []float64   // 上界（秒），升序
+	counts    []uint64    // 每个桶的非累积计数
+	exemplars []*exemplar // 每个桶（含 +Inf）最近一次观测的示例
+	sum       float64
+	count     uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets:   buckets,
+		counts:    make([]uint64, len(buckets)),
+		exemplars: make([]*exemplar, len(buckets)+1),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	h.observeWithExemplar(v, "")
+}
+
+// observeWithExemplar 记录一次观测，labels 非空时作为该桶的示例
+func (h *histogram) observeWithExemplar(v float64, labels string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	idx := len(h.buckets)
+	for i, b := range h.buckets {
+		if v <= b {
+			idx = i
+			break
+		}
+	}
+	if idx < len(h.buckets) {
+		h.counts[idx]++
+	}
+	if labels != "" {
+		h.exemplars[idx] = &exemplar{labels: labels, value: v, ts: time.Now()}
+	}
+	h.sum += v
+	h.count++
+}
+
+// write 以 Prometheus 文本格式输出直方图；openMetrics 为 true 时附带示例
+func (h *histogram) write(w io.Writer, name, help string, openMetrics bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	var cumulative uint64
+	for i, b := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d%s\n", name, strconv.FormatFloat(b, 'f', -1, 64), cumulative, h.exemplarSuffix(i, openMetrics))
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d%s\n", name, h.count, h.exemplarSuffix(len(h.buckets), openMetrics))
+	fmt.Fprintf(w, "%s_sum %s\n", name, strconv.FormatFloat(h.sum, 'f', -1, 64))
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+}
+
+// exemplarSuffix 生成 OpenMetrics 示例后缀：" # {labels} value timestamp"
+func (h *histogram) exemplarSuffix(i int, openMetrics bool) string {
+	e := h.exemplars[i]
+	if !openMetrics || e == nil {
+		return ""
+	}
+	ts := float64(e.ts.UnixNano()) / 1e9
+	return fmt.Sprintf(" # {%s} %s %s", e.labels, strconv.FormatFloat(e.value, 'f', -1, 64), strconv.FormatFloat(ts, 'f', 3, 64))
+}
+
+// escapeLabelValue 按 Prometheus 文本格式转义标签值
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// 首帧延迟直方图：覆盖亚秒级到数秒级的换台时间
+var firstFrameHistogram = newHistogram([]float64{0.05, 0.1, 0.25, 0.5, 0.75, 1, 1.5, 2, 3, 5, 10})
+
+// 代理测速响应时间直方图
+var proxyResponseHistogram = newHistogram([]float64{0.01, 0.025, 0.05, 0.1, 0.2, 0.4, 0.8, 1.5, 3, 5})
+
+// ObserveFirstFrameLatency 记录客户端从连接到收到首帧数据的耗时
+func ObserveFirstFrameLatency(d time.Duration) {
+	firstFrameHistogram.observe(d.Seconds())
+}
+
+// ObserveProxyResponseTime 记录一次代理测速耗时，并以代理名/服务器作为示例标签
+func ObserveProxyResponseTime(proxyName, server string, d time.Duration) {
+	labels := fmt.Sprintf(`proxy="%s",server="%s"`, escapeLabelValue(proxyName), escapeLabelValue(server))
+	proxyResponseHistogram.observeWithExemplar(d.Seconds(), labels)
+}
+
+// writeGauge 输出单个 gauge 指标
+func writeGauge(w io.Writer, name, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+	fmt.Fprintf(w, "%s %s\n", name, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// wantsOpenMetrics 根据 Accept 头判断是否输出 OpenMetrics 格式
+func wantsOpenMetrics(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+}
+
+// HandleMetrics 以 Prometheus 文本格式输出监控指标；
+// Accept 包含 application/openmetrics-text 时输出 OpenMetrics 格式（含示例与 # EOF）
+func HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	openMetrics := wantsOpenMetrics(r)
+	w.Header().Set("server", "TVGate")
+	setCacheHeaders(w, "Accept")
+	if openMetrics {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	}
+
+	writeGauge(w, "tvgate_uptime_seconds", "Seconds since TVGate started.", time.Since(config.StartTime).Seconds())
+	writeGauge(w, "tvgate_goroutines", "Number of goroutines.", float64(runtime.NumGoroutine()))
+	writeGauge(w, "tvgate_active_clients", "Number of registered active client connections.", float64(len(ActiveClients.GetAll())))
+	writeGauge(w, "tvgate_viewers", "Total streaming clients across all channel hubs.", float64(GetTotalViewers()))
+	maintenanceValue := 0.0
+	if InMaintenance() {
+		maintenanceValue = 1
+	}
+	writeGauge(w, "tvgate_maintenance", "Whether maintenance mode is enabled (1) or not (0).", maintenanceValue)
+	firstFrameHistogram.write(w, "tvgate_first_frame_seconds", "Time from client subscription to first stream frame delivered.", openMetrics)
+	proxyResponseHistogram.write(w, "tvgate_proxy_response_time_seconds", "Proxy speed test response time.", openMetrics)
+	writeIfaceMetrics(w, openMetrics)
+	writeMemoryShedMetric(w, openMetrics)
+	writeFastStartMetrics(w, openMetrics)
+
+	if openMetrics {
+		io.WriteString(w, "# EOF\n")
+	}
+}
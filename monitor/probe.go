@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ProbeResult 对运行中 Hub 的一次静默探测结果
+type ProbeResult struct {
+	HubKey     string
+	Duration   time.Duration
+	Frames     int
+	Bytes      uint64
+	FirstFrame time.Duration // 订阅到收到第一帧的耗时，未收到数据时为 0
+	Flowing    bool          // 探测期间是否收到数据
+}
+
+const (
+	defaultProbeDuration = 2 * time.Second
+	maxProbeDuration     = 10 * time.Second
+)
+
+var (
+	probeFunc func(keyOrAddr string, d time.Duration) (ProbeResult, bool)
+	probeMu   sync.RWMutex
+)
+
+// RegisterProbeFunc 注册 Hub 探测函数（由 stream 包注册，避免循环依赖）
+func RegisterProbeFunc(fn func(keyOrAddr string, d time.Duration) (ProbeResult, bool)) {
+	probeMu.Lock()
+	defer probeMu.Unlock()
+	probeFunc = fn
+}
+
+// HandleProbe 以静默订阅者身份探测指定 Hub 是否有数据流入（?hub= HubKey 或源地址，?duration= 默认 2s、最长 10s）；
+// 探测不计入观看人数，也不会让无人观看的 Hub 保持运行；Hub 不存在时返回 404
+func HandleProbe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("server", "TVGate")
+	w.Header().Set("Content-Type", "application/json")
+	setCacheHeaders(w)
+
+	key := r.URL.Query().Get("hub")
+	if key == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "参数 hub 必须提供"})
+		return
+	}
+	d := defaultProbeDuration
+	if s := r.URL.Query().Get("duration"); s != "" {
+		v, err := time.ParseDuration(s)
+		if err != nil || v <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "无效的 duration: " + s})
+			return
+		}
+		d = min(v, maxProbeDuration)
+	}
+
+	probeMu.RLock()
+	fn := probeFunc
+	probeMu.RUnlock()
+	if fn == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "探测不可用"})
+		return
+	}
+	res, ok := fn(key, d)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "未找到对应的 Hub: " + key})
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,40 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+)
+
+// ProxyDNSStatus 代理主机名的解析缓存状态
+type ProxyDNSStatus struct {
+	Host       string
+	IPs        []string // 全部 A/AAAA 记录
+	LastIP     string   // 最近一次使用的地址
+	ResolvedAt time.Time
+	Expires    time.Time
+	Error      string // 最近一次解析错误，成功后清空
+	ErrorAt    time.Time
+}
+
+var (
+	proxyDNSFunc func() []ProxyDNSStatus
+	proxyDNSMu   sync.RWMutex
+)
+
+// RegisterProxyDNSFunc 注册代理 DNS 缓存状态采集函数（由 proxy 包注册，避免循环依赖）
+func RegisterProxyDNSFunc(fn func() []ProxyDNSStatus) {
+	proxyDNSMu.Lock()
+	defer proxyDNSMu.Unlock()
+	proxyDNSFunc = fn
+}
+
+// GetProxyDNSStatuses 获取代理 DNS 缓存状态，未注册时返回 nil
+func GetProxyDNSStatuses() []ProxyDNSStatus {
+	proxyDNSMu.RLock()
+	fn := proxyDNSFunc
+	proxyDNSMu.RUnlock()
+	if fn == nil {
+		return nil
+	}
+	return fn()
+}
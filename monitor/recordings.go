@@ -0,0 +1,41 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+)
+
+// RecordingStatus 正在进行的按需录制
+type RecordingStatus struct {
+	ID        string
+	Channel   string // 频道路径，直接按地址录制时为空
+	Addr      string
+	File      string // 当前写入的文件
+	Files     int    // 本次录制已生成的文件数（含已轮转删除的）
+	Bytes     uint64
+	StartedAt time.Time
+	Until     time.Time // 到期自动停止的时间
+}
+
+var (
+	recordingsFunc func() []RecordingStatus
+	recordingsMu   sync.RWMutex
+)
+
+// RegisterRecordingsFunc 注册录制状态采集函数（由 stream 包注册，避免循环依赖）
+func RegisterRecordingsFunc(fn func() []RecordingStatus) {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	recordingsFunc = fn
+}
+
+// GetRecordings 获取正在进行的录制，未注册时返回 nil
+func GetRecordings() []RecordingStatus {
+	recordingsMu.RLock()
+	fn := recordingsFunc
+	recordingsMu.RUnlock()
+	if fn == nil {
+		return nil
+	}
+	return fn()
+}
@@ -0,0 +1,31 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// HandleRefresh 立即采样一次系统统计（POST），供外部缓存层在读取前主动刷新；
+// 距上次采样不足 minBandwidthInterval（及 monitor.min_refresh_interval）时直接返回现有数据，避免带宽计算失真与高频采样
+func HandleRefresh(w http.ResponseWriter, r *http.Request) {
+	floor := minBandwidthInterval
+	if min, _ := refreshSettings(); min > floor {
+		floor = min
+	}
+	_, last := GlobalTrafficStats.OutboundRate()
+	refreshed := false
+	if time.Since(last) >= floor {
+		refreshSystemStats()
+		refreshed = true
+	}
+	_, updated := GlobalTrafficStats.OutboundRate()
+
+	w.Header().Set("server", "TVGate")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":     "success",
+		"refreshed":  refreshed,
+		"updated_at": updated.Format(time.RFC3339),
+	})
+}
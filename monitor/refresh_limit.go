@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+const (
+	// refreshBurst 同一客户端允许的突发请求数（手动刷新、多个标签页），之后按最小刷新间隔补充
+	refreshBurst = 3
+	// refreshLimiterMaxIPs 记录的客户端上限，超出时清理已补满的条目
+	refreshLimiterMaxIPs = 4096
+	// defaultMinRefreshInterval 未加载配置时的最小刷新间隔
+	defaultMinRefreshInterval = 3 * time.Second
+)
+
+// refreshSettings 返回状态页最小刷新间隔及可选刷新间隔（已过滤掉小于最小间隔的项并排序）
+func refreshSettings() (time.Duration, []time.Duration) {
+	config.CfgMu.RLock()
+	min := config.Cfg.Monitor.MinRefreshInterval
+	list := append([]time.Duration(nil), config.Cfg.Monitor.RefreshIntervals...)
+	config.CfgMu.RUnlock()
+	if min <= 0 {
+		min = defaultMinRefreshInterval
+	}
+
+	options := list[:0]
+	for _, d := range list {
+		if d >= min {
+			options = append(options, d)
+		}
+	}
+	sort.Slice(options, func(i, j int) bool { return options[i] < options[j] })
+	if len(options) == 0 {
+		options = []time.Duration{min}
+	}
+	return min, options
+}
+
+// refreshBucket 单个客户端的令牌桶
+type refreshBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+var (
+	refreshBuckets   = make(map[string]*refreshBucket)
+	refreshBucketsMu sync.Mutex
+)
+
+// allowRefresh 按客户端 IP 的令牌桶判断是否允许本次请求，不允许时返回需要等待的时间
+func allowRefresh(ip string, interval time.Duration, now time.Time) (bool, time.Duration) {
+	refreshBucketsMu.Lock()
+	defer refreshBucketsMu.Unlock()
+
+	b := refreshBuckets[ip]
+	if b == nil {
+		if len(refreshBuckets) >= refreshLimiterMaxIPs {
+			pruneRefreshBuckets(interval, now)
+		}
+		b = &refreshBucket{tokens: refreshBurst, last: now}
+		refreshBuckets[ip] = b
+	}
+	b.tokens += float64(now.Sub(b.last)) / float64(interval)
+	if b.tokens > refreshBurst {
+		b.tokens = refreshBurst
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) * float64(interval))
+}
+
+// pruneRefreshBuckets 删除已经补满令牌的客户端；调用方持有 refreshBucketsMu
+func pruneRefreshBuckets(interval time.Duration, now time.Time) {
+	for ip, b := range refreshBuckets {
+		if now.Sub(b.last) >= interval*refreshBurst {
+			delete(refreshBuckets, ip)
+		}
+	}
+}
+
+// limitRefresh 在服务端强制状态页的最小刷新间隔：同一客户端超出突发额度后返回 429 + Retry-After，
+// 即使客户端绕过页面上的间隔选项也无法高频轮询。
+// MessagePack 状态本就是为嵌入式面板高频轮询提供的，不受该间隔限制（仍受 monitor.max_concurrent 约束）
+func limitRefresh(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if wantsMsgpack(r) {
+			next(w, r)
+			return
+		}
+		min, _ := refreshSettings()
+		ok, wait := allowRefresh(GetClientIP(r), min, time.Now())
+		if !ok {
+			secs := int((wait + time.Second - 1) / time.Second)
+			w.Header().Set("server", "TVGate")
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			WriteError(w, r, http.StatusTooManyRequests, "刷新过于频繁，最小刷新间隔为 "+min.String())
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/qist/tvgate/config"
+)
+
+// monitorEndpoint 监控路径下的子接口
+type monitorEndpoint struct {
+	Path        string // 相对监控路径的子路径，"" 表示监控路径本身
+	Method      string // 允许的请求方法，"" 表示只读接口（GET/HEAD）；修改状态的操作使用 POST
+	Description string
+	handler     http.HandlerFunc
+}
+
+// allows 判断接口是否接受该请求方法
+func (ep monitorEndpoint) allows(method string) bool {
+	if ep.Method == "" {
+		return method == http.MethodGet || method == http.MethodHead
+	}
+	return method == ep.Method
+}
+
+// allowHeader 返回 405 响应的 Allow 头
+func (ep monitorEndpoint) allowHeader() string {
+	if ep.Method == "" {
+		return "GET, HEAD"
+	}
+	return ep.Method
+}
+
+// monitorEndpoints 监控命名空间下的全部接口，同时用于 404 页面的接口列表
+var monitorEndpoints = []monitorEndpoint{
+	{Path: "", Description: "状态页面（?format=json 返回 JSON，?format=text 或 Accept: text/plain 返回文本摘要，?static=1 不自动刷新；同一客户端超过 monitor.min_refresh_interval 的频率返回 429（?format=msgpack 除外）；维护模式下返回 503 维护提示）", handler: limitRefresh(duringMaintenance(handleStatusPage))},
+	{Path: "/metrics", Description: "Prometheus 指标", handler: HandleMetrics},
+	{Path: "/clients", Description: "活跃客户端 JSON（?hub= 按频道过滤）", handler: HandleClients},
+	{Path: "/channels", Description: "全部已配置频道及状态 JSON（active/idle/error，?tag=、?status= 过滤）", handler: HandleChannels},
+	{Path: "/capabilities", Description: "能力清单 JSON：状态接口支持的格式、流可协商的 Content-Type、各频道的输出方式", handler: HandleCapabilities},
+	{Path: "/probe", Description: "静默探测 Hub 是否有数据（?hub= HubKey 或源地址，?duration= 默认 2s），不计入观看人数", handler: HandleProbe},
+	{Path: "/healthz", Description: "存活检查 JSON：进程正常即返回 200，maintenance 字段标明是否处于维护模式（挂在监控路径下，不占用代理的 /healthz）", handler: HandleHealthz},
+	{Path: "/refresh", Method: http.MethodPost, Description: "立即刷新系统统计（CPU、内存、网卡流量等）", handler: HandleRefresh},
+}
+
+// monitorBasePath 返回配置的监控路径（不含结尾的 /）
+func monitorBasePath() string {
+	config.CfgMu.RLock()
+	path := config.Cfg.Monitor.Path
+	config.CfgMu.RUnlock()
+	if path == "" {
+		path = "/status"
+	}
+	return strings.TrimSuffix(path, "/")
+}
+
+var notFoundTmpl = template.Must(template.New("notfound").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<title>404 - TVGate 状态监控</title>
+<style>
+body { font-family: 'Segoe UI', sans-serif; max-width:800px; margin:40px auto; background:#121212; color:#e0e0e0; }
+.header { background:#1f1f1f; padding:20px; border-radius:10px; margin-bottom:20px; }
+.header h1 { margin:0; }
+a { color:#4CAF50; }
+li { margin:6px 0; }
+code { color:#aaa; }
+</style>
+</head>
+<body>
+<div class="header">
+<h1>404 · 未找到</h1>
+<p>监控路径下不存在 <code>{{.Path}}</code></p>
+</div>
+<h2>可用接口</h2>
+<ul>
+{{range .Endpoints}}<li><code>{{.Method}}</code> {{if eq .Method "GET"}}<a href="{{.URL}}">{{.URL}}</a>{{else}}{{.URL}}{{end}} — {{.Description}}</li>
+{{end}}</ul>
+</body>
+</html>`))
+
+// handleMethodNotAllowed 请求方法与接口不匹配时返回 405
+func handleMethodNotAllowed(w http.ResponseWriter, r *http.Request, ep monitorEndpoint) {
+	w.Header().Set("server", "TVGate")
+	w.Header().Set("Allow", ep.allowHeader())
+	WriteError(w, r, http.StatusMethodNotAllowed, "Method Not Allowed")
+}
+
+// handleMonitorNotFound 监控命名空间的 404 响应，列出可用接口；按 Accept 或 ?format=json 返回 JSON
+func handleMonitorNotFound(w http.ResponseWriter, r *http.Request) {
+	base := monitorBasePath()
+	type endpointInfo struct {
+		Method      string
+		URL         string
+		Description string
+	}
+	endpoints := make([]endpointInfo, 0, len(monitorEndpoints))
+	for _, ep := range monitorEndpoints {
+		url := base + ep.Path
+		if url == "" {
+			url = "/"
+		}
+		method := ep.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		endpoints = append(endpoints, endpointInfo{Method: method, URL: url, Description: ep.Description})
+	}
+
+	w.Header().Set("server", "TVGate")
+	if WantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": APIErrorBody{
+				Code:    errorCode(http.StatusNotFound),
+				Status:  http.StatusNotFound,
+				Message: "监控路径下不存在 " + r.URL.Path,
+			},
+			"path":      r.URL.Path,
+			"endpoints": endpoints,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	notFoundTmpl.Execute(w, map[string]any{
+		"Path":      r.URL.Path,
+		"Endpoints": endpoints,
+	})
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qist/tvgate/config"
+)
+
+// 存活检查位于监控路径下，维护模式下仍返回 200 并如实标明
+func TestMonitorHealthz(t *testing.T) {
+	config.MarkLoaded()
+	SetMaintenance(true, "", "admin")
+	defer SetMaintenance(false, "", "admin")
+
+	rec := httptest.NewRecorder()
+	HandleMonitor(rec, httptest.NewRequest(http.MethodGet, monitorBasePath()+"/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Status      string            `json:"status"`
+		Maintenance MaintenanceStatus `json:"maintenance"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Status != "ok" || !body.Maintenance.Enabled {
+		t.Errorf("body = %q, err = %v", rec.Body.String(), err)
+	}
+}
+
+// withProxyStats 临时替换代理组配置为单个代理 p 的统计 stats，测试结束后恢复
+func withProxyStats(t *testing.T, stats *config.ProxyStats) {
+	t.Helper()
+	config.CfgMu.Lock()
+	saved := config.Cfg.ProxyGroups
+	config.Cfg.ProxyGroups = map[string]*config.ProxyGroupConfig{
+		"g": {
+			Proxies: []*config.ProxyConfig{{Name: "p", Type: "socks5"}},
+			Stats:   &config.GroupStats{ProxyStats: map[string]*config.ProxyStats{"p": stats}},
+		},
+	}
+	config.CfgMu.Unlock()
+	t.Cleanup(func() {
+		config.CfgMu.Lock()
+		config.Cfg.ProxyGroups = saved
+		config.CfgMu.Unlock()
+	})
+}
+
+// proxyStatsField 从状态 JSON 中取出代理组 g 中代理 p 的统计字段，字段名随命名风格变化
+func proxyStatsField(t *testing.T, body map[string]any, naming, field string) any {
+	t.Helper()
+	keys := []string{"ProxyGroups", "g", "Stats", "ProxyStats", "p"}
+	if naming == JSONNamingSnake {
+		keys = []string{"proxy_groups", "g", "stats", "proxy_stats", "p"}
+	}
+	var v any = body
+	for _, k := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("%s: %v is %T, want object", naming, keys, v)
+		}
+		v = m[k]
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		t.Fatalf("%s: proxy stats is %T, want object", naming, v)
+	}
+	return m[field]
+}
+
+// 活跃连接数为原子计数器，状态 JSON 中须输出为数值而不是空对象
+func TestStatusJSONActiveConns(t *testing.T) {
+	stats := &config.ProxyStats{Alive: true}
+	stats.ActiveConns.Store(3)
+	withProxyStats(t, stats)
+
+	for naming, field := range map[string]string{JSONNamingLegacy: "ActiveConns", JSONNamingSnake: "active_conns"} {
+		rec := httptest.NewRecorder()
+		handleJSONRequest(rec, httptest.NewRequest(http.MethodGet, "/status?format=json&naming="+naming, nil))
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: %v", naming, err)
+		}
+		if got := proxyStatsField(t, body, naming, field); got != float64(3) {
+			t.Errorf("%s: %s = %#v, want 3", naming, field, got)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// serverTiming 状态页各阶段耗时，输出为 Server-Timing 响应头，在浏览器开发者工具的 Timing 面板中查看
+// 仅在 monitor.server_timing 开启时创建；方法对 nil 接收者是空操作，调用方无需判断
+type serverTiming struct {
+	start   time.Time
+	entries []string
+}
+
+type serverTimingKey struct{}
+
+func serverTimingEnabled() bool {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	return config.Cfg.Monitor.ServerTiming
+}
+
+// withServerTiming 开启时在请求上下文中挂载计时器
+func withServerTiming(r *http.Request) *http.Request {
+	if !serverTimingEnabled() {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), serverTimingKey{}, &serverTiming{start: time.Now()}))
+}
+
+// timingOf 返回请求上的计时器，未开启时为 nil
+func timingOf(r *http.Request) *serverTiming {
+	t, _ := r.Context().Value(serverTimingKey{}).(*serverTiming)
+	return t
+}
+
+// since 记录从 start 到现在的一个阶段
+func (t *serverTiming) since(name, desc string, start time.Time) {
+	if t == nil {
+		return
+	}
+	dur := float64(time.Since(start).Microseconds()) / 1000
+	t.entries = append(t.entries, fmt.Sprintf("%s;desc=%q;dur=%.3f", name, desc, dur))
+}
+
+// write 设置 Server-Timing 响应头（附带总耗时），需在写出响应体之前调用
+func (t *serverTiming) write(w http.ResponseWriter) {
+	if t == nil {
+		return
+	}
+	t.since("total", "Total", t.start)
+	w.Header().Set("Server-Timing", strings.Join(t.entries, ", "))
+}
@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// defaultRecentSessions 未配置 monitor.recent_sessions 时保留的会话数
+const defaultRecentSessions = 50
+
+// SessionRecord 已结束的客户端会话摘要
+type SessionRecord struct {
+	IP             string
+	Channel        string
+	HubKey         string
+	UserAgent      string
+	ConnectionType string
+	ConnectedAt    time.Time
+	DisconnectedAt time.Time
+	Duration       time.Duration
+	Bytes          uint64 // 会话期间发送给客户端的字节数
+	FirstFrame     string // 首帧来源：fast-start / live，未收到数据时为空
+}
+
+var (
+	recentSessions   []SessionRecord // 环形缓冲，按结束时间追加
+	recentSessionsMu sync.Mutex
+)
+
+// recentSessionsLimit 读取最近会话保留数，负数表示关闭
+func recentSessionsLimit() int {
+	config.CfgMu.RLock()
+	n := config.Cfg.Monitor.RecentSessions
+	config.CfgMu.RUnlock()
+	if n == 0 {
+		n = defaultRecentSessions
+	}
+	return n
+}
+
+// RecordSession 记录一个结束的会话，超过保留数时丢弃最旧的记录
+func RecordSession(s SessionRecord) {
+	limit := recentSessionsLimit()
+	recentSessionsMu.Lock()
+	defer recentSessionsMu.Unlock()
+	if limit < 0 {
+		recentSessions = nil
+		return
+	}
+	recentSessions = append(recentSessions, s)
+	if over := len(recentSessions) - limit; over > 0 {
+		recentSessions = append(recentSessions[:0:0], recentSessions[over:]...)
+	}
+}
+
+// RecentSessions 返回最近结束的会话，最新的在前
+func RecentSessions() []SessionRecord {
+	recentSessionsMu.Lock()
+	defer recentSessionsMu.Unlock()
+	out := make([]SessionRecord, len(recentSessions))
+	for i, s := range recentSessions {
+		out[len(out)-1-i] = s
+	}
+	return out
+}
@@ -0,0 +1,161 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+)
+
+// persistedState 跨重启保留的累计计数
+type persistedState struct {
+	SavedAt      time.Time
+	Traffic      trafficState
+	PeakClients  int
+	PeakAt       time.Time
+	ChannelBytes map[string]uint64 // 按源地址的累计接收字节
+}
+
+// trafficState 系统累计流量及其基线
+type trafficState struct {
+	CountersResetAt time.Time
+	InboundBytes    uint64 // 保存时的累计值
+	OutboundBytes   uint64
+	RawInbound      uint64 // 保存时的网卡原始计数，用于判断系统是否重启过
+	RawOutbound     uint64
+	BaseInbound     uint64
+	BaseOutbound    uint64
+	CarryInbound    uint64
+	CarryOutbound   uint64
+}
+
+var stateSaveMu sync.Mutex
+
+// applyRestore 应用持久化的流量状态；调用方需持有 ts.mu，且 rawInbound/rawOutbound 已为当前值
+func (ts *TrafficStats) applyRestore(p *trafficState) {
+	if ts.rawInbound >= p.RawInbound && ts.rawOutbound >= p.RawOutbound {
+		// 系统未重启：网卡计数连续，沿用原基线
+		ts.baseInbound, ts.baseOutbound = p.BaseInbound, p.BaseOutbound
+		ts.carryInbound, ts.carryOutbound = p.CarryInbound, p.CarryOutbound
+	} else {
+		// 系统已重启：网卡计数从 0 开始，已累计的值作为接续量
+		ts.baseInbound, ts.baseOutbound = 0, 0
+		ts.carryInbound, ts.carryOutbound = p.InboundBytes, p.OutboundBytes
+	}
+	ts.CountersResetAt = p.CountersResetAt
+}
+
+// snapshotTrafficState 读取当前可持久化的流量状态
+func (ts *TrafficStats) snapshotTrafficState() trafficState {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if p := ts.pendingRestore; p != nil {
+		// 尚未完成首次采样，原样保留待恢复的状态
+		return *p
+	}
+	return trafficState{
+		CountersResetAt: ts.CountersResetAt,
+		InboundBytes:    ts.InboundBytes,
+		OutboundBytes:   ts.OutboundBytes,
+		RawInbound:      ts.rawInbound,
+		RawOutbound:     ts.rawOutbound,
+		BaseInbound:     ts.baseInbound,
+		BaseOutbound:    ts.baseOutbound,
+		CarryInbound:    ts.carryInbound,
+		CarryOutbound:   ts.carryOutbound,
+	}
+}
+
+// statePath 读取持久化文件路径，为空表示关闭
+func statePath() string {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	return config.Cfg.Monitor.StateFile
+}
+
+// StartStatePersistence 启动时恢复持久化状态，之后按 monitor.state_interval 周期保存；
+// 磁盘读写均在独立协程中进行，失败仅记录日志，不影响转发
+func StartStatePersistence() {
+	if path := statePath(); path != "" {
+		loadState(path)
+	}
+	go func() {
+		for {
+			config.CfgMu.RLock()
+			interval := config.Cfg.Monitor.StateInterval
+			config.CfgMu.RUnlock()
+			if interval <= 0 {
+				interval = time.Minute
+			}
+			time.Sleep(interval)
+			SaveState()
+		}
+	}()
+}
+
+// loadState 读取并恢复持久化状态，文件不存在时静默跳过
+func loadState(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.LogPrintf("⚠️ 读取状态文件 %s 失败: %v", path, err)
+		}
+		return
+	}
+	var st persistedState
+	if err := json.Unmarshal(data, &st); err != nil {
+		logger.LogPrintf("⚠️ 解析状态文件 %s 失败: %v", path, err)
+		return
+	}
+
+	GlobalTrafficStats.mu.Lock()
+	GlobalTrafficStats.pendingRestore = &st.Traffic
+	GlobalTrafficStats.mu.Unlock()
+	ActiveClients.restorePeak(st.PeakClients, st.PeakAt)
+	restoreChannelBytes(st.ChannelBytes)
+	logger.LogPrintf("💾 已恢复持久化状态 %s（保存于 %s）", path, st.SavedAt.Format("2006-01-02 15:04:05"))
+}
+
+// SaveState 将累计计数写入状态文件（先写临时文件再重命名，避免中途崩溃损坏文件）
+func SaveState() {
+	path := statePath()
+	if path == "" {
+		return
+	}
+	stateSaveMu.Lock()
+	defer stateSaveMu.Unlock()
+
+	peak, peakAt := ActiveClients.Peak()
+	st := persistedState{
+		SavedAt:      time.Now(),
+		Traffic:      GlobalTrafficStats.snapshotTrafficState(),
+		PeakClients:  peak,
+		PeakAt:       peakAt,
+		ChannelBytes: ChannelTotalBytes(),
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		logger.LogPrintf("⚠️ 序列化状态失败: %v", err)
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tvgate-state-*")
+	if err != nil {
+		logger.LogPrintf("⚠️ 保存状态文件 %s 失败: %v", path, err)
+		return
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil {
+		_ = os.Remove(tmp.Name())
+		logger.LogPrintf("⚠️ 保存状态文件 %s 失败: %v %v", path, werr, cerr)
+		return
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		logger.LogPrintf("⚠️ 保存状态文件 %s 失败: %v", path, err)
+	}
+}
@@ -0,0 +1,192 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MsgpackContentType 状态的二进制 (MessagePack) 编码
+const MsgpackContentType = "application/msgpack"
+
+// wantsMsgpack 是否请求 MessagePack 编码：?format=msgpack 或 Accept: application/msgpack（兼容 application/x-msgpack）
+func wantsMsgpack(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "msgpack" {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "msgpack")
+}
+
+// handleMsgpackRequest 以 MessagePack 输出状态：与 JSON 共用 StatusData 及字段命名（?naming=），
+// 响应体为 4 字节大端长度前缀 + 一个 MessagePack map，便于嵌入式客户端按帧读取
+func handleMsgpackRequest(w http.ResponseWriter, r *http.Request) {
+	data := prepareStatusData(r)
+	fillHumanFields(&data)
+
+	naming := jsonNaming(r)
+	var v any = data
+	if naming == JSONNamingSnake {
+		v = snakeTree(reflect.ValueOf(data))
+	}
+	js, err := json.Marshal(v)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "状态编码失败: "+err.Error())
+		return
+	}
+	var body bytes.Buffer
+	body.Write([]byte{0, 0, 0, 0})
+	if err := jsonToMsgpack(&body, js); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "状态编码失败: "+err.Error())
+		return
+	}
+	out := body.Bytes()
+	binary.BigEndian.PutUint32(out, uint32(len(out)-4))
+
+	w.Header().Set("server", "TVGate")
+	w.Header().Set("X-TVGate-JSON-Naming", naming)
+	w.Header().Set("Content-Type", MsgpackContentType)
+	w.Write(out)
+}
+
+// jsonToMsgpack 将一个 JSON 文档转码为 MessagePack，保持对象字段顺序；
+// 整数编码为最短的 int/uint 格式，其余数值为 float64
+func jsonToMsgpack(buf *bytes.Buffer, js []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+	return msgpackValue(buf, dec)
+}
+
+func msgpackValue(buf *bytes.Buffer, dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if t {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case string:
+		msgpackString(buf, t)
+	case json.Number:
+		msgpackNumber(buf, t)
+	case json.Delim:
+		var (
+			sub bytes.Buffer
+			n   int
+		)
+		for dec.More() {
+			if t == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				msgpackString(&sub, key.(string))
+			}
+			if err := msgpackValue(&sub, dec); err != nil {
+				return err
+			}
+			n++
+		}
+		if _, err := dec.Token(); err != nil { // 结束的 } 或 ]
+			return err
+		}
+		if t == '{' {
+			msgpackHeader(buf, n, 0x80, 0xde, 0xdf)
+		} else {
+			msgpackHeader(buf, n, 0x90, 0xdc, 0xdd)
+		}
+		buf.Write(sub.Bytes())
+	default:
+		return fmt.Errorf("unexpected JSON token %v", tok)
+	}
+	return nil
+}
+
+// msgpackHeader 写入 map/array 头：元素数 < 16 用 fix 格式，否则 16/32 位长度
+func msgpackHeader(buf *bytes.Buffer, n int, fix, b16, b32 byte) {
+	switch {
+	case n < 16:
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(b16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		buf.WriteByte(b32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+func msgpackString(buf *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		buf.WriteByte(0xdb)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+	buf.WriteString(s)
+}
+
+func msgpackNumber(buf *bytes.Buffer, num json.Number) {
+	if i, err := num.Int64(); err == nil {
+		msgpackInt(buf, i)
+		return
+	}
+	if u, err := strconv.ParseUint(num.String(), 10, 64); err == nil {
+		buf.WriteByte(0xcf)
+		buf.Write(binary.BigEndian.AppendUint64(nil, u))
+		return
+	}
+	f, _ := num.Float64()
+	buf.WriteByte(0xcb)
+	buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+func msgpackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i < 128:
+		buf.WriteByte(byte(i))
+	case i >= -32 && i < 0:
+		buf.WriteByte(byte(int8(i)))
+	case i >= 0 && i <= math.MaxUint8:
+		buf.Write([]byte{0xcc, byte(i)})
+	case i >= 0 && i <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(i)))
+	case i >= 0 && i <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(i)))
+	case i >= 0:
+		buf.WriteByte(0xcf)
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	case i >= math.MinInt8:
+		buf.Write([]byte{0xd0, byte(int8(i))})
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(int16(i))))
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(i))))
+	default:
+		buf.WriteByte(0xd3)
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	}
+}
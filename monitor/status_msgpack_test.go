@@ -0,0 +1,310 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/qist/tvgate/config"
+)
+
+// decodeMsgpack 测试用的 MessagePack 解码器，覆盖 jsonToMsgpack 可能输出的全部格式；
+// 整数解码为 int64（超出 int64 的为 uint64），浮点为 float64，map 为 map[string]any
+func decodeMsgpack(b []byte) (any, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, fmt.Errorf("unexpected end of input")
+	}
+	c, b := b[0], b[1:]
+	need := func(n int) ([]byte, []byte, error) {
+		if len(b) < n {
+			return nil, nil, fmt.Errorf("short input for 0x%02x", c)
+		}
+		return b[:n], b[n:], nil
+	}
+	var n int
+	switch {
+	case c <= 0x7f:
+		return int64(c), b, nil
+	case c >= 0xe0:
+		return int64(int8(c)), b, nil
+	case c&0xe0 == 0xa0:
+		s, rest, err := need(int(c & 0x1f))
+		return string(s), rest, err
+	case c&0xf0 == 0x80:
+		return decodeMap(b, int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return decodeArray(b, int(c&0x0f))
+	}
+	switch c {
+	case 0xc0:
+		return nil, b, nil
+	case 0xc2, 0xc3:
+		return c == 0xc3, b, nil
+	case 0xcc, 0xcd, 0xce, 0xcf, 0xd0, 0xd1, 0xd2, 0xd3, 0xcb:
+		size := map[byte]int{0xcc: 1, 0xcd: 2, 0xce: 4, 0xcf: 8, 0xd0: 1, 0xd1: 2, 0xd2: 4, 0xd3: 8, 0xcb: 8}[c]
+		raw, rest, err := need(size)
+		if err != nil {
+			return nil, nil, err
+		}
+		var u uint64
+		for _, x := range raw {
+			u = u<<8 | uint64(x)
+		}
+		switch c {
+		case 0xcb:
+			return math.Float64frombits(u), rest, nil
+		case 0xcf:
+			if u > math.MaxInt64 {
+				return u, rest, nil
+			}
+			return int64(u), rest, nil
+		case 0xd0:
+			return int64(int8(u)), rest, nil
+		case 0xd1:
+			return int64(int16(u)), rest, nil
+		case 0xd2:
+			return int64(int32(u)), rest, nil
+		case 0xd3:
+			return int64(u), rest, nil
+		}
+		return int64(u), rest, nil
+	case 0xd9, 0xda, 0xdb, 0xdc, 0xdd, 0xde, 0xdf:
+		size := map[byte]int{0xd9: 1, 0xda: 2, 0xdb: 4, 0xdc: 2, 0xdd: 4, 0xde: 2, 0xdf: 4}[c]
+		raw, rest, err := need(size)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, x := range raw {
+			n = n<<8 | int(x)
+		}
+		b = rest
+		switch c {
+		case 0xdc, 0xdd:
+			return decodeArray(b, n)
+		case 0xde, 0xdf:
+			return decodeMap(b, n)
+		}
+		s, rest, err := need(n)
+		return string(s), rest, err
+	}
+	return nil, nil, fmt.Errorf("unsupported format 0x%02x", c)
+}
+
+func decodeArray(b []byte, n int) (any, []byte, error) {
+	arr := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		v, rest, err := decodeMsgpack(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		arr, b = append(arr, v), rest
+	}
+	return arr, b, nil
+}
+
+func decodeMap(b []byte, n int) (any, []byte, error) {
+	m := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		k, rest, err := decodeMsgpack(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("non-string key %v", k)
+		}
+		v, rest, err := decodeMsgpack(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		m[key], b = v, rest
+	}
+	return m, b, nil
+}
+
+// jsonCanonical 按 decodeMsgpack 的类型约定解析 JSON，作为往返比较的基准
+func jsonCanonical(t *testing.T, js []byte) any {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	var walk func(any) any
+	walk = func(v any) any {
+		switch x := v.(type) {
+		case json.Number:
+			if i, err := x.Int64(); err == nil {
+				return i
+			}
+			if u, err := strconv.ParseUint(x.String(), 10, 64); err == nil {
+				return u
+			}
+			f, _ := x.Float64()
+			return f
+		case []any:
+			for i := range x {
+				x[i] = walk(x[i])
+			}
+		case map[string]any:
+			for k := range x {
+				x[k] = walk(x[k])
+			}
+		}
+		return v
+	}
+	return walk(v)
+}
+
+func roundTrip(t *testing.T, js []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jsonToMsgpack(&buf, js); err != nil {
+		t.Fatalf("jsonToMsgpack(%.80s): %v", js, err)
+	}
+	got, rest, err := decodeMsgpack(buf.Bytes())
+	if err != nil || len(rest) != 0 {
+		t.Fatalf("decode(%.80s): err = %v, %d trailing bytes", js, err, len(rest))
+	}
+	if want := jsonCanonical(t, js); !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip of %.80s:\n got  %#v\n want %#v", js, got, want)
+	}
+}
+
+func TestMsgpackRoundTripNumbers(t *testing.T) {
+	for _, n := range []string{
+		"0", "1", "127", "128", "255", "256", "65535", "65536", "4294967295", "4294967296",
+		"9223372036854775807", "18446744073709551615",
+		"-1", "-32", "-33", "-128", "-129", "-32768", "-32769", "-2147483648", "-2147483649", "-9223372036854775808",
+		"1.5", "-0.25", "1e300", "3.141592653589793", "12345678901234567890123",
+	} {
+		roundTrip(t, []byte(n))
+	}
+}
+
+func TestMsgpackRoundTripStrings(t *testing.T) {
+	for _, n := range []int{0, 1, 31, 32, 255, 256, 65535, 65536} {
+		js, _ := json.Marshal(strings.Repeat("a", n))
+		roundTrip(t, js)
+	}
+	roundTrip(t, []byte(`"频道 CCTV-1 \"高清\" \u00e9\n"`))
+}
+
+func TestMsgpackRoundTripNested(t *testing.T) {
+	// 15/16 个元素分别落在 fix 格式与 16 位长度格式两侧
+	m15, m16 := map[string]any{}, map[string]any{}
+	var a15, a16 []any
+	for i := 0; i < 16; i++ {
+		if i < 15 {
+			m15[fmt.Sprint("k", i)] = i
+			a15 = append(a15, i)
+		}
+		m16[fmt.Sprint("k", i)] = -i
+		a16 = append(a16, fmt.Sprint(i))
+	}
+	big := make([]int, 70000)
+	doc := map[string]any{
+		"null": nil, "t": true, "f": false,
+		"m15": m15, "m16": m16, "a15": a15, "a16": a16, "big": big,
+		"nested": map[string]any{"hubs": []any{map[string]any{"addr": "239.1.1.1:5000", "clients": 3, "rate": 1.25, "ifaces": []string{}}}},
+		"empty":  map[string]any{},
+	}
+	js, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, js)
+
+	// 对象字段顺序与 JSON 一致
+	var buf bytes.Buffer
+	if err := jsonToMsgpack(&buf, []byte(`{"b":1,"a":2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x82, 0xa1, 'b', 0x01, 0xa1, 'a', 0x02}; !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("field order: got % x, want % x", buf.Bytes(), want)
+	}
+}
+
+// 二进制状态供高频轮询：不受状态页的最小刷新间隔限制，HTML/JSON 请求仍然限频
+func TestMsgpackFeedNotRefreshLimited(t *testing.T) {
+	calls := 0
+	h := limitRefresh(func(w http.ResponseWriter, r *http.Request) { calls++ })
+	do := func(target, accept string) int {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = "192.0.2.186:1234"
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		return rec.Code
+	}
+	for i := 0; i < 10*refreshBurst; i++ {
+		if code := do("/status?format=msgpack", ""); code != http.StatusOK {
+			t.Fatalf("msgpack poll #%d: status = %d", i, code)
+		}
+		if code := do("/status", MsgpackContentType); code != http.StatusOK {
+			t.Fatalf("msgpack (Accept) poll #%d: status = %d", i, code)
+		}
+	}
+	limited := false
+	for i := 0; i <= refreshBurst; i++ {
+		limited = limited || do("/status?format=json", "") == http.StatusTooManyRequests
+	}
+	if !limited {
+		t.Error("JSON polling was not rate limited")
+	}
+}
+
+// 响应体为 4 字节大端长度前缀 + 一个 MessagePack map
+func TestHandleMsgpackRequestFraming(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleMsgpackRequest(rec, httptest.NewRequest(http.MethodGet, "/status?format=msgpack", nil))
+	body := rec.Body.Bytes()
+	if rec.Code != http.StatusOK || len(body) < 5 {
+		t.Fatalf("status = %d, %d bytes", rec.Code, len(body))
+	}
+	if n := binary.BigEndian.Uint32(body); int(n) != len(body)-4 {
+		t.Fatalf("length prefix = %d, body = %d", n, len(body)-4)
+	}
+	v, rest, err := decodeMsgpack(body[4:])
+	if err != nil || len(rest) != 0 {
+		t.Fatalf("decode: %v (%d trailing bytes)", err, len(rest))
+	}
+	if _, ok := v.(map[string]any); !ok {
+		t.Errorf("top-level value is %T, want map", v)
+	}
+}
+
+// 代理转发字节数为原子计数器，二进制状态中须输出为整数而不是空 map
+func TestMsgpackBytesTransferred(t *testing.T) {
+	stats := &config.ProxyStats{Alive: true}
+	stats.BytesTransferred.Store(5 << 30)
+	withProxyStats(t, stats)
+
+	for naming, field := range map[string]string{JSONNamingLegacy: "BytesTransferred", JSONNamingSnake: "bytes_transferred"} {
+		rec := httptest.NewRecorder()
+		handleMsgpackRequest(rec, httptest.NewRequest(http.MethodGet, "/status?format=msgpack&naming="+naming, nil))
+		body := rec.Body.Bytes()
+		if rec.Code != http.StatusOK || len(body) < 4 {
+			t.Fatalf("%s: status = %d, %d bytes", naming, rec.Code, len(body))
+		}
+		v, _, err := decodeMsgpack(body[4:])
+		if err != nil {
+			t.Fatalf("%s: decode: %v", naming, err)
+		}
+		m, _ := v.(map[string]any)
+		if got := proxyStatsField(t, m, naming, field); got != int64(5<<30) {
+			t.Errorf("%s: %s = %#v, want %d", naming, field, got, int64(5<<30))
+		}
+	}
+}
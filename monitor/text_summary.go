@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// wantsTextSummary 判断是否返回纯文本摘要（Accept: text/plain 或 ?format=text）
+func wantsTextSummary(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "text" {
+		return true
+	}
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "text/plain") && !strings.Contains(accept, "text/html")
+}
+
+// handleTextRequest 输出便于终端查看和 grep 的简短状态摘要，每行一个 key: value
+func handleTextRequest(w http.ResponseWriter, r *http.Request) {
+	data := prepareStatusData(r)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "version: %s\n", data.Version)
+	fmt.Fprintf(&b, "uptime: %s\n", FormatDuration(data.Uptime))
+	fmt.Fprintf(&b, "goroutines: %d\n", data.Goroutines)
+	fmt.Fprintf(&b, "viewers: %d\n", data.TotalViewers)
+	fmt.Fprintf(&b, "hubs: %d\n", len(data.Hubs))
+	fmt.Fprintf(&b, "clients: %d\n", len(data.ActiveClients))
+	fmt.Fprintf(&b, "alarms: %d\n", len(data.Alarms))
+	for _, a := range data.Alarms {
+		fmt.Fprintf(&b, "alarm %s %s %s: %s\n", a.Severity, a.Kind, a.Target, a.Message)
+	}
+
+	names := make([]string, 0, len(data.ProxyGroups))
+	for name := range data.ProxyGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	now := time.Now()
+	for _, name := range names {
+		group := data.ProxyGroups[name]
+		alive, dead := 0, 0
+		for _, p := range group.Proxies {
+			stats := group.Stats.ProxyStats[p.Name]
+			if stats != nil && stats.Alive && now.After(stats.CooldownUntil) {
+				alive++
+			} else {
+				dead++
+			}
+		}
+		fmt.Fprintf(&b, "group %s: alive=%d dead=%d\n", name, alive, dead)
+	}
+
+	w.Header().Set("server", "TVGate")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
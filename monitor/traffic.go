@@ -1,6 +1,8 @@
 package monitor
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"os"
 	"runtime"
@@ -9,6 +11,7 @@ import (
 	"time"
 	// "fmt"
 
+	"github.com/qist/tvgate/config"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/host"
@@ -58,12 +61,21 @@ type HostInfo struct {
 
 type NetworkInterfaceInfo struct {
 	Name          string
+	Addrs         []string // 网卡地址（CIDR 形式），用于确认组播加入使用的网卡
 	BytesRecv     uint64
 	BytesSent     uint64
 	PacketsRecv   uint64
 	PacketsSent   uint64
+	DropsRecv     uint64 // 内核统计的接收丢包数（自启动以来）
+	ErrorsRecv    uint64 // 接收错误数（自启动以来）
 	RecvBandwidth uint64 // 实时接收带宽 (bytes/sec)
 	SendBandwidth uint64 // 实时发送带宽 (bytes/sec)
+
+	// 格式化字段（仅用于 JSON 输出）
+	BytesRecvHuman     string
+	BytesSentHuman     string
+	RecvBandwidthHuman string
+	SendBandwidthHuman string
 }
 
 type ProxyGroupTraffic struct {
@@ -87,6 +99,9 @@ type AppStats struct {
 	PrevIOCounters    *process.IOCountersStat
 	PrevCPUTime       float64 // ← 新增，用于计算 CPU 百分比
 	CPUTemperature    float64 // 添加CPU温度字段
+	OpenFDs           int32   // 当前打开的文件描述符数量
+	MaxFDs            uint64  // 文件描述符上限（soft limit），0 表示无法获取
+	MemoryUsageHuman  string  // 格式化字段（仅用于 JSON 输出）
 }
 
 type TrafficStats struct {
@@ -97,6 +112,8 @@ type TrafficStats struct {
 
 	InboundBytes      uint64
 	OutboundBytes     uint64
+	// 系统级实时带宽 (bytes/sec)：相邻两次采样间所有网卡收/发字节增量除以实际间隔，
+	// 采样间隔由 monitor.bandwidth_interval 控制（越大越平滑，越小越灵敏），间隔不足 1s 的样本被丢弃
 	InboundBandwidth  uint64
 	OutboundBandwidth uint64
 
@@ -122,9 +139,32 @@ type TrafficStats struct {
 	// 应用自身流量
 	App AppStats
 
+	// 格式化字段（仅用于 JSON 输出）
+	TotalBytesHuman        string
+	InboundBytesHuman      string
+	OutboundBytesHuman     string
+	InboundBandwidthHuman  string
+	OutboundBandwidthHuman string
+	MemoryUsageHuman       string
+	MemoryTotalHuman       string
+
 	LastUpdate      time.Time
 	PrevNetCounters map[string]net.IOCountersStat
+	CountersResetAt time.Time // 累计流量清零时间，零值表示自开机起累计
 	mu              sync.RWMutex
+
+	// 网卡原始累计值及清零时的基线，累计流量 = 原始值 - 基线，带宽按原始值计算不受清零影响
+	rawInbound    uint64
+	rawOutbound   uint64
+	baseInbound   uint64
+	baseOutbound  uint64
+	baseIfaceRecv map[string]uint64
+	baseIfaceSent map[string]uint64
+
+	// 持久化恢复的累计量（系统重启后网卡计数归零时接续），清零时一并归零
+	carryInbound   uint64
+	carryOutbound  uint64
+	pendingRestore *trafficState // 待首次采样时应用的持久化状态
 }
 
 // -------------------- 全局实例 --------------------
@@ -186,20 +226,108 @@ func (ts *TrafficStats) GetTrafficStats() *TrafficStats {
 		ProxyGroupStats:   proxyStatsCopy,
 		App:               appCopy,
 		LastUpdate:        ts.LastUpdate,
+		CountersResetAt:   ts.CountersResetAt,
+	}
+}
+
+// OutboundRate 返回最近一次采样的系统出口带宽 (bytes/s) 及采样时间，开销低于 GetTrafficStats
+func (ts *TrafficStats) OutboundRate() (uint64, time.Time) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.OutboundBandwidth, ts.LastUpdate
+}
+
+// ResetCounters 将累计流量计数清零（记录当前原始值为基线），实时带宽不受影响
+func (ts *TrafficStats) ResetCounters() time.Time {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	now := time.Now()
+	ts.baseInbound = ts.rawInbound
+	ts.baseOutbound = ts.rawOutbound
+	ts.baseIfaceRecv = make(map[string]uint64, len(ts.PrevNetCounters))
+	ts.baseIfaceSent = make(map[string]uint64, len(ts.PrevNetCounters))
+	for name, c := range ts.PrevNetCounters {
+		ts.baseIfaceRecv[name] = c.BytesRecv
+		ts.baseIfaceSent[name] = c.BytesSent
+	}
+	ts.carryInbound = 0
+	ts.carryOutbound = 0
+	ts.InboundBytes = 0
+	ts.OutboundBytes = 0
+	ts.TotalBytes = 0
+	for i := range ts.NetworkInterfaces {
+		ts.NetworkInterfaces[i].BytesRecv = 0
+		ts.NetworkInterfaces[i].BytesSent = 0
+	}
+	ts.CountersResetAt = now
+	return now
+}
+
+// sinceBase 返回相对基线的增量，计数器回绕或网卡重置时从 0 开始
+func sinceBase(raw, base uint64) uint64 {
+	if raw < base {
+		return raw
 	}
+	return raw - base
 }
 
 // -------------------- 系统统计 --------------------
 
+// interfaceAddrs 按网卡名返回地址列表，获取失败时返回空表
+func interfaceAddrs() map[string][]string {
+	ifaces, err := net.Interfaces()
+	recordCollector("net.interfaces", err)
+	if err != nil {
+		return nil
+	}
+	addrs := make(map[string][]string, len(ifaces))
+	for _, iface := range ifaces {
+		for _, a := range iface.Addrs {
+			addrs[iface.Name] = append(addrs[iface.Name], a.Addr)
+		}
+	}
+	return addrs
+}
+
+// minBandwidthInterval 带宽计算的最小采样间隔，避免除以过小时间差产生尖峰
+const minBandwidthInterval = time.Second
+
+// StartSystemStatsUpdater 周期性更新系统统计；每轮读取 monitor.bandwidth_interval，
+// 未配置时使用 interval，热加载后下一轮生效
 func StartSystemStatsUpdater(interval time.Duration) {
 	go func() {
 		for {
-			updateSystemStats()
-			time.Sleep(interval)
+			refreshSystemStats()
+			time.Sleep(statsInterval(interval))
 		}
 	}()
 }
 
+// systemStatsMu 串行化系统统计采样（周期更新与手动刷新共用采样状态）
+var systemStatsMu sync.Mutex
+
+// refreshSystemStats 采样一次系统统计
+func refreshSystemStats() {
+	systemStatsMu.Lock()
+	defer systemStatsMu.Unlock()
+	updateSystemStats()
+}
+
+// statsInterval 返回当前采样间隔，不低于 minBandwidthInterval
+func statsInterval(def time.Duration) time.Duration {
+	config.CfgMu.RLock()
+	d := config.Cfg.Monitor.BandwidthInterval
+	config.CfgMu.RUnlock()
+	if d <= 0 {
+		d = def
+	}
+	if d < minBandwidthInterval {
+		d = minBandwidthInterval
+	}
+	return d
+}
+
 var (
 	lastCPUSample      time.Time
 	cpuUsageCache      float64
@@ -282,7 +410,8 @@ func updateSystemStats() {
 	var cpuUsage float64
 	if now.Sub(lastCPUSample) > 5*time.Second {
 		// 使用更短的采样时间(300ms)降低开销
-		cpuPercent, _ := cpu.Percent(300*time.Millisecond, false)
+		cpuPercent, err := cpu.Percent(300*time.Millisecond, false)
+		recordCollector("cpu", err)
 		if len(cpuPercent) > 0 {
 			rawUsage := cpuPercent[0]
 			// 简化计算逻辑
@@ -306,7 +435,8 @@ func updateSystemStats() {
 	GlobalTrafficStats.CPUCount = cpuCountCache // 更新全局CPU核心数
 
 	// 内存
-	vmem, _ := mem.VirtualMemory()
+	vmem, err := mem.VirtualMemory()
+	recordCollector("mem", err)
 	memUsage, memTotal := uint64(0), uint64(0)
 	if vmem != nil {
 		memUsage = vmem.Used
@@ -323,10 +453,13 @@ func updateSystemStats() {
 	)
 
 	if now.Sub(lastDiskScan) > 30*time.Second {
-		parts, _ := disk.Partitions(true)
+		parts, err := disk.Partitions(true)
 		tempPartitions := make([]DiskPartitionInfo, 0)
 		var tempUsage, tempTotal uint64
 		var tempUsedPercent float64
+		// 部分分区读取失败也记录，避免页面静默缺失分区
+		var usageErr error
+		usageFailed := 0
 
 		for _, part := range parts {
 			if runtime.GOOS != "windows" {
@@ -340,6 +473,8 @@ func updateSystemStats() {
 			}
 			stat, err := disk.Usage(part.Mountpoint)
 			if err != nil {
+				usageErr = err
+				usageFailed++
 				continue
 			}
 			skip := false
@@ -367,6 +502,10 @@ func updateSystemStats() {
 				MountPoint:  stat.Path,
 			})
 		}
+		if err == nil && usageErr != nil {
+			err = fmt.Errorf("%d 个分区读取失败: %v", usageFailed, usageErr)
+		}
+		recordCollector("disk", err)
 		diskPartitions = tempPartitions
 		diskUsage = tempUsage
 		diskTotal = tempTotal
@@ -375,7 +514,8 @@ func updateSystemStats() {
 	}
 
 	// 系统负载
-	loadAvg, _ := load.Avg()
+	loadAvg, err := load.Avg()
+	recordCollector("load", err)
 	loadAverage := LoadAverageInfo{}
 	if loadAvg != nil {
 		loadAverage.Load1 = loadAvg.Load1
@@ -384,7 +524,8 @@ func updateSystemStats() {
 	}
 
 	// 主机信息
-	hostInfo, _ := host.Info()
+	hostInfo, err := host.Info()
+	recordCollector("host", err)
 	hostDetails := HostInfo{}
 	if hostInfo != nil {
 		hostDetails.OS = hostInfo.OS
@@ -401,24 +542,34 @@ func updateSystemStats() {
 	)
 
 	if now.Sub(lastNetSample) > 1*time.Second {
-		counters, _ := net.IOCounters(true)
+		counters, err := net.IOCounters(true)
+		recordCollector("net", err)
+		ifaceAddrs := interfaceAddrs()
+		exclude := excludedIfacePatterns()
 		tempInterfaces := make([]NetworkInterfaceInfo, 0, len(counters))
 		var tempIn, tempOut uint64
 
 		for _, c := range counters {
 			tempIn += c.BytesRecv
 			tempOut += c.BytesSent
+			// 排除的网卡仍计入总流量，只是不单独列出
+			if ifaceExcluded(c.Name, exclude) {
+				continue
+			}
 
 			info := NetworkInterfaceInfo{
 				Name:        c.Name,
+				Addrs:       ifaceAddrs[c.Name],
 				BytesRecv:   c.BytesRecv,
 				BytesSent:   c.BytesSent,
 				PacketsRecv: c.PacketsRecv,
 				PacketsSent: c.PacketsSent,
+				DropsRecv:   c.Dropin,
+				ErrorsRecv:  c.Errin,
 			}
 			if prev, ok := GlobalTrafficStats.PrevNetCounters[c.Name]; ok {
 				timeDiff := now.Sub(GlobalTrafficStats.LastUpdate).Seconds()
-				if timeDiff > 0 {
+				if timeDiff >= minBandwidthInterval.Seconds() {
 					info.RecvBandwidth = uint64(float64(c.BytesRecv-prev.BytesRecv) / timeDiff)
 					info.SendBandwidth = uint64(float64(c.BytesSent-prev.BytesSent) / timeDiff)
 				}
@@ -433,10 +584,11 @@ func updateSystemStats() {
 
 		// 带宽计算
 		GlobalTrafficStats.mu.Lock()
-		oldTotalIn := GlobalTrafficStats.InboundBytes
-		oldTotalOut := GlobalTrafficStats.OutboundBytes
+		oldTotalIn := GlobalTrafficStats.rawInbound
+		oldTotalOut := GlobalTrafficStats.rawOutbound
 		timeDiff := now.Sub(GlobalTrafficStats.LastUpdate).Seconds()
-		if timeDiff > 0 {
+		// 首次采样没有基线（原始计数为 0），跳过以免把累计值当作增量
+		if timeDiff >= minBandwidthInterval.Seconds() && oldTotalIn > 0 {
 			GlobalTrafficStats.InboundBandwidth = uint64(float64(totalIn-oldTotalIn) / timeDiff)
 			GlobalTrafficStats.OutboundBandwidth = uint64(float64(totalOut-oldTotalOut) / timeDiff)
 		}
@@ -462,10 +614,21 @@ func updateSystemStats() {
 	GlobalTrafficStats.DiskPartitions = diskPartitions
 	GlobalTrafficStats.LoadAverage = loadAverage
 	GlobalTrafficStats.HostInfo = hostDetails
+	for i := range networkInterfaces {
+		iface := &networkInterfaces[i]
+		iface.BytesRecv = sinceBase(iface.BytesRecv, GlobalTrafficStats.baseIfaceRecv[iface.Name])
+		iface.BytesSent = sinceBase(iface.BytesSent, GlobalTrafficStats.baseIfaceSent[iface.Name])
+	}
 	GlobalTrafficStats.NetworkInterfaces = networkInterfaces
-	GlobalTrafficStats.InboundBytes = totalIn
-	GlobalTrafficStats.OutboundBytes = totalOut
-	GlobalTrafficStats.TotalBytes = totalIn + totalOut
+	GlobalTrafficStats.rawInbound = totalIn
+	GlobalTrafficStats.rawOutbound = totalOut
+	if p := GlobalTrafficStats.pendingRestore; p != nil && totalIn > 0 {
+		GlobalTrafficStats.applyRestore(p)
+		GlobalTrafficStats.pendingRestore = nil
+	}
+	GlobalTrafficStats.InboundBytes = sinceBase(totalIn, GlobalTrafficStats.baseInbound) + GlobalTrafficStats.carryInbound
+	GlobalTrafficStats.OutboundBytes = sinceBase(totalOut, GlobalTrafficStats.baseOutbound) + GlobalTrafficStats.carryOutbound
+	GlobalTrafficStats.TotalBytes = GlobalTrafficStats.InboundBytes + GlobalTrafficStats.OutboundBytes
 	GlobalTrafficStats.LastUpdate = now
 
 	// 更新应用自身统计
@@ -477,6 +640,7 @@ func updateSystemStats() {
 func updateAppStats(ts *TrafficStats) {
 	p := getAppProcess()
 	if p == nil {
+		recordCollector("process", errors.New("无法获取当前进程信息"))
 		return
 	}
 
@@ -485,6 +649,7 @@ func updateAppStats(ts *TrafficStats) {
 	// ---------------- CPU 使用率 ----------------
 	cpuUsage := ts.App.CPUPercent
 	cpuTimes, err := p.Times()
+	recordCollector("process", err)
 	if err == nil {
 		if !ts.App.LastUpdate.IsZero() && ts.App.PrevCPUTime > 0 {
 			duration := now.Sub(ts.App.LastUpdate).Seconds()
@@ -508,6 +673,21 @@ func updateAppStats(ts *TrafficStats) {
 		memUsage = memStats.RSS
 	}
 
+	// ---------------- 文件描述符 ----------------
+	openFDs := int32(0)
+	if n, err := p.NumFDs(); err == nil {
+		openFDs = n
+	}
+	maxFDs := uint64(0)
+	if limits, err := p.Rlimit(); err == nil {
+		for _, l := range limits {
+			if l.Resource == process.RLIMIT_NOFILE {
+				maxFDs = l.Soft
+				break
+			}
+		}
+	}
+
 	// ---------------- IO 流量 ----------------
 	// inBytes, outBytes := uint64(0), uint64(0)
 	// if ioCounters, err := p.IOCounters(); err == nil {
@@ -534,6 +714,8 @@ func updateAppStats(ts *TrafficStats) {
 	// fmt.Printf("DEBUG: App Stats - CPU: %.2f%%, Memory: %d bytes\n", cpuUsage, memUsage)
 	ts.App.CPUPercent = cpuUsage
 	ts.App.MemoryUsage = memUsage
+	ts.App.OpenFDs = openFDs
+	ts.App.MaxFDs = maxFDs
 	// ts.App.InboundBytes = inBytes
 	// ts.App.OutboundBytes = outBytes
 	// ts.App.InboundBandwidth = inBW
@@ -0,0 +1,56 @@
+package monitor
+
+import "strings"
+
+// 播放器分类
+const (
+	UACategoryVLC     = "VLC"
+	UACategoryFFmpeg  = "FFmpeg"
+	UACategoryMPV     = "mpv"
+	UACategoryKodi    = "Kodi"
+	UACategoryExo     = "ExoPlayer"
+	UACategoryIJK     = "IJKPlayer"
+	UACategorySTB     = "STB"
+	UACategoryBrowser = "Browser"
+	UACategoryOther   = "Other"
+)
+
+// uaRules 按顺序匹配，先匹配具体播放器，再匹配机顶盒，最后才是浏览器（很多播放器 UA 也带 Mozilla）
+var uaRules = []struct {
+	category string
+	keywords []string
+}{
+	{UACategoryVLC, []string{"vlc", "libvlc"}},
+	{UACategoryFFmpeg, []string{"lavf", "ffmpeg", "ffprobe", "ffplay"}},
+	{UACategoryMPV, []string{"mpv"}},
+	{UACategoryKodi, []string{"kodi", "xbmc"}},
+	{UACategoryExo, []string{"exoplayer", "exolib"}},
+	{UACategoryIJK, []string{"ijkplayer", "ijkmedia"}},
+	{UACategorySTB, []string{"stb", "set-top", "settop", "infomir", "mag250", "mag322", "iptv", "hisilicon", "hitv", "dalvik", "okhttp", "tvbox"}},
+	{UACategoryBrowser, []string{"mozilla", "chrome", "safari", "firefox", "edge", "opera"}},
+}
+
+// ClassifyUserAgent 根据 User-Agent 将客户端归类为播放器类型
+func ClassifyUserAgent(ua string) string {
+	ua = strings.ToLower(ua)
+	if ua == "" {
+		return UACategoryOther
+	}
+	for _, rule := range uaRules {
+		for _, kw := range rule.keywords {
+			if strings.Contains(ua, kw) {
+				return rule.category
+			}
+		}
+	}
+	return UACategoryOther
+}
+
+// countPlayerCategories 统计各播放器类型的客户端数量
+func countPlayerCategories(clients []*ClientConnection) map[string]int {
+	counts := make(map[string]int)
+	for _, c := range clients {
+		counts[c.PlayerCategory]++
+	}
+	return counts
+}
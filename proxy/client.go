@@ -11,6 +11,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -20,7 +21,7 @@ func CreateProxyClient(ctx context.Context, cfg *config.Config, proxyConfig conf
 	NormalizeProxyConfig(&proxyConfig)
 
 	proxyType := strings.ToLower(proxyConfig.Type)
-	proxyAddr := fmt.Sprintf("%s:%d", proxyConfig.Server, proxyConfig.Port)
+	proxyAddr := net.JoinHostPort(proxyConfig.Server, strconv.Itoa(proxyConfig.Port))
 
 	transport := &http.Transport{
 		TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
@@ -0,0 +1,140 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+	"github.com/qist/tvgate/monitor"
+)
+
+// dnsEntry 单个代理主机名的解析缓存
+type dnsEntry struct {
+	ips        []net.IP // 全部 A/AAAA 记录，IPv4 在前
+	next       int      // 轮询下标，在多条记录间分摊连接
+	lastIP     string
+	resolvedAt time.Time
+	expires    time.Time
+	err        error
+	errAt      time.Time
+}
+
+var (
+	dnsCacheMu sync.Mutex
+	dnsCache   = make(map[string]*dnsEntry)
+)
+
+func init() {
+	monitor.RegisterProxyDNSFunc(ProxyDNSStatuses)
+}
+
+// proxyDNSSettings 读取代理 DNS 缓存配置
+func proxyDNSSettings() (enabled bool, ttl, errTTL time.Duration) {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	c := config.Cfg.ProxyDNS
+	ttl, errTTL = c.TTL, c.ErrorTTL
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	if errTTL <= 0 {
+		errTTL = 10 * time.Second
+	}
+	return c.Enabled, ttl, errTTL
+}
+
+// resolveProxyHost 将代理主机名解析为 IP（带 TTL 缓存），多条记录时轮询返回；
+// 解析失败时在缓存过期前继续使用上次成功的结果，完全失败则返回原主机名交由拨号时解析
+func resolveProxyHost(host string) string {
+	enabled, ttl, errTTL := proxyDNSSettings()
+	if !enabled || host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+
+	dnsCacheMu.Lock()
+	e, ok := dnsCache[host]
+	if !ok {
+		e = &dnsEntry{}
+		dnsCache[host] = e
+	}
+	now := time.Now()
+	needLookup := now.After(e.expires) && (e.err == nil || now.Sub(e.errAt) >= errTTL)
+	dnsCacheMu.Unlock()
+
+	if needLookup {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ips, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
+		cancel()
+
+		dnsCacheMu.Lock()
+		if err != nil || len(ips) == 0 {
+			if err == nil {
+				err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+			}
+			e.err, e.errAt = err, now
+			logger.LogPrintf("⚠️ 解析代理地址 %s 失败: %v", host, err)
+		} else {
+			sort.SliceStable(ips, func(i, j int) bool { return ips[i].To4() != nil && ips[j].To4() == nil })
+			e.ips, e.next = ips, 0
+			e.resolvedAt, e.expires = now, now.Add(ttl)
+			e.err = nil
+		}
+		dnsCacheMu.Unlock()
+	}
+
+	dnsCacheMu.Lock()
+	defer dnsCacheMu.Unlock()
+	if len(e.ips) == 0 {
+		return host
+	}
+	ip := e.ips[e.next%len(e.ips)]
+	e.next++
+	e.lastIP = ip.String()
+	return e.lastIP
+}
+
+// RefreshDNSCache 清空代理 DNS 缓存，host 非空时仅清除该主机名，返回清除的条目数
+func RefreshDNSCache(host string) int {
+	dnsCacheMu.Lock()
+	defer dnsCacheMu.Unlock()
+	if host != "" {
+		if _, ok := dnsCache[host]; ok {
+			delete(dnsCache, host)
+			return 1
+		}
+		return 0
+	}
+	n := len(dnsCache)
+	dnsCache = make(map[string]*dnsEntry)
+	return n
+}
+
+// ProxyDNSStatuses 返回代理 DNS 缓存状态供监控展示
+func ProxyDNSStatuses() []monitor.ProxyDNSStatus {
+	dnsCacheMu.Lock()
+	defer dnsCacheMu.Unlock()
+
+	list := make([]monitor.ProxyDNSStatus, 0, len(dnsCache))
+	for host, e := range dnsCache {
+		st := monitor.ProxyDNSStatus{
+			Host:       host,
+			LastIP:     e.lastIP,
+			ResolvedAt: e.resolvedAt,
+			Expires:    e.expires,
+		}
+		for _, ip := range e.ips {
+			st.IPs = append(st.IPs, ip.String())
+		}
+		if e.err != nil {
+			st.Error = e.err.Error()
+			st.ErrorAt = e.errAt
+		}
+		list = append(list, st)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Host < list[j].Host })
+	return list
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,7 +17,7 @@ import (
 func CreateProxyDialer(proxyConfig config.ProxyConfig) (*cnf.DialContextWrapper, error) {
 	NormalizeProxyConfig(&proxyConfig)
 
-	proxyAddr := fmt.Sprintf("%s:%d", proxyConfig.Server, proxyConfig.Port)
+	proxyAddr := net.JoinHostPort(proxyConfig.Server, strconv.Itoa(proxyConfig.Port))
 	proxyType := strings.ToLower(proxyConfig.Type)
 
 	switch proxyType {
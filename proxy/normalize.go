@@ -1,12 +1,18 @@
 package proxy
 
 import (
+	"strings"
+
 	"github.com/qist/tvgate/config"
 )
 
-// 初始化 Headers，避免 nil
+// 初始化 Headers，避免 nil；启用 proxy_dns 时将主机名替换为缓存的解析结果
+// https 代理需要主机名完成 TLS SNI，保持原样
 func NormalizeProxyConfig(pc *config.ProxyConfig) {
 	if pc.Headers == nil {
 		pc.Headers = make(map[string]string)
 	}
+	if !strings.EqualFold(pc.Type, "https") {
+		pc.Server = resolveProxyHost(pc.Server)
+	}
 }
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// validDSCP 校验 DSCP 取值（0-63），0 表示不标记
+func validDSCP() int {
+	config.CfgMu.RLock()
+	dscp := config.Cfg.Server.DSCP
+	config.CfgMu.RUnlock()
+	if dscp < 0 || dscp > 63 {
+		logger.LogPrintf("⚠️ 无效的 DSCP 值 %d（有效范围 0-63），不设置 QoS 标记", dscp)
+		return 0
+	}
+	if dscp > 0 {
+		logger.LogPrintf("🏷️ 输出连接启用 DSCP 标记 %d (TOS=0x%02x)", dscp, dscp<<2)
+	}
+	return dscp
+}
+
+// dscpConnContext 返回在新连接上设置 DSCP/TOS 的 ConnContext 钩子，dscp 为 0 时返回 nil
+func dscpConnContext(dscp int) func(ctx context.Context, c net.Conn) context.Context {
+	if dscp == 0 {
+		return nil
+	}
+	tos := dscp << 2
+	return func(ctx context.Context, c net.Conn) context.Context {
+		if tc, ok := c.(*tls.Conn); ok {
+			c = tc.NetConn()
+		}
+		tcpConn, ok := c.(*net.TCPConn)
+		if !ok {
+			return ctx
+		}
+		var err error
+		if addr, ok := tcpConn.LocalAddr().(*net.TCPAddr); ok && addr.IP.To4() == nil {
+			err = ipv6.NewConn(tcpConn).SetTrafficClass(tos)
+		} else {
+			err = ipv4.NewConn(tcpConn).SetTOS(tos)
+		}
+		if err != nil {
+			logger.LogPrintf("⚠️ 设置 DSCP 标记失败 %s: %v", c.RemoteAddr(), err)
+		}
+		return ctx
+	}
+}
@@ -46,6 +46,15 @@ func StartHTTPServer(ctx context.Context, handler http.Handler) error {
 		ReadHeaderTimeout: 10 * time.Second,
 		MaxHeaderBytes:    1 << 20,
 		TLSConfig:         tlsConfig,
+		ConnContext:       dscpConnContext(validDSCP()),
+		HTTP2:             &http.HTTP2Config{MaxConcurrentStreams: int(config.Cfg.Server.H2MaxStreams)},
+	}
+
+	// 明文 HTTP/2：客户端需以 prior knowledge 方式直接发送 h2 前言
+	if tlsConfig == nil && config.Cfg.Server.H2C {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
 	}
 
 	// HTTP/3 server
@@ -110,7 +119,7 @@ func StartHTTPServer(ctx context.Context, handler http.Handler) error {
 				logger.LogPrintf("❌ 创建 H1 Listener 失败: %v", err)
 				return
 			}
-			_ = http2.ConfigureServer(srv, &http2.Server{})
+			_ = http2.ConfigureServer(srv, &http2.Server{MaxConcurrentStreams: config.Cfg.Server.H2MaxStreams})
 			logger.LogPrintf("🚀 启动 HTTPS H1/H2 %s", addr)
 			if err := srv.ServeTLS(ln, certFile, keyFile); err != nil && err != http.ErrServerClosed {
 				logger.LogPrintf("❌ HTTP/1.x/2 错误: %v", err)
@@ -121,7 +130,11 @@ func StartHTTPServer(ctx context.Context, handler http.Handler) error {
 				logger.LogPrintf("❌ 创建 H1 Listener 失败: %v", err)
 				return
 			}
-			logger.LogPrintf("🚀 启动 HTTP/1.1 %s", addr)
+			if srv.Protocols != nil {
+				logger.LogPrintf("🚀 启动 HTTP/1.1 + h2c %s", addr)
+			} else {
+				logger.LogPrintf("🚀 启动 HTTP/1.1 %s", addr)
+			}
 			if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
 				logger.LogPrintf("❌ HTTP/1.x 错误: %v", err)
 			}
@@ -0,0 +1,302 @@
+package stream
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+	"github.com/qist/tvgate/monitor"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	defaultDedupWindow = 1024
+	maxDedupWindow     = 65536
+)
+
+// allInterfacesMode 读取多网卡同时接收配置，返回是否启用及去重窗口大小（包数）
+func allInterfacesMode() (bool, int) {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	n := config.Cfg.Stream.DedupWindow
+	if n <= 0 {
+		n = defaultDedupWindow
+	}
+	if n > maxDedupWindow {
+		n = maxDedupWindow
+	}
+	return config.Cfg.Stream.AllInterfaces, n
+}
+
+// eligibleInterfaces 返回可加入组播的网卡：names 非空时按其顺序，否则为全部已启用、支持组播且有对应地址族地址的非回环网卡
+func eligibleInterfaces(names []string, v4 bool) []*net.Interface {
+	var list []*net.Interface
+	if len(names) > 0 {
+		for _, name := range names {
+			iface, err := net.InterfaceByName(name)
+			if err != nil {
+				logger.LogPrintf("⚠️ 网卡 %s 不存在或不可用: %v", name, err)
+				continue
+			}
+			list = append(list, iface)
+		}
+		return list
+	}
+
+	ifs, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	for i := range ifs {
+		iface := &ifs[i]
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok && (ipNet.IP.To4() != nil) == v4 {
+				list = append(list, iface)
+				break
+			}
+		}
+	}
+	return list
+}
+
+// listenAllInterfaces 在所有候选网卡上加入同一组播组（单个套接字多次加入），返回成功加入的网卡名
+func listenAllInterfaces(udpAddr string, names []string) (*net.UDPConn, []string, error) {
+	addr, err := net.ResolveUDPAddr("udp", udpAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !addr.IP.IsMulticast() {
+		return nil, nil, fmt.Errorf("%s 不是组播地址", udpAddr)
+	}
+	v4 := addr.IP.To4() != nil
+	candidates := eligibleInterfaces(names, v4)
+	if len(candidates) == 0 {
+		return nil, nil, fmt.Errorf("没有可加入组播的网卡")
+	}
+
+	var (
+		conn   *net.UDPConn
+		joined []string
+	)
+	for _, iface := range candidates {
+		if conn == nil {
+			// 第一个网卡由 ListenMulticastUDP 加入（同时设置地址复用）
+			c, err := net.ListenMulticastUDP("udp", iface, addr)
+			if err != nil {
+				logger.LogPrintf("⚠️ 监听 %s@%s 失败: %v", udpAddr, iface.Name, err)
+				continue
+			}
+			conn = c
+		} else {
+			var jerr error
+			if v4 {
+				jerr = ipv4.NewPacketConn(conn).JoinGroup(iface, &net.UDPAddr{IP: addr.IP})
+			} else {
+				jerr = ipv6.NewPacketConn(conn).JoinGroup(iface, &net.UDPAddr{IP: addr.IP})
+			}
+			if jerr != nil {
+				logger.LogPrintf("⚠️ 加入 %s@%s 失败: %v", udpAddr, iface.Name, jerr)
+				continue
+			}
+		}
+		joined = append(joined, iface.Name)
+	}
+	if conn == nil {
+		return nil, nil, fmt.Errorf("所有网卡加入组播 %s 失败", udpAddr)
+	}
+	logger.LogPrintf("🟢 多网卡监听 %s 成功: %v", udpAddr, joined)
+	return conn, joined, nil
+}
+
+// leaveAllInterfaces 在所有已加入的网卡上退出组播组
+func leaveAllInterfaces(conn *net.UDPConn, udpAddr string, ifaces []string) {
+	addr, err := net.ResolveUDPAddr("udp", udpAddr)
+	if err != nil {
+		return
+	}
+	for _, name := range ifaces {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			continue
+		}
+		if err := leaveGroup(conn, addr, iface); err != nil {
+			logger.LogPrintf("⚠️ 退出组播组 %s@%s 失败: %v", udpAddr, name, err)
+		}
+	}
+}
+
+// ifIndexReader 返回读取数据并带回入口网卡索引的函数；平台不支持时索引为 0
+func ifIndexReader(conn *net.UDPConn, v4 bool) func([]byte) (int, int, error) {
+	if v4 {
+		p := ipv4.NewPacketConn(conn)
+		_ = p.SetControlMessage(ipv4.FlagInterface, true)
+		return func(b []byte) (int, int, error) {
+			n, cm, _, err := p.ReadFrom(b)
+			if cm != nil {
+				return n, cm.IfIndex, err
+			}
+			return n, 0, err
+		}
+	}
+	p := ipv6.NewPacketConn(conn)
+	_ = p.SetControlMessage(ipv6.FlagInterface, true)
+	return func(b []byte) (int, int, error) {
+		n, cm, _, err := p.ReadFrom(b)
+		if cm != nil {
+			return n, cm.IfIndex, err
+		}
+		return n, 0, err
+	}
+}
+
+// allIfacesReadLoop 多网卡模式的读循环：按入口网卡计数，去重后送入广播
+func (h *StreamHub) allIfacesReadLoop() {
+	defer h.pinReadThread()()
+	deadline := readDeadline()
+	errorBackoff := getStreamTimeouts().errorBackoff
+	v4 := true
+	if addr, err := net.ResolveUDPAddr("udp", h.addr); err == nil {
+		v4 = addr.IP.To4() != nil
+	}
+
+	var (
+		cur   *net.UDPConn
+		read  func([]byte) (int, int, error)
+		names = make(map[int]string)
+	)
+	for {
+		buf := h.BufPool.Get().([]byte)
+		conn := h.UdpConn
+		if conn == nil {
+			h.BufPool.Put(buf)
+			return
+		}
+		if conn != cur {
+			cur, read = conn, ifIndexReader(conn, v4)
+		}
+		if deadline > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(deadline))
+		}
+		n, idx, err := read(buf)
+		if err != nil {
+			h.BufPool.Put(buf)
+			if h.handleReadError(err, errorBackoff) {
+				return
+			}
+			continue
+		}
+
+		name, ok := names[idx]
+		if !ok {
+			name = "unknown"
+			if iface, err := net.InterfaceByIndex(idx); err == nil {
+				name = iface.Name
+			}
+			names[idx] = name
+		}
+
+		h.sendMu.Lock()
+		dup := h.dedup != nil && h.dedup.seen(packetKey(buf[:n], h.dedup.seed))
+		h.countIfaceRx(name, n, dup)
+		if !dup {
+			h.ingestLocked(buf[:n])
+		}
+		h.sendMu.Unlock()
+		h.BufPool.Put(buf[:cap(buf)])
+	}
+}
+
+// ifaceRxCounter 多网卡模式下单个网卡的接收计数
+type ifaceRxCounter struct {
+	packets    uint64
+	bytes      uint64
+	duplicates uint64
+}
+
+// countIfaceRx 累加网卡接收计数；调用方需持有 h.sendMu
+func (h *StreamHub) countIfaceRx(name string, n int, dup bool) {
+	if h.ifaceRx == nil {
+		h.ifaceRx = make(map[string]*ifaceRxCounter)
+	}
+	c := h.ifaceRx[name]
+	if c == nil {
+		c = &ifaceRxCounter{}
+		h.ifaceRx[name] = c
+	}
+	c.packets++
+	c.bytes += uint64(n)
+	if dup {
+		c.duplicates++
+	}
+}
+
+// ifaceRxStats 返回按网卡名排序的接收计数；调用方需持有 h.sendMu
+func (h *StreamHub) ifaceRxStats() []monitor.IfaceRxStat {
+	if len(h.ifaceRx) == 0 {
+		return nil
+	}
+	list := make([]monitor.IfaceRxStat, 0, len(h.ifaceRx))
+	for name, c := range h.ifaceRx {
+		list = append(list, monitor.IfaceRxStat{Name: name, Packets: c.packets, Bytes: c.bytes, Duplicates: c.duplicates})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// dedupWindow 最近 N 个包标识的滑动窗口，用于丢弃从多个网卡重复收到的包
+type dedupWindow struct {
+	seed maphash.Seed
+	ring []uint64
+	pos  int
+	set  map[uint64]int // 标识 → 在窗口中的出现次数
+}
+
+func newDedupWindow(size int) *dedupWindow {
+	return &dedupWindow{
+		seed: maphash.MakeSeed(),
+		ring: make([]uint64, 0, size),
+		set:  make(map[uint64]int, size),
+	}
+}
+
+// seen 判断标识是否已在窗口内，未出现过则加入窗口（超出容量时淘汰最旧的标识）
+func (d *dedupWindow) seen(key uint64) bool {
+	if d.set[key] > 0 {
+		return true
+	}
+	if len(d.ring) < cap(d.ring) {
+		d.ring = append(d.ring, key)
+	} else {
+		old := d.ring[d.pos]
+		if d.set[old]--; d.set[old] <= 0 {
+			delete(d.set, old)
+		}
+		d.ring[d.pos] = key
+		d.pos = (d.pos + 1) % len(d.ring)
+	}
+	d.set[key]++
+	return false
+}
+
+// packetKey 计算包的去重标识：RTP 包使用 SSRC+序列号，其余（裸 TS）使用内容哈希
+func packetKey(p []byte, seed maphash.Seed) uint64 {
+	if len(p) >= 12 && p[0]>>6 == 2 {
+		seq := uint64(binary.BigEndian.Uint16(p[2:4]))
+		ssrc := uint64(binary.BigEndian.Uint32(p[8:12]))
+		return ssrc<<16 | seq
+	}
+	return maphash.Bytes(seed, p)
+}
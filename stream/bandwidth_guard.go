@@ -0,0 +1,92 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+	"github.com/qist/tvgate/monitor"
+)
+
+// 总出口带宽超限时的处理策略
+const (
+	BandwidthPolicyReject = "reject" // 拒绝新连接，已有连接不受影响（默认）
+	BandwidthPolicyShed   = "shed"   // 拒绝新连接，并逐个关闭观众最少的频道直至回落
+)
+
+// bandwidthCap 读取出口带宽上限 (bytes/s，0 表示不限制) 及策略
+func bandwidthCap() (uint64, string) {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	limit := uint64(config.Cfg.Stream.MaxOutboundMbps * 1000 * 1000 / 8)
+	policy := config.Cfg.Stream.BandwidthPolicy
+	if policy != BandwidthPolicyShed {
+		policy = BandwidthPolicyReject
+	}
+	return limit, policy
+}
+
+// OverBandwidthCap 当前总出口带宽是否已达上限，用于拒绝新的流连接
+func OverBandwidthCap() bool {
+	limit, _ := bandwidthCap()
+	if limit == 0 {
+		return false
+	}
+	bw, _ := monitor.GlobalTrafficStats.OutboundRate()
+	return bw >= limit
+}
+
+// StartBandwidthGuard 启动出口带宽守护：shed 策略下超限时关闭观众最少的频道，
+// 每次带宽采样最多关闭一个，等待新的采样反映效果后再决定是否继续
+func StartBandwidthGuard() {
+	go func() {
+		var lastSample time.Time
+		for {
+			time.Sleep(time.Second)
+
+			limit, policy := bandwidthCap()
+			if limit == 0 || policy != BandwidthPolicyShed {
+				continue
+			}
+			bw, sampledAt := monitor.GlobalTrafficStats.OutboundRate()
+			if !sampledAt.After(lastSample) {
+				continue
+			}
+			lastSample = sampledAt
+			if bw < limit {
+				continue
+			}
+			if key, viewers := shedLowestViewerHub(); key != "" {
+				logger.LogPrintf("✂️ 出口带宽 %s 超过上限 %s，关闭观众最少的频道 %s（%d 人）",
+					monitor.FormatNetworkBandwidth(bw), monitor.FormatNetworkBandwidth(limit), key, viewers)
+			}
+		}
+	}()
+}
+
+// shedLowestViewerHub 关闭客户端数最少的运行中 Hub，返回其标识与客户端数
+func shedLowestViewerHub() (string, int) {
+	var (
+		victim    *StreamHub
+		victimKey string
+		minCount  = -1
+	)
+	for key, h := range snapshotHubs() {
+		select {
+		case <-h.Closed:
+			continue
+		default:
+		}
+		h.Mu.Lock()
+		n := len(h.Clients)
+		h.Mu.Unlock()
+		if n > 0 && (minCount < 0 || n < minCount) {
+			victim, victimKey, minCount = h, key, n
+		}
+	}
+	if victim == nil {
+		return "", 0
+	}
+	victim.Close()
+	return victimKey, minCount
+}
@@ -0,0 +1,35 @@
+package stream
+
+import "time"
+
+// rateWindowSeconds 码率估算的滑动窗口长度（秒）
+const rateWindowSeconds = 5
+
+// rateEstimator 按秒分桶的滑动窗口码率估算器，调用方负责加锁
+type rateEstimator struct {
+	buckets [rateWindowSeconds]uint64
+	seconds [rateWindowSeconds]int64 // 每个桶对应的 Unix 秒
+}
+
+// add 记录在 now 时刻收到的 n 字节
+func (e *rateEstimator) add(n int, now time.Time) {
+	sec := now.Unix()
+	idx := sec % rateWindowSeconds
+	if e.seconds[idx] != sec {
+		e.seconds[idx] = sec
+		e.buckets[idx] = 0
+	}
+	e.buckets[idx] += uint64(n)
+}
+
+// rate 返回窗口内已完成的整秒的平均码率 (bytes/s)，不含当前未满的一秒
+func (e *rateEstimator) rate(now time.Time) uint64 {
+	cur := now.Unix()
+	var total uint64
+	for i := 0; i < rateWindowSeconds; i++ {
+		if s := e.seconds[i]; s < cur && cur-s < rateWindowSeconds {
+			total += e.buckets[i]
+		}
+	}
+	return total / (rateWindowSeconds - 1)
+}
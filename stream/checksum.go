@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"sync"
+)
+
+// ChecksumWriter 包装客户端 ResponseWriter，对最近 N 次写入的数据维护滚动 CRC32，
+// 同一频道上同步的客户端应得到相同的校验值，用于调试验证分发一致性
+type ChecksumWriter struct {
+	http.ResponseWriter
+
+	mu     sync.Mutex
+	crcs   []uint32 // 最近 N 帧的 CRC32 环形缓冲
+	next   int
+	filled bool
+	frames uint64
+	sum    uint32
+}
+
+// NewChecksumWriter 创建校验写入器，frames 为滚动窗口的帧数
+func NewChecksumWriter(w http.ResponseWriter, frames int) *ChecksumWriter {
+	if frames <= 0 {
+		frames = 32
+	}
+	return &ChecksumWriter{ResponseWriter: w, crcs: make([]uint32, frames)}
+}
+
+func (c *ChecksumWriter) Write(p []byte) (int, error) {
+	n, err := c.ResponseWriter.Write(p)
+	if n > 0 {
+		c.add(crc32.ChecksumIEEE(p[:n]))
+	}
+	return n, err
+}
+
+// Flush 透传 http.Flusher，保证逐帧推送
+func (c *ChecksumWriter) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (c *ChecksumWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// add 记录一帧的 CRC，并按窗口内帧顺序重新计算滚动校验值
+func (c *ChecksumWriter) add(frameCRC uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.crcs[c.next] = frameCRC
+	c.next = (c.next + 1) % len(c.crcs)
+	if c.next == 0 {
+		c.filled = true
+	}
+	c.frames++
+
+	start, count := 0, c.next
+	if c.filled {
+		start, count = c.next, len(c.crcs)
+	}
+	var buf [4]byte
+	sum := uint32(0)
+	for i := 0; i < count; i++ {
+		binary.BigEndian.PutUint32(buf[:], c.crcs[(start+i)%len(c.crcs)])
+		sum = crc32.Update(sum, crc32.IEEETable, buf[:])
+	}
+	c.sum = sum
+}
+
+// Sum 返回当前滚动校验值及累计写入帧数，格式如 "1a2b3c4d@1024"
+func (c *ChecksumWriter) Sum() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return fmt.Sprintf("%08x@%d", c.sum, c.frames)
+}
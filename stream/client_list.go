@@ -0,0 +1,73 @@
+package stream
+
+// 客户端列表快照（写时复制）
+// h.Clients 仍是加入/离开的权威集合；广播使用由它生成的切片快照，快照只在加入/离开时标记失效、
+// 下次广播时重建一次，稳态下每包广播既不遍历 map 也不分配内存。
+// 快照生成后不再修改，持有旧快照的遍历（如广播中途断开客户端）不受重建影响。
+// 客户端集合的修改同时持有 h.Mu 与 h.sendMu；快照与广播只需 h.sendMu，因此收包路径不争用 h.Mu。
+
+// clientSnapshotLocked 返回当前客户端的只读快照（不含已断开待退订的客户端），调用方不得修改返回的切片；
+// 调用方需持有 h.sendMu
+func (h *StreamHub) clientSnapshotLocked() []chan []byte {
+	if h.clientListStale {
+		list := make([]chan []byte, 0, len(h.Clients))
+		for ch := range h.Clients {
+			if _, gone := h.evicted[ch]; !gone {
+				list = append(list, ch)
+			}
+		}
+		h.clientList, h.clientListStale = list, false
+	}
+	return h.clientList
+}
+
+// addClientLocked 登记客户端并使快照失效；调用方需同时持有 h.Mu 与 h.sendMu
+func (h *StreamHub) addClientLocked(ch chan []byte) {
+	h.Clients[ch] = struct{}{}
+	h.clientListStale = true
+}
+
+// removeClientLocked 移除客户端并使快照失效，通道尚未因断开被关闭时关闭它；
+// 调用方需同时持有 h.Mu 与 h.sendMu
+func (h *StreamHub) removeClientLocked(ch chan []byte) {
+	delete(h.Clients, ch)
+	if _, gone := h.evicted[ch]; gone {
+		delete(h.evicted, ch)
+	} else {
+		close(ch)
+	}
+	h.clientListStale = true
+}
+
+// evictLocked 断开跟不上的客户端：关闭通道通知订阅方退出并停止向其广播，
+// 由订阅方退订时从 h.Clients 移除；只需持有 h.sendMu，供广播路径调用
+func (h *StreamHub) evictLocked(ch chan []byte) {
+	if _, gone := h.evicted[ch]; gone {
+		return
+	}
+	if h.evicted == nil {
+		h.evicted = make(map[chan []byte]struct{})
+	}
+	h.evicted[ch] = struct{}{}
+	close(ch)
+	h.clientListStale = true
+}
+
+// closeClientsLocked 关闭全部客户端通道并整体替换客户端集合（清空或置 nil）；
+// 调用方需同时持有 h.Mu 与 h.sendMu
+func (h *StreamHub) closeClientsLocked(clients map[chan []byte]struct{}) {
+	for ch := range h.Clients {
+		if _, gone := h.evicted[ch]; !gone {
+			close(ch)
+		}
+	}
+	h.resetClientsLocked(clients)
+}
+
+// resetClientsLocked 整体替换客户端集合（清空或置 nil）并使快照失效，不关闭通道；
+// 调用方需同时持有 h.Mu 与 h.sendMu
+func (h *StreamHub) resetClientsLocked(clients map[chan []byte]struct{}) {
+	h.Clients = clients
+	h.evicted = nil
+	h.clientListStale = true
+}
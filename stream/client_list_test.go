@@ -0,0 +1,68 @@
+package stream
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// subscribeN 向 Hub 登记 n 个客户端，返回它们的通道
+func subscribeN(tb testing.TB, hub *StreamHub, n int) []chan []byte {
+	tb.Helper()
+	chs := make([]chan []byte, n)
+	for i := range chs {
+		chs[i] = make(chan []byte, 16)
+		if err := hub.subscribe(chs[i], time.Second); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	waitClients(hub, n)
+	return chs
+}
+
+// drainAll 非阻塞清空客户端通道，使下一次广播走正常投递而不是通道已满的分支
+func drainAll(chs []chan []byte) {
+	for _, ch := range chs {
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// 稳态广播复用客户端快照，每包不分配内存
+func TestBroadcastDoesNotAllocate(t *testing.T) {
+	hub := newTestHub(t)
+	chs := subscribeN(t, hub, 32)
+	data := seqFrame(1)
+
+	hub.sendMu.Lock()
+	defer hub.sendMu.Unlock()
+	allocs := testing.AllocsPerRun(100, func() {
+		hub.broadcast(data)
+		drainAll(chs)
+	})
+	if allocs != 0 {
+		t.Errorf("broadcast allocated %.1f times per packet, want 0", allocs)
+	}
+}
+
+// BenchmarkBroadcast 广播热路径（快照 + 逐客户端投递）的耗时与分配，go test -bench Broadcast ./stream
+func BenchmarkBroadcast(b *testing.B) {
+	for _, n := range []int{1, 32, 256} {
+		b.Run(fmt.Sprintf("clients=%d", n), func(b *testing.B) {
+			hub := newTestHub(b)
+			chs := subscribeN(b, hub, n)
+			data := seqFrame(1)
+
+			hub.sendMu.Lock()
+			defer hub.sendMu.Unlock()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				hub.broadcast(data)
+				drainAll(chs)
+			}
+		})
+	}
+}
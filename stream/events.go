@@ -0,0 +1,102 @@
+package stream
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HubEventType Hub 生命周期事件类型
+type HubEventType string
+
+const (
+	HubCreated   HubEventType = "hub_created"
+	HubClosed    HubEventType = "hub_closed"
+	ClientJoined HubEventType = "client_joined"
+	ClientLeft   HubEventType = "client_left"
+)
+
+// HubEvent Hub 生命周期事件
+type HubEvent struct {
+	Type    HubEventType
+	HubKey  string
+	Addr    string
+	Clients int // 事件发生后的客户端数
+	Time    time.Time
+}
+
+// eventBufferSize 每个订阅者的事件缓冲，满时丢弃新事件
+const eventBufferSize = 256
+
+type eventSubscriber struct {
+	ch      chan HubEvent
+	dropped atomic.Uint64
+}
+
+var (
+	eventSubsMu sync.RWMutex
+	eventSubs   = make(map[*eventSubscriber]struct{})
+)
+
+// Subscribe 订阅 Hub 生命周期事件（创建/关闭、客户端加入/离开），fn 在独立 goroutine 中按顺序调用。
+// 发布永不阻塞数据路径：订阅者处理过慢导致缓冲已满时新事件被丢弃。返回取消订阅函数
+func Subscribe(fn func(HubEvent)) (unsubscribe func()) {
+	sub := &eventSubscriber{ch: make(chan HubEvent, eventBufferSize)}
+	eventSubsMu.Lock()
+	eventSubs[sub] = struct{}{}
+	eventSubsMu.Unlock()
+
+	go func() {
+		for ev := range sub.ch {
+			fn(ev)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			eventSubsMu.Lock()
+			delete(eventSubs, sub)
+			close(sub.ch)
+			eventSubsMu.Unlock()
+		})
+	}
+}
+
+// publishEvent 非阻塞地向所有订阅者投递事件，可在持有 h.Mu 时调用
+func publishEvent(ev HubEvent) {
+	eventSubsMu.RLock()
+	defer eventSubsMu.RUnlock()
+	if len(eventSubs) == 0 {
+		return
+	}
+	ev.Time = time.Now()
+	for sub := range eventSubs {
+		select {
+		case sub.ch <- ev:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// DroppedEvents 返回所有订阅者因缓冲已满而丢弃的事件总数
+func DroppedEvents() uint64 {
+	eventSubsMu.RLock()
+	defer eventSubsMu.RUnlock()
+	var n uint64
+	for sub := range eventSubs {
+		n += sub.dropped.Load()
+	}
+	return n
+}
+
+// publishLocked 发布本 Hub 的事件，调用方需持有 h.Mu
+func (h *StreamHub) publishLocked(typ HubEventType, clients int) {
+	publishEvent(HubEvent{
+		Type:    typ,
+		HubKey:  HubKey(h.addr, h.Ifaces, h.LocalAddr),
+		Addr:    h.addr,
+		Clients: clients,
+	})
+}
@@ -0,0 +1,68 @@
+package stream
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// endOfStreamTrailer Hub 关闭时设置的 HTTP Trailer，值为结束原因
+const endOfStreamTrailer = "X-TVGate-End-Reason"
+
+// endOfStreamNullPackets Hub 关闭时发送的 TS 空包数量（一个 7 包 UDP 载荷的大小）
+const endOfStreamNullPackets = 7
+
+// endOfStreamAction Hub 关闭时对客户端的收尾动作
+type endOfStreamAction struct {
+	null    bool // 发送一组 TS 空包后再结束响应
+	trailer bool // 设置 X-TVGate-End-Reason Trailer
+}
+
+// getEndOfStreamAction 按 Content-Type 读取 stream.end_of_stream 配置，
+// 取值 none / null / trailer / null+trailer，未配置的类型回退到 "*"
+func getEndOfStreamAction(contentType string) endOfStreamAction {
+	ct := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+
+	config.CfgMu.RLock()
+	v, ok := config.Cfg.Stream.EndOfStream[ct]
+	if !ok {
+		v = config.Cfg.Stream.EndOfStream["*"]
+	}
+	config.CfgMu.RUnlock()
+
+	var a endOfStreamAction
+	for _, part := range strings.Split(strings.ToLower(v), "+") {
+		switch strings.TrimSpace(part) {
+		case "null":
+			a.null = true
+		case "trailer":
+			a.trailer = true
+		}
+	}
+	return a
+}
+
+// announce 在写入响应头前声明 Trailer（HTTP/1.1 分块传输与 HTTP/2 均支持）
+func (a endOfStreamAction) announce(w http.ResponseWriter) {
+	if a.trailer {
+		w.Header().Set("Trailer", endOfStreamTrailer)
+	}
+}
+
+// finish Hub 关闭时执行收尾：设置结束原因并在写超时内发送空包，让播放器识别为正常结束；
+// 在处理协程中同步写入，超时由写截止时间控制
+func (a endOfStreamAction) finish(w http.ResponseWriter, rc *http.ResponseController, writeTimeout time.Duration) {
+	if a.trailer {
+		w.Header().Set(endOfStreamTrailer, "hub-closed")
+	}
+	if !a.null {
+		return
+	}
+	_ = rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := w.Write(bytes.Repeat(tsNullPacket, endOfStreamNullPackets)); err == nil {
+		_ = rc.Flush()
+	}
+}
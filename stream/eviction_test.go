@@ -0,0 +1,97 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// newTestHub 创建一个单播监听本机随机端口的 Hub，测试结束时关闭；需要特定 stream 配置时先调用 withStreamConfig
+func newTestHub(tb testing.TB) *StreamHub {
+	tb.Helper()
+	hub, err := NewStreamHub(config.UnicastScheme+"127.0.0.1:0", nil, "")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(hub.Close)
+	return hub
+}
+
+// withStreamConfig 临时修改 stream 配置，测试结束时（在其后创建的 Hub 关闭之后）恢复
+func withStreamConfig(tb testing.TB, set func(*config.StreamConfig)) {
+	tb.Helper()
+	config.CfgMu.Lock()
+	saved := config.Cfg.Stream
+	set(&config.Cfg.Stream)
+	config.CfgMu.Unlock()
+	tb.Cleanup(func() {
+		config.CfgMu.Lock()
+		config.Cfg.Stream = saved
+		config.CfgMu.Unlock()
+	})
+}
+
+// waitClients 等待 Hub 的客户端数达到 n
+func waitClients(hub *StreamHub, n int) {
+	for i := 0; i < 200; i++ {
+		hub.Mu.Lock()
+		count := len(hub.Clients)
+		hub.Mu.Unlock()
+		if count >= n {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Hub 关闭时收尾空包在处理函数返回前同步写出，之后不再有协程访问 ResponseWriter（配合 -race 检查）
+func TestServeHTTPEndOfStreamAfterHubClose(t *testing.T) {
+	withStreamConfig(t, func(c *config.StreamConfig) { c.EndOfStream = map[string]string{"*": "null+trailer"} })
+	hub := newTestHub(t)
+	returned := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(returned)
+		hub.ServeHTTP(w, r, "video/mp2t", nil, nil)
+	}))
+	defer srv.Close()
+
+	// 响应头随首帧发出：等客户端订阅后再广播，之后 http.Get 才会返回
+	frame := bytes.Repeat([]byte{0x47, 0x01, 0x00, 0x10}, 47)
+	go func() {
+		waitClients(hub, 1)
+		hub.Mu.Lock()
+		hub.broadcast(frame)
+		hub.Mu.Unlock()
+	}()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	got := make([]byte, len(frame))
+	if _, err := io.ReadFull(resp.Body, got); err != nil || !bytes.Equal(got, frame) {
+		t.Fatalf("first frame = %x, err = %v", got, err)
+	}
+
+	hub.Close()
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := bytes.Repeat(tsNullPacket, endOfStreamNullPackets); !bytes.Equal(rest, want) {
+		t.Errorf("tail = %d bytes, want %d null packet bytes", len(rest), len(want))
+	}
+	if reason := resp.Trailer.Get(endOfStreamTrailer); reason != "hub-closed" {
+		t.Errorf("trailer = %q, want hub-closed", reason)
+	}
+	select {
+	case <-returned:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler did not return after hub close")
+	}
+}
@@ -0,0 +1,81 @@
+package stream
+
+import "sync"
+
+// minFanoutClients 客户端数低于该值时串行广播，避免协程切换开销超过收益
+const minFanoutClients = 64
+
+// fanoutPool 每个 Hub 固定数量的广播工作协程
+// 每帧将客户端快照切分为连续分片并行投递，全部完成后才返回（逐帧屏障），
+// 因此同一客户端收到的帧顺序不变，内存仅为一份客户端快照；
+// block-with-deadline 等阻塞投递在分片间并行等待，readLoop 的停顿由各客户端耗时之和降为最慢分片的耗时。
+// 工作协程在 Hub 关闭时由 stopLocked 关闭任务通道后退出，而不是监听 h.Closed：
+// Close 先关闭 h.Closed 再获取 sendMu，若工作协程提前退出，持有 sendMu 的广播会在投递或等待分片时永久阻塞
+type fanoutPool struct {
+	workers    int
+	jobs       chan fanoutJob
+	stopped    bool          // 任务通道已关闭，受 h.sendMu 保护
+	clients    []chan []byte // 本帧使用的客户端快照（h.clientSnapshotLocked，只读），受 h.sendMu 保护
+	disconnect []bool        // 与快照下标对应的断开标记
+	wg         sync.WaitGroup
+}
+
+type fanoutJob struct {
+	data   []byte
+	lo, hi int
+}
+
+func newFanoutPool(h *StreamHub, workers int) *fanoutPool {
+	p := &fanoutPool{
+		workers: workers,
+		jobs:    make(chan fanoutJob, workers),
+	}
+	for i := 0; i < workers; i++ {
+		go p.worker(h)
+	}
+	return p
+}
+
+func (p *fanoutPool) worker(h *StreamHub) {
+	for job := range p.jobs {
+		for i := job.lo; i < job.hi; i++ {
+			p.disconnect[i] = h.deliver(p.clients[i], job.data)
+		}
+		p.wg.Done()
+	}
+}
+
+// stopLocked 关闭任务通道让工作协程退出；调用方需持有 h.sendMu，与 broadcast 互斥，因此没有进行中的分片
+func (p *fanoutPool) stopLocked() {
+	if !p.stopped {
+		p.stopped = true
+		close(p.jobs)
+	}
+}
+
+// broadcast 分片并行投递一帧；调用方需持有 h.sendMu 且池未停止，返回前处理需断开的客户端
+func (p *fanoutPool) broadcast(h *StreamHub, clients []chan []byte, data []byte) {
+	p.clients = clients
+	n := len(p.clients)
+	if cap(p.disconnect) < n {
+		p.disconnect = make([]bool, n)
+	}
+	p.disconnect = p.disconnect[:n]
+
+	chunk := (n + p.workers - 1) / p.workers
+	for lo := 0; lo < n; lo += chunk {
+		hi := lo + chunk
+		if hi > n {
+			hi = n
+		}
+		p.wg.Add(1)
+		p.jobs <- fanoutJob{data: data, lo: lo, hi: hi}
+	}
+	p.wg.Wait()
+
+	for i, ch := range p.clients {
+		if p.disconnect[i] {
+			h.evictLocked(ch)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package stream
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// Close 先关闭 h.Closed 再等待 sendMu：此时仍持有 sendMu 的并行广播必须能完成，而不是等待已退出的工作协程
+func TestFanoutBroadcastDuringClose(t *testing.T) {
+	withStreamConfig(t, func(c *config.StreamConfig) { c.BroadcastWorkers = 4 })
+	hub := newTestHub(t)
+	if hub.fanout == nil {
+		t.Fatal("fanout pool not created")
+	}
+	chs := subscribeN(t, hub, minFanoutClients)
+
+	hub.sendMu.Lock()
+	closed := make(chan struct{})
+	go func() {
+		hub.Close()
+		close(closed)
+	}()
+	<-hub.Closed
+	time.Sleep(20 * time.Millisecond) // 给工作协程观察到 h.Closed 的时间
+
+	done := make(chan struct{})
+	go func() {
+		hub.broadcast(seqFrame(1))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		hub.sendMu.Unlock()
+		t.Fatal("broadcast blocked after the hub started closing")
+	}
+	hub.sendMu.Unlock()
+	<-closed
+
+	for i, ch := range chs {
+		if p, ok := <-ch; !ok || frameSeq(p) != 1 {
+			t.Fatalf("client %d: frame = %v, ok = %v", i, p, ok)
+		}
+	}
+	hub.sendMu.Lock()
+	stopped := hub.fanout.stopped
+	hub.sendMu.Unlock()
+	if !stopped {
+		t.Error("fanout pool not stopped by Close")
+	}
+}
+
+// BenchmarkFanout 比较串行广播与 broadcast_workers 并行广播，go test -bench Fanout ./stream
+// stalled 个客户端从不消费且使用 block-with-deadline 策略：串行时每帧等待时间累加，并行时按分片重叠
+func BenchmarkFanout(b *testing.B) {
+	for _, stalled := range []int{0, 8} {
+		for _, workers := range []int{0, 4, 16} {
+			b.Run(fmt.Sprintf("clients=1024/stalled=%d/workers=%d", stalled, workers), func(b *testing.B) {
+				withStreamConfig(b, func(c *config.StreamConfig) { c.BroadcastWorkers = workers })
+				hub := newTestHub(b)
+				hub.fullPolicy, hub.blockTimeout = FullPolicyBlock, 50*time.Microsecond
+				chs := subscribeN(b, hub, 1024)
+				// 均匀分散到各分片，停滞客户端的通道先填满
+				step := len(chs) / (stalled + 1)
+				live := make([]chan []byte, 0, len(chs))
+				for i, ch := range chs {
+					if stalled > 0 && i > 0 && i%step == 0 && i/step <= stalled {
+						for len(ch) < cap(ch) {
+							ch <- nil
+						}
+						continue
+					}
+					live = append(live, ch)
+				}
+				data := seqFrame(1)
+
+				hub.sendMu.Lock()
+				defer hub.sendMu.Unlock()
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					hub.broadcast(data)
+					drainAll(live)
+				}
+			})
+		}
+	}
+}
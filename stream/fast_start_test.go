@@ -0,0 +1,118 @@
+package stream
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/qist/tvgate/monitor"
+)
+
+// seqFrame 生成携带序号的测试帧（一个 TS 包大小）
+func seqFrame(seq uint32) []byte {
+	p := make([]byte, 188)
+	p[0] = 0x47
+	binary.BigEndian.PutUint32(p[4:], seq)
+	return p
+}
+
+func frameSeq(p []byte) uint32 {
+	return binary.BigEndian.Uint32(p[4:])
+}
+
+// ingest 以收包路径的方式送入一帧：只持有 sendMu，不持有 Mu
+func ingest(h *StreamHub, seq uint32) {
+	h.sendMu.Lock()
+	h.ingestLocked(seqFrame(seq))
+	h.sendMu.Unlock()
+}
+
+// 收包与广播只持有 sendMu：中途加入的客户端先收到完整的秒开缓存，随后是不重不漏、顺序连续的实时包
+func TestFastStartReplayOrderWithConcurrentIngest(t *testing.T) {
+	hub := newTestHub(t)
+
+	// 先有一个客户端，收包路径才会写入秒开缓存
+	first := make(chan []byte, 4096)
+	if err := hub.subscribe(first, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	waitClients(hub, 1)
+	const cached = 20
+	for seq := uint32(1); seq <= cached; seq++ {
+		ingest(hub, seq)
+	}
+
+	// 加入的同时持续收包
+	const total = 2000
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for seq := uint32(cached + 1); seq <= total; seq++ {
+			ingest(hub, seq)
+		}
+	}()
+	late := make(chan []byte, 4096)
+	if err := hub.subscribe(late, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	waitClients(hub, 2)
+	<-done
+
+	got := make([]uint32, 0, total)
+	for len(late) > 0 {
+		got = append(got, frameSeq(<-late))
+	}
+	// 首帧是加入时秒开缓存中最旧的一帧（缓存最多 50 帧），其后不应有缺口或乱序
+	if len(got) == 0 {
+		t.Fatal("late client received nothing")
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] != got[i-1]+1 {
+			t.Fatalf("late client frames not contiguous at %d: %d after %d", i, got[i], got[i-1])
+		}
+	}
+	if last := got[len(got)-1]; last != total {
+		t.Errorf("late client last frame = %d, want %d", last, total)
+	}
+	if src := hub.takeFirstFrameSource(late); src != monitor.FirstFrameFastStart {
+		t.Errorf("first frame source = %q, want %q", src, monitor.FirstFrameFastStart)
+	}
+	if lf := hub.LastFrame(); lf == nil || frameSeq(lf) != total {
+		t.Errorf("LastFrame seq = %v, want %d", lf, total)
+	}
+}
+
+// 收到首帧前断开的客户端，其秒开标记随退订一并清除
+func TestPrimedClearedOnEarlyDisconnect(t *testing.T) {
+	hub := newTestHub(t)
+	first := make(chan []byte, 16)
+	if err := hub.subscribe(first, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	waitClients(hub, 1)
+	ingest(hub, 1)
+
+	ch := make(chan []byte, 16)
+	if err := hub.subscribe(ch, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	waitClients(hub, 2)
+	hub.Mu.Lock()
+	_, primed := hub.primed[ch]
+	hub.Mu.Unlock()
+	if !primed {
+		t.Fatal("client not primed after fast-start replay")
+	}
+
+	hub.unsubscribe(ch, time.Second)
+	for i := 0; i < 200; i++ {
+		hub.Mu.Lock()
+		_, primed = hub.primed[ch]
+		hub.Mu.Unlock()
+		if !primed {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("primed entry leaked after disconnect before first frame")
+}
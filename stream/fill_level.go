@@ -0,0 +1,48 @@
+package stream
+
+import "time"
+
+const (
+	fillSampleInterval = time.Second      // 客户端通道填充度采样间隔
+	fillWindow         = 10 * time.Second // 统计窗口
+)
+
+// fillLevelStats 客户端通道填充度（len/cap）统计：每次采样取全部客户端的平均与最大值，
+// 按窗口汇总；调用方需持有 StreamHub.Mu
+type fillLevelStats struct {
+	windowStart time.Time
+	sum, max    float64
+	count       int
+
+	// 最近一个完整窗口的结果
+	valid            bool
+	lastAvg, lastMax float64
+}
+
+// sample 对当前全部客户端通道采样一次，无客户端时不计入
+func (s *fillLevelStats) sample(clients map[chan []byte]struct{}, now time.Time) {
+	if len(clients) == 0 {
+		return
+	}
+	if s.windowStart.IsZero() {
+		s.windowStart = now
+	}
+	for ch := range clients {
+		if c := cap(ch); c > 0 {
+			ratio := float64(len(ch)) / float64(c)
+			s.sum += ratio
+			if ratio > s.max {
+				s.max = ratio
+			}
+			s.count++
+		}
+	}
+
+	if now.Sub(s.windowStart) >= fillWindow && s.count > 0 {
+		s.valid = true
+		s.lastAvg = s.sum / float64(s.count)
+		s.lastMax = s.max
+		s.windowStart = now
+		s.sum, s.max, s.count = 0, 0, 0
+	}
+}
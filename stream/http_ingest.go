@@ -0,0 +1,137 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qist/tvgate/logger"
+)
+
+const (
+	httpIngestChunk        = 7 * 188 // 每次读取 7 个 TS 包，与常见 UDP 负载大小一致
+	httpIngestMinBackoff   = time.Second
+	httpIngestMaxBackoff   = 30 * time.Second
+	httpIngestStallTimeout = 30 * time.Second // read_deadline 为负数（不设置读超时）时的无数据超时
+)
+
+// httpIngestClient 拉流专用客户端：不设整体超时（长连接流），仅限制响应头等待时间
+var httpIngestClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: 10 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// IsHTTPSource 源地址是否为 HTTP(S) URL，是则由 Hub 主动拉流而不是监听 UDP
+func IsHTTPSource(addr string) bool {
+	return strings.HasPrefix(addr, "http://") || strings.HasPrefix(addr, "https://")
+}
+
+// httpReadLoop 持续从 HTTP 源拉流并送入广播，断开后指数退避重连；
+// 断开时已无客户端则关闭 Hub
+func (h *StreamHub) httpReadLoop() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-h.Closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	backoff := httpIngestMinBackoff
+	for {
+		received, err := h.httpIngestOnce(ctx)
+		select {
+		case <-h.Closed:
+			return
+		default:
+		}
+
+		h.Mu.Lock()
+		clientCount := len(h.Clients)
+		if err != nil {
+			h.readErrors++
+			h.lastErr = err.Error()
+			h.lastErrAt = time.Now()
+		}
+		h.Mu.Unlock()
+		if clientCount == 0 && h.closeIfIdle() {
+			logger.LogPrintf("没有客户端，停止拉流并关闭 Hub: %s", h.sourceURL)
+			return
+		}
+
+		if received {
+			backoff = httpIngestMinBackoff
+		}
+		logger.LogPrintf("⚠️ HTTP 源 %s 断开: %v，%v 后重连", h.sourceURL, err, backoff)
+		t := time.NewTimer(backoff)
+		select {
+		case <-h.Closed:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		backoff *= 2
+		if backoff > httpIngestMaxBackoff {
+			backoff = httpIngestMaxBackoff
+		}
+	}
+}
+
+// httpIngestOnce 建立一次拉流连接并读取到断开为止，received 表示本次是否收到过数据
+func (h *StreamHub) httpIngestOnce(parent context.Context) (received bool, err error) {
+	stall := readDeadline()
+	if stall <= 0 {
+		stall = httpIngestStallTimeout
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	// 源停止发送数据但不断开连接时，由看门狗取消请求
+	watchdog := time.AfterFunc(stall, cancel)
+	defer watchdog.Stop()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.sourceURL, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := httpIngestClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	logger.LogPrintf("🟢 HTTP 拉流 %s 已连接", h.sourceURL)
+
+	for {
+		buf := h.BufPool.Get().([]byte)
+		n, rerr := io.ReadFull(resp.Body, buf[:httpIngestChunk])
+		if n > 0 {
+			received = true
+			watchdog.Reset(stall)
+			h.sendMu.Lock()
+			h.ingestLocked(buf[:n])
+			h.sendMu.Unlock()
+		}
+		h.BufPool.Put(buf[:cap(buf)])
+		if rerr != nil {
+			if errors.Is(rerr, io.ErrUnexpectedEOF) {
+				rerr = io.EOF
+			}
+			if ctx.Err() != nil && parent.Err() == nil {
+				rerr = fmt.Errorf("%v 内未收到数据", stall)
+			}
+			return received, rerr
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package stream
+
+import (
+	"sort"
+	"time"
+)
+
+// HubDebugInfo 单个 Hub 的完整内部状态，供 /debug/hubs 排查问题使用
+type HubDebugInfo struct {
+	Key          string    `json:"key"`
+	Addr         string    `json:"addr"`
+	Source       string    `json:"source"` // udp / http / unicast / preview
+	SourceURL    string    `json:"source_url,omitempty"`
+	Ifaces       []string  `json:"ifaces"`
+	LocalAddr    string    `json:"local_addr,omitempty"`
+	IsMulticast  bool      `json:"is_multicast"`
+	AllIfaces    bool      `json:"all_interfaces"`
+	JoinedIfaces []string  `json:"joined_ifaces,omitempty"`
+	Closed       bool      `json:"closed"`
+	ConnOpen     bool      `json:"conn_open"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	ClientCount  int `json:"client_count"`
+	SilentCount  int `json:"silent_count"`
+	PendingAdd   int `json:"pending_add"`    // AddCh 中等待处理的客户端
+	PendingLeave int `json:"pending_remove"` // RemoveCh 中等待处理的客户端
+
+	Bitrate       uint64    `json:"bitrate"` // bytes/s
+	IngestBytes   uint64    `json:"ingest_bytes"`
+	IngestPackets uint64    `json:"ingest_packets"`
+	LastFrameSize int       `json:"last_frame_size"`
+	LastPacketAt  time.Time `json:"last_packet_at,omitempty"`
+	CacheFrames   int       `json:"cache_frames"`
+	ReadErrors    uint64    `json:"read_errors"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorAt   time.Time `json:"last_error_at,omitempty"`
+
+	FullPolicy   string         `json:"full_policy"`
+	BlockTimeout time.Duration  `json:"block_timeout"`
+	JitterFrames int            `json:"jitter_frames,omitempty"` // 抖动缓冲当前帧数，未启用时为 0
+	PSIReplay    bool           `json:"psi_replay"`
+	Fanout       bool           `json:"fanout"`
+	Settings     HubSettings    `json:"settings"`
+	SwitchEvents int            `json:"switch_events"`
+	Lock         HubLockStats   `json:"lock"`              // h.Mu 竞争统计（stream.lock_stats）
+	Sockets      []SocketRxStat `json:"sockets,omitempty"` // 多套接字接收时各套接字的包数（stream.read_sockets）
+}
+
+// DebugHubs 在 HubsMu 与各 Hub 的锁（Mu 与 sendMu）下采集所有 Hub 的内部状态，按 Key 排序
+func DebugHubs() []HubDebugInfo {
+	hubs := snapshotHubs()
+
+	now := time.Now()
+	list := make([]HubDebugInfo, 0, len(hubs))
+	for key, h := range hubs {
+		settings := h.settings()
+		lock := h.Mu.stats()
+
+		h.Mu.Lock()
+		h.sendMu.Lock()
+		info := HubDebugInfo{
+			Key:          key,
+			Addr:         h.addr,
+			Source:       "udp",
+			SourceURL:    h.sourceURL,
+			Ifaces:       append([]string(nil), h.Ifaces...),
+			LocalAddr:    h.LocalAddr,
+			IsMulticast:  h.IsMulticast,
+			AllIfaces:    h.allIfaces,
+			JoinedIfaces: append([]string(nil), h.joinedIfaces...),
+			ConnOpen:     h.UdpConn != nil,
+			CreatedAt:    h.createdAt,
+
+			ClientCount:  len(h.Clients),
+			SilentCount:  len(h.silent),
+			PendingAdd:   len(h.AddCh),
+			PendingLeave: len(h.RemoveCh),
+
+			Bitrate:       h.ingestRate.rate(now),
+			IngestBytes:   h.ingestBytes,
+			IngestPackets: h.ingestPackets,
+			LastFrameSize: len(h.LastFrame()),
+			LastPacketAt:  h.lastPacketAt,
+			CacheFrames:   len(h.CacheBuffer),
+			ReadErrors:    h.readErrors,
+			LastError:     h.lastErr,
+			LastErrorAt:   h.lastErrAt,
+
+			FullPolicy:   h.fullPolicy,
+			BlockTimeout: h.blockTimeout,
+			PSIReplay:    h.psi != nil,
+			Fanout:       h.fanout != nil,
+			Settings:     settings,
+			SwitchEvents: len(h.switchEvents),
+			Lock:         lock,
+			Sockets:      h.socketRxStats(),
+		}
+		if h.sourceURL != "" {
+			info.Source = "http"
+		} else if h.preview != "" {
+			info.Source = "preview"
+		} else if h.unicast {
+			info.Source = "unicast"
+		}
+		if h.jitter != nil {
+			info.JitterFrames = len(h.jitter.frames)
+		}
+		select {
+		case <-h.Closed:
+			info.Closed = true
+		default:
+		}
+		h.sendMu.Unlock()
+		h.Mu.Unlock()
+
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
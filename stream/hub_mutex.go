@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// lockStatsEnabled 读取是否统计 Hub 锁竞争，在新 Hub 创建时生效
+func lockStatsEnabled() bool {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	return config.Cfg.Stream.LockStats
+}
+
+// hubMutex StreamHub.Mu 的实现：未启用统计时等同于 sync.Mutex；启用后先 TryLock，
+// 失败（发生竞争）时才计时等待，无竞争路径只多一次原子加
+type hubMutex struct {
+	mu         sync.Mutex
+	instrument bool // 创建 Hub 时确定，之后只读
+
+	acquisitions atomic.Uint64
+	contended    atomic.Uint64
+	waitTotal    atomic.Int64 // 竞争时的累计等待 (ns)
+	waitMax      atomic.Int64
+}
+
+func (m *hubMutex) Lock() {
+	if !m.instrument {
+		m.mu.Lock()
+		return
+	}
+	m.acquisitions.Add(1)
+	if m.mu.TryLock() {
+		return
+	}
+	start := time.Now()
+	m.mu.Lock()
+	wait := int64(time.Since(start))
+	m.contended.Add(1)
+	m.waitTotal.Add(wait)
+	for {
+		cur := m.waitMax.Load()
+		if wait <= cur || m.waitMax.CompareAndSwap(cur, wait) {
+			break
+		}
+	}
+}
+
+func (m *hubMutex) Unlock() {
+	m.mu.Unlock()
+}
+
+// HubLockStats Hub 锁竞争统计
+type HubLockStats struct {
+	Enabled      bool          `json:"enabled"`
+	Acquisitions uint64        `json:"acquisitions"`
+	Contended    uint64        `json:"contended"`     // 需要等待的加锁次数
+	ContendedPct float64       `json:"contended_pct"` // 竞争比例 (%)
+	WaitTotal    time.Duration `json:"wait_total"`
+	WaitAvg      time.Duration `json:"wait_avg"` // 竞争时的平均等待
+	WaitMax      time.Duration `json:"wait_max"`
+}
+
+// stats 返回当前统计，可在不持有锁时调用
+func (m *hubMutex) stats() HubLockStats {
+	s := HubLockStats{Enabled: m.instrument}
+	if !m.instrument {
+		return s
+	}
+	s.Acquisitions = m.acquisitions.Load()
+	s.Contended = m.contended.Load()
+	s.WaitTotal = time.Duration(m.waitTotal.Load())
+	s.WaitMax = time.Duration(m.waitMax.Load())
+	if s.Acquisitions > 0 {
+		s.ContendedPct = float64(s.Contended) * 100 / float64(s.Acquisitions)
+	}
+	if s.Contended > 0 {
+		s.WaitAvg = s.WaitTotal / time.Duration(s.Contended)
+	}
+	return s
+}
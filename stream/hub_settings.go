@@ -0,0 +1,222 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+)
+
+// defaultClientBuffer 客户端通道默认缓冲帧数
+const defaultClientBuffer = 200
+
+// HubSettings 频道（Hub）级运行参数的生效值，修改只影响之后加入的客户端
+type HubSettings struct {
+	ClientBuffer int           `json:"client_buffer"` // 客户端通道缓冲帧数
+	IdleTimeout  time.Duration `json:"idle_timeout"`  // 客户端持续收不到数据的超时
+	FastStart    bool          `json:"fast_start"`    // 新客户端加入时先发送缓存的数据包（秒开）
+}
+
+// HubSettingsOverride 运行时覆盖项，nil 字段表示沿用全局配置
+type HubSettingsOverride struct {
+	ClientBuffer *int           `json:"client_buffer,omitempty"`
+	IdleTimeout  *time.Duration `json:"idle_timeout,omitempty"`
+	FastStart    *bool          `json:"fast_start,omitempty"`
+}
+
+// empty 覆盖项是否全部为空
+func (o HubSettingsOverride) empty() bool {
+	return o.ClientBuffer == nil && o.IdleTimeout == nil && o.FastStart == nil
+}
+
+var (
+	hubOverrides     = make(map[string]HubSettingsOverride) // HubKey → 覆盖项，Hub 重建后仍然生效
+	hubOverridesMu   sync.Mutex
+	hubOverridesOnce sync.Once
+)
+
+// hubSettingsFile 读取覆盖项持久化文件路径，为空表示不持久化
+func hubSettingsFile() string {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	return config.Cfg.Stream.HubSettingsFile
+}
+
+// loadHubOverrides 首次使用时从持久化文件加载覆盖项；调用方持有 hubOverridesMu
+func loadHubOverrides() {
+	hubOverridesOnce.Do(func() {
+		path := hubSettingsFile()
+		if path == "" {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logger.LogPrintf("⚠️ 读取频道参数文件 %s 失败: %v", path, err)
+			}
+			return
+		}
+		var saved map[string]HubSettingsOverride
+		if err := json.Unmarshal(data, &saved); err != nil {
+			logger.LogPrintf("⚠️ 解析频道参数文件 %s 失败: %v", path, err)
+			return
+		}
+		for k, o := range saved {
+			hubOverrides[k] = o
+		}
+		logger.LogPrintf("📂 已加载 %d 个频道的运行参数: %s", len(saved), path)
+	})
+}
+
+// defaultHubSettings 由全局配置得到的默认参数
+func defaultHubSettings() HubSettings {
+	return HubSettings{
+		ClientBuffer: defaultClientBuffer,
+		IdleTimeout:  getStreamTimeouts().idle,
+		FastStart:    true,
+	}
+}
+
+// effectiveHubSettings 返回指定 Hub 的生效参数（全局配置 + 运行时覆盖）
+func effectiveHubSettings(key string) HubSettings {
+	s := defaultHubSettings()
+	hubOverridesMu.Lock()
+	loadHubOverrides()
+	o, ok := hubOverrides[key]
+	hubOverridesMu.Unlock()
+	if !ok {
+		return s
+	}
+	if o.ClientBuffer != nil {
+		s.ClientBuffer = *o.ClientBuffer
+	}
+	if o.IdleTimeout != nil {
+		s.IdleTimeout = *o.IdleTimeout
+	}
+	if o.FastStart != nil {
+		s.FastStart = *o.FastStart
+	}
+	return s
+}
+
+// settings 返回当前 Hub 的生效参数
+func (h *StreamHub) settings() HubSettings {
+	return effectiveHubSettings(HubKey(h.addr, h.Ifaces, h.LocalAddr))
+}
+
+// HubSettingsEntry 管理接口返回的单个 Hub 参数
+type HubSettingsEntry struct {
+	Key       string              `json:"key"`
+	Running   bool                `json:"running"` // Hub 当前是否在运行
+	Effective HubSettings         `json:"effective"`
+	Override  HubSettingsOverride `json:"override"`
+}
+
+// ListHubSettings 列出运行中的 Hub 及存在覆盖项的 Hub 的参数
+func ListHubSettings() []HubSettingsEntry {
+	keys := make(map[string]bool)
+	for key := range snapshotHubs() {
+		keys[key] = true
+	}
+	hubOverridesMu.Lock()
+	loadHubOverrides()
+	overrides := make(map[string]HubSettingsOverride, len(hubOverrides))
+	for k, o := range hubOverrides {
+		overrides[k] = o
+		if _, ok := keys[k]; !ok {
+			keys[k] = false
+		}
+	}
+	hubOverridesMu.Unlock()
+
+	list := make([]HubSettingsEntry, 0, len(keys))
+	for k, running := range keys {
+		list = append(list, HubSettingsEntry{
+			Key:       k,
+			Running:   running,
+			Effective: effectiveHubSettings(k),
+			Override:  overrides[k],
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
+
+// ResolveHubKey 将源地址别名解析为运行中 Hub 的 HubKey，未找到时原样返回
+func ResolveHubKey(keyOrAddr string) string {
+	if key, _, ok := findHub(keyOrAddr); ok {
+		return key
+	}
+	return keyOrAddr
+}
+
+// SetHubSettings 合并更新指定 Hub 的覆盖项（reset 为 true 时先清空），返回生效参数；
+// persist 为 true 时写入 stream.hub_settings_file，否则仅在本次运行期间有效
+func SetHubSettings(key string, o HubSettingsOverride, reset, persist bool) (HubSettings, error) {
+	if o.ClientBuffer != nil && (*o.ClientBuffer < 1 || *o.ClientBuffer > 10000) {
+		return HubSettings{}, errors.New("client_buffer 取值范围 1-10000")
+	}
+	if o.IdleTimeout != nil && *o.IdleTimeout < time.Second {
+		return HubSettings{}, errors.New("idle_timeout 不能小于 1s")
+	}
+	path := hubSettingsFile()
+	if persist && path == "" {
+		return HubSettings{}, errors.New("未配置 stream.hub_settings_file，无法持久化")
+	}
+
+	hubOverridesMu.Lock()
+	loadHubOverrides()
+	cur := hubOverrides[key]
+	if reset {
+		cur = HubSettingsOverride{}
+	}
+	if o.ClientBuffer != nil {
+		cur.ClientBuffer = o.ClientBuffer
+	}
+	if o.IdleTimeout != nil {
+		cur.IdleTimeout = o.IdleTimeout
+	}
+	if o.FastStart != nil {
+		cur.FastStart = o.FastStart
+	}
+	if cur.empty() {
+		delete(hubOverrides, key)
+	} else {
+		hubOverrides[key] = cur
+	}
+	var err error
+	if persist {
+		err = saveHubOverrides(path)
+	}
+	hubOverridesMu.Unlock()
+	if err != nil {
+		return HubSettings{}, err
+	}
+
+	logger.LogPrintf("🎛 频道参数已更新: %s（持久化=%v）", key, persist)
+	return effectiveHubSettings(key), nil
+}
+
+// saveHubOverrides 原子写入全部覆盖项；调用方持有 hubOverridesMu
+func saveHubOverrides(path string) error {
+	data, err := json.MarshalIndent(hubOverrides, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
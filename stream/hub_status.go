@@ -0,0 +1,99 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/qist/tvgate/monitor"
+)
+
+func init() {
+	monitor.RegisterHubStatusFunc(HubStatuses)
+	monitor.RegisterTotalViewersFunc(TotalViewers)
+}
+
+// snapshotHubs 在 HubsMu 下复制当前 Hub 列表，避免持有全局锁时再去获取 Hub 自身的锁
+func snapshotHubs() map[string]*StreamHub {
+	HubsMu.Lock()
+	defer HubsMu.Unlock()
+
+	hubs := make(map[string]*StreamHub, len(Hubs))
+	for key, hub := range Hubs {
+		hubs[key] = hub
+	}
+	return hubs
+}
+
+// TotalViewers 统计所有 Hub 上正在观看的客户端总数
+func TotalViewers() int {
+	total := 0
+	for _, h := range snapshotHubs() {
+		h.Mu.Lock()
+		total += len(h.Clients)
+		h.Mu.Unlock()
+	}
+	return total
+}
+
+// HubStatuses 采集所有 UDP/组播 Hub 的状态供监控页面展示
+func HubStatuses() []monitor.HubStatus {
+	hubs := snapshotHubs()
+
+	now := time.Now()
+	list := make([]monitor.HubStatus, 0, len(hubs))
+	for key, h := range hubs {
+		h.Mu.Lock()
+		h.sendMu.Lock()
+		st := monitor.HubStatus{
+			Key:         key,
+			Addr:        h.addr,
+			Ifaces:      append([]string(nil), h.Ifaces...),
+			LocalAddr:   h.LocalAddr,
+			IsMulticast: h.IsMulticast,
+			IfacesLost:  h.ifacesLost,
+			Pinned:      h.pinned,
+			ActiveIface: h.activeIfaceLocked(),
+			Source:      "udp",
+			ClientCount: len(h.Clients),
+			SilentCount: len(h.silent),
+			Bitrate:     h.ingestRate.rate(now),
+			TotalBytes:  h.ingestBytes,
+
+			SwitchEvents: append([]monitor.SourceSwitchEvent(nil), h.switchEvents...),
+			IfaceRx:      h.ifaceRxStats(),
+		}
+		if h.sourceURL != "" {
+			st.Source = "http"
+		} else if h.preview != "" {
+			st.Source = "preview"
+		} else if h.unicast {
+			st.Source = "unicast"
+		}
+		if h.reorder != nil {
+			st.HasReorder = true
+			st.ReorderedPkts = h.reorder.reordered
+			st.ReorderLate = h.reorder.late
+			st.ReorderSkipped = h.reorder.lost
+		}
+		if h.slate != nil {
+			st.HasSlate = true
+			st.SlateActive = h.slate.active
+			st.SlateTime = h.slate.elapsed(now).Truncate(time.Second)
+			st.SlateCount = h.slate.count
+		}
+		if h.fill.valid {
+			st.HasFill = true
+			st.FillAvg = h.fill.lastAvg
+			st.FillMax = h.fill.lastMax
+		}
+		if h.rxJitter.valid {
+			st.HasJitter = true
+			st.JitterMin = h.rxJitter.lastMin.Round(time.Microsecond)
+			st.JitterAvg = h.rxJitter.lastAvg.Round(time.Microsecond)
+			st.JitterMax = h.rxJitter.lastMax.Round(time.Microsecond)
+		}
+		list = append(list, st)
+		h.sendMu.Unlock()
+		h.Mu.Unlock()
+	}
+	return list
+}
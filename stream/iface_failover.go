@@ -0,0 +1,179 @@
+package stream
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+)
+
+const (
+	defaultIfaceFailback = 30 * time.Second
+	ifaceProbeWindow     = 2 * time.Second
+)
+
+// ifaceFailover 组播网卡按优先级故障切换的状态，字段受 h.Mu 保护
+// ifaces 列表顺序即优先级：当前网卡超过 timeout 未收到数据时切到下一块，
+// 每隔 failback 探测更高优先级的网卡，恢复后切回
+type ifaceFailover struct {
+	timeout   time.Duration
+	failback  time.Duration
+	active    int       // 当前加入组播的网卡在 h.Ifaces 中的下标，-1 表示未按网卡加入
+	since     time.Time // 切换到当前网卡的时间，切换后从该时刻重新计算无数据时长
+	lastProbe time.Time
+}
+
+// ifaceFailoverConfig 读取网卡故障切换超时与回切探测间隔，超时为 0 表示关闭
+func ifaceFailoverConfig() (timeout, failback time.Duration) {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	timeout, failback = config.Cfg.Stream.IfaceFailover, config.Cfg.Stream.IfaceFailback
+	if failback <= 0 {
+		failback = defaultIfaceFailback
+	}
+	return timeout, failback
+}
+
+// activeIfaceLocked 返回当前接收组播的网卡名，未启用网卡故障切换时为空；调用方需持有 h.Mu
+func (h *StreamHub) activeIfaceLocked() string {
+	if h.failover == nil || h.failover.active < 0 || h.failover.active >= len(h.Ifaces) {
+		return ""
+	}
+	return h.Ifaces[h.failover.active]
+}
+
+// failoverLoop 周期检查当前网卡是否仍有数据，并探测更高优先级网卡是否恢复
+func (h *StreamHub) failoverLoop() {
+	h.Mu.Lock()
+	interval := h.failover.timeout / 4
+	h.Mu.Unlock()
+	if interval < 200*time.Millisecond {
+		interval = 200 * time.Millisecond
+	} else if interval > 5*time.Second {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.Closed:
+			return
+		case <-ticker.C:
+		}
+		h.checkIfaceFailover()
+		h.probeIfaceFailback()
+	}
+}
+
+// checkIfaceFailover 当前网卡超时无数据时切换到下一块网卡；已是最后一块时保持不动，等待回切探测
+func (h *StreamHub) checkIfaceFailover() {
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+	f := h.failover
+	if h.UdpConn == nil || f.active < 0 || f.active+1 >= len(h.Ifaces) {
+		return
+	}
+	h.sendMu.Lock()
+	last := h.lastPacketAt
+	h.sendMu.Unlock()
+	if last.Before(f.since) {
+		last = f.since
+	}
+	idle := time.Since(last)
+	if idle < f.timeout {
+		return
+	}
+	for next := f.active + 1; next < len(h.Ifaces); next++ {
+		reason := fmt.Sprintf("网卡 %s 无数据 %s，故障切换", h.Ifaces[f.active], idle.Truncate(time.Second))
+		if h.switchIfaceLocked(next, reason) {
+			return
+		}
+	}
+}
+
+// probeIfaceFailback 按间隔探测比当前更高优先级的网卡，收到该网卡入口的组播包即切回
+// 探测依赖按入口网卡区分数据包（IP_PKTINFO/IPV6_PKTINFO），不支持的平台不会回切
+func (h *StreamHub) probeIfaceFailback() {
+	h.Mu.Lock()
+	f := h.failover
+	if h.UdpConn == nil || f.active <= 0 || time.Since(f.lastProbe) < f.failback {
+		h.Mu.Unlock()
+		return
+	}
+	f.lastProbe = time.Now()
+	addr, active := h.addr, f.active
+	higher := append([]string(nil), h.Ifaces[:active]...)
+	h.Mu.Unlock()
+
+	for i, name := range higher {
+		if !probeIface(addr, name, ifaceProbeWindow) {
+			continue
+		}
+		h.Mu.Lock()
+		// 探测期间网卡列表或当前网卡可能已变化
+		if h.failover.active == active && h.addr == addr && i < len(h.Ifaces) && h.Ifaces[i] == name {
+			h.switchIfaceLocked(i, fmt.Sprintf("高优先级网卡 %s 恢复，回切", name))
+		}
+		h.Mu.Unlock()
+		return
+	}
+}
+
+// switchIfaceLocked 在 h.Ifaces[idx] 上重新加入组播并替换当前连接，readLoop 下次读取时跟随新连接；
+// 调用方需持有 h.Mu
+func (h *StreamHub) switchIfaceLocked(idx int, reason string) bool {
+	name := h.Ifaces[idx]
+	conn, multicast, _, err := listenUDP(h.addr, []string{name}, "")
+	if err != nil || !multicast {
+		if conn != nil {
+			_ = conn.Close()
+		}
+		logger.LogPrintf("⚠️ 网卡 %s 加入组播 %s 失败: %v", name, h.addr, err)
+		return false
+	}
+	from := h.Ifaces[h.failover.active]
+	h.leaveAndCloseConnLocked()
+	h.UdpConn = conn
+	h.failover.active, h.failover.since = idx, time.Now()
+	h.recordSwitch(h.addr+"@"+from, h.addr+"@"+name, reason)
+	return true
+}
+
+// probeIface 在指定网卡上临时加入组播，窗口期内收到从该网卡进入的数据包即认为可用
+func probeIface(udpAddr, name string, window time.Duration) bool {
+	addr, err := net.ResolveUDPAddr("udp", udpAddr)
+	if err != nil {
+		return false
+	}
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return false
+	}
+	conn, err := net.ListenMulticastUDP("udp", iface, addr)
+	if err != nil {
+		return false
+	}
+	defer func() {
+		leaveMulticastGroup(conn, udpAddr, []string{name}, "")
+		_ = conn.Close()
+	}()
+
+	read := ifIndexReader(conn, addr.IP.To4() != nil)
+	buf := make([]byte, 2048)
+	deadline := time.Now().Add(window)
+	_ = conn.SetReadDeadline(deadline)
+	for time.Now().Before(deadline) {
+		_, ifIndex, err := read(buf)
+		if err != nil {
+			return false
+		}
+		// 入口网卡未知（平台不支持）时不判定为恢复，避免误回切
+		if ifIndex == iface.Index {
+			return true
+		}
+	}
+	return false
+}
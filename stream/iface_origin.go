@@ -0,0 +1,59 @@
+package stream
+
+import (
+	"strings"
+
+	"github.com/qist/tvgate/config"
+)
+
+// HubIfaceOrigin Hub 网卡列表的来源，决定配置重载时 Hub 是否切换网卡：
+// 使用全局默认的随 server.multicast_ifaces 变化，取自频道的按新的频道表重新解析，请求显式指定的保持不变
+type HubIfaceOrigin struct {
+	Default bool   // 未指定网卡，使用全局 server.multicast_ifaces
+	Channel string // 网卡取自该频道的 channels[].ifaces（频道未配置网卡时同时为 Default）
+}
+
+// ResolveIfaces 返回 Hub 使用的网卡列表及其来源：ifaces 为空时使用全局 server.multicast_ifaces；
+// channel 非空表示 ifaces 取自该频道配置，为空表示由请求显式指定。调用方需持有 config.CfgMu 读锁
+func ResolveIfaces(channel string, ifaces []string) ([]string, HubIfaceOrigin) {
+	return resolveIfaces(channel, ifaces, config.Cfg.Server.MulticastIfaces)
+}
+
+func resolveIfaces(channel string, ifaces, global []string) ([]string, HubIfaceOrigin) {
+	if len(ifaces) == 0 {
+		return append([]string(nil), global...), HubIfaceOrigin{Default: true, Channel: channel}
+	}
+	return append([]string(nil), ifaces...), HubIfaceOrigin{Channel: channel}
+}
+
+// ReresolveIfaces 按重载后的配置重新解析监听 addr 的 Hub 应使用的网卡列表：
+// 取自频道的按新的频道表解析（频道仍指向 addr 时），使用全局默认的改用 global；
+// ok 为 false 表示网卡由请求显式指定或频道已不再指向该源，保持不变。调用方需持有 config.CfgMu 读锁
+func ReresolveIfaces(addr string, origin HubIfaceOrigin, global []string) (ifaces []string, newOrigin HubIfaceOrigin, ok bool) {
+	if origin.Channel != "" {
+		for _, ch := range config.Cfg.Channels {
+			if ch != nil && strings.TrimSuffix(ch.Path, "/") == strings.TrimSuffix(origin.Channel, "/") && ch.SourceAddr() == addr {
+				ifaces, newOrigin = resolveIfaces(origin.Channel, ch.Ifaces, global)
+				return ifaces, newOrigin, true
+			}
+		}
+	}
+	if origin.Default {
+		return append([]string(nil), global...), origin, true
+	}
+	return nil, origin, false
+}
+
+// IfaceOrigin 返回 Hub 网卡列表的来源
+func (h *StreamHub) IfaceOrigin() HubIfaceOrigin {
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+	return h.ifaceOrigin
+}
+
+// SetIfaceOrigin 更新 Hub 网卡列表的来源，重载时随网卡一起更新
+func (h *StreamHub) SetIfaceOrigin(origin HubIfaceOrigin) {
+	h.Mu.Lock()
+	h.ifaceOrigin = origin
+	h.Mu.Unlock()
+}
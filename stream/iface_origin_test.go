@@ -0,0 +1,60 @@
+package stream
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qist/tvgate/config"
+)
+
+// 配置重载时只有使用全局默认网卡的 Hub 切换到新的全局网卡，取自频道的按新频道表解析，请求指定的不变
+func TestReresolveIfaces(t *testing.T) {
+	setChannels(t,
+		&config.ChannelConfig{Path: "/live/a", UDPAddr: "239.1.1.1:5000", Ifaces: []string{"eth2"}},
+		&config.ChannelConfig{Path: "/live/b", UDPAddr: "239.1.1.2:5000"},
+		&config.ChannelConfig{Path: "/live/moved", UDPAddr: "239.1.1.9:5000", Ifaces: []string{"eth2"}},
+	)
+	global := []string{"eth1"}
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+
+	for _, tc := range []struct {
+		name   string
+		addr   string
+		origin HubIfaceOrigin
+		want   []string
+		wantOK bool
+		wantO  HubIfaceOrigin
+	}{
+		{"global default", "239.1.1.5:5000", HubIfaceOrigin{Default: true}, global, true, HubIfaceOrigin{Default: true}},
+		{"explicit", "239.1.1.5:5000", HubIfaceOrigin{}, nil, false, HubIfaceOrigin{}},
+		{"channel ifaces", "239.1.1.1:5000", HubIfaceOrigin{Channel: "/live/a"}, []string{"eth2"}, true, HubIfaceOrigin{Channel: "/live/a"}},
+		{"channel now default", "239.1.1.2:5000", HubIfaceOrigin{Channel: "/live/b"}, global, true, HubIfaceOrigin{Default: true, Channel: "/live/b"}},
+		{"channel now explicit", "239.1.1.1:5000", HubIfaceOrigin{Default: true, Channel: "/live/a"}, []string{"eth2"}, true, HubIfaceOrigin{Channel: "/live/a"}},
+		{"channel source changed", "239.1.1.3:5000", HubIfaceOrigin{Channel: "/live/moved"}, nil, false, HubIfaceOrigin{Channel: "/live/moved"}},
+		{"channel removed", "239.1.1.4:5000", HubIfaceOrigin{Channel: "/live/gone"}, nil, false, HubIfaceOrigin{Channel: "/live/gone"}},
+	} {
+		ifaces, origin, ok := ReresolveIfaces(tc.addr, tc.origin, global)
+		if ok != tc.wantOK || !reflect.DeepEqual(ifaces, tc.want) || origin != tc.wantO {
+			t.Errorf("%s: got %v, %+v, %v; want %v, %+v, %v", tc.name, ifaces, origin, ok, tc.want, tc.wantO, tc.wantOK)
+		}
+	}
+}
+
+// 新建的 Hub 记录网卡来源，复用已有 Hub 时保持创建时的来源
+func TestGetOrCreateHubRecordsIfaceOrigin(t *testing.T) {
+	closeAllHubs()
+	t.Cleanup(closeAllHubs)
+	addr := config.UnicastScheme + "127.0.0.1:0"
+	hub, err := GetOrCreateHubWithOrigin(addr, nil, "", HubIfaceOrigin{Channel: "/live/a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := GetOrCreateHub(addr, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != hub || hub.IfaceOrigin() != (HubIfaceOrigin{Channel: "/live/a"}) {
+		t.Errorf("origin = %+v (same hub %v)", hub.IfaceOrigin(), again == hub)
+	}
+}
@@ -0,0 +1,106 @@
+package stream
+
+import (
+	"time"
+)
+
+// 抖动缓冲最大帧数上限，防止误配置导致内存无界增长（每帧最多 4KB）
+const maxJitterBufferFrames = 2000
+
+// jitterBuffer 有界抖动缓冲：平滑源端微突发，源短暂停顿时按平均包间隔继续向客户端输出缓存帧
+// 所有字段受 StreamHub.sendMu 保护（notify 除外）
+type jitterBuffer struct {
+	frames   [][]byte
+	size     int           // 最大缓存帧数
+	target   int           // 目标缓存深度，低于该深度时按平均间隔输出
+	lastIn   time.Time     // 上一帧到达时间
+	interval time.Duration // 平均包到达间隔（EWMA）
+	notify   chan struct{} // 新帧到达通知
+}
+
+func newJitterBuffer(size int) *jitterBuffer {
+	if size > maxJitterBufferFrames {
+		size = maxJitterBufferFrames
+	}
+	target := size / 2
+	if target < 1 {
+		target = 1
+	}
+	return &jitterBuffer{
+		frames: make([][]byte, 0, size),
+		size:   size,
+		target: target,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// push 写入一帧，缓冲已满时丢弃最旧的帧；调用方需持有 h.sendMu
+func (jb *jitterBuffer) push(data []byte, now time.Time) {
+	if !jb.lastIn.IsZero() {
+		d := now.Sub(jb.lastIn)
+		if d > 100*time.Millisecond {
+			d = 100 * time.Millisecond // 停顿不计入平均间隔
+		}
+		jb.interval = jb.interval*7/8 + d/8
+	}
+	jb.lastIn = now
+
+	if len(jb.frames) >= jb.size {
+		jb.frames[0] = nil
+		jb.frames = jb.frames[1:]
+	}
+	jb.frames = append(jb.frames, data)
+
+	select {
+	case jb.notify <- struct{}{}:
+	default:
+	}
+}
+
+// jitterLoop 从抖动缓冲中按节奏取帧广播给客户端：
+// 缓冲深度高于目标时立即输出，低于目标时按平均包间隔输出，缓冲取空后重新预填充
+func (h *StreamHub) jitterLoop() {
+	jb := h.jitter
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	primed := false
+	for {
+		h.sendMu.Lock()
+		n := len(jb.frames)
+		if n >= jb.target {
+			primed = true
+		} else if n == 0 {
+			primed = false
+		}
+		if !primed {
+			h.sendMu.Unlock()
+			select {
+			case <-jb.notify:
+			case <-h.Closed:
+				return
+			}
+			continue
+		}
+
+		data := jb.frames[0]
+		jb.frames[0] = nil
+		jb.frames = jb.frames[1:]
+		h.broadcast(data)
+		var wait time.Duration
+		if n-1 < jb.target {
+			wait = jb.interval
+		}
+		h.sendMu.Unlock()
+
+		if wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+			case <-h.Closed:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package stream
+
+// tsNullPacket 保活用的 MPEG-TS 空包（PID 0x1FFF，播放器会直接丢弃）
+var tsNullPacket = func() []byte {
+	const packetSize = 188
+	pkt := make([]byte, packetSize)
+	pkt[0] = 0x47 // 同步字节
+	pkt[1] = 0x1F // PID 高 5 位
+	pkt[2] = 0xFF // PID 低 8 位
+	pkt[3] = 0x10 // 仅有效载荷，连续计数器 0
+	for i := 4; i < packetSize; i++ {
+		pkt[i] = 0xFF
+	}
+	return pkt
+}()
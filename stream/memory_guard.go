@@ -0,0 +1,81 @@
+package stream
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+	"github.com/qist/tvgate/monitor"
+)
+
+// 内存上限的计量来源
+const (
+	MemorySourceHeap = "heap" // runtime.MemStats.HeapInuse（默认）
+	MemorySourceRSS  = "rss"  // 监控采样的进程常驻内存
+)
+
+// heapSampleInterval HeapInuse 的缓存时间，避免每个新连接都调用 ReadMemStats
+const heapSampleInterval = time.Second
+
+var (
+	heapMu        sync.Mutex
+	heapInuse     uint64
+	heapSampledAt time.Time
+	lastShedLog   time.Time
+)
+
+// memoryCap 读取内存上限 (bytes，0 表示不限制) 及计量来源
+func memoryCap() (uint64, string) {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	limit := uint64(config.Cfg.Stream.MaxMemoryMB) << 20
+	source := config.Cfg.Stream.MemorySource
+	if source != MemorySourceRSS {
+		source = MemorySourceHeap
+	}
+	return limit, source
+}
+
+// currentHeapInuse 返回最多缓存 1s 的 HeapInuse
+func currentHeapInuse() uint64 {
+	heapMu.Lock()
+	defer heapMu.Unlock()
+	if time.Since(heapSampledAt) >= heapSampleInterval {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		heapInuse, heapSampledAt = ms.HeapInuse, time.Now()
+	}
+	return heapInuse
+}
+
+// OverMemoryLimit 内存是否已超过 stream.max_memory_mb，用于在 OOM 前拒绝新的流连接（已有连接不受影响）；
+// 超限时计入监控的拒绝次数，并最多每 10s 记录一次日志
+func OverMemoryLimit() bool {
+	limit, source := memoryCap()
+	if limit == 0 {
+		return false
+	}
+	var used uint64
+	if source == MemorySourceRSS {
+		used = monitor.GlobalTrafficStats.AppMemoryUsage()
+	} else {
+		used = currentHeapInuse()
+	}
+	if used < limit {
+		return false
+	}
+	monitor.RecordMemoryShed()
+	heapMu.Lock()
+	logNow := time.Since(lastShedLog) >= 10*time.Second
+	if logNow {
+		lastShedLog = time.Now()
+	}
+	heapMu.Unlock()
+	if logNow {
+		logger.LogPrintf("🧠 内存 %s (%s) 超过上限 %s，拒绝新的流连接（累计 %d 次）",
+			monitor.FormatBytes(used), source, monitor.FormatBytes(limit), monitor.MemoryShedCount())
+	}
+	return true
+}
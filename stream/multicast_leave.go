@@ -0,0 +1,67 @@
+package stream
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/qist/tvgate/logger"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// leaveMulticastGroup 在关闭套接字前显式退出组播组，促使内核立即发送 IGMP/MLD Leave，
+// 避免部分平台仅关闭套接字时不及时发送离开报文，交换机继续转发组播流量
+// 网卡的选择与 listenUDP 加入时一致：localAddr 优先，其次按网卡列表顺序，最后为默认接口
+func leaveMulticastGroup(conn *net.UDPConn, udpAddr string, ifaces []string, localAddr string) {
+	if conn == nil {
+		return
+	}
+	addr, err := net.ResolveUDPAddr("udp", udpAddr)
+	if err != nil || !addr.IP.IsMulticast() {
+		return
+	}
+
+	var candidates []*net.Interface
+	switch {
+	case localAddr != "":
+		if ip := net.ParseIP(localAddr); ip != nil {
+			if iface, err := interfaceByIP(ip); err == nil {
+				candidates = append(candidates, iface)
+			}
+		}
+	case len(ifaces) > 0:
+		for _, name := range ifaces {
+			if iface, err := net.InterfaceByName(name); err == nil {
+				candidates = append(candidates, iface)
+			}
+		}
+	default:
+		candidates = append(candidates, nil)
+	}
+
+	var lastErr error
+	for _, iface := range candidates {
+		if lastErr = leaveGroup(conn, addr, iface); lastErr == nil {
+			name := "默认接口"
+			if iface != nil {
+				name = iface.Name
+			}
+			logger.LogPrintf("👋 已退出组播组 %s@%s", udpAddr, name)
+			return
+		}
+	}
+	if lastErr != nil {
+		logger.LogPrintf("⚠️ 退出组播组 %s 失败（将依赖关闭套接字隐式退出）: %v", udpAddr, lastErr)
+	}
+}
+
+// leaveGroup 按地址族在指定网卡上退出组播组，iface 为 nil 表示默认接口
+func leaveGroup(conn *net.UDPConn, group *net.UDPAddr, iface *net.Interface) error {
+	if group.IP.To4() != nil {
+		return ipv4.NewPacketConn(conn).LeaveGroup(iface, &net.UDPAddr{IP: group.IP})
+	}
+	if group.IP.To16() != nil {
+		return ipv6.NewPacketConn(conn).LeaveGroup(iface, &net.UDPAddr{IP: group.IP})
+	}
+	return fmt.Errorf("无效的组播地址: %s", group.IP)
+}
@@ -0,0 +1,234 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+	"github.com/qist/tvgate/monitor"
+)
+
+// 预览变体：把频道完整流交给 ffmpeg 转成低分辨率低码率的 TS，作为独立 Hub 分发给监控墙等预览客户端。
+// 预览 Hub 以真实客户端身份订阅完整 Hub，只在有预览观众时运行 ffmpeg，最后一个预览观众离开后随 Hub 关闭一起退出
+const (
+	previewPrefix         = "preview:"
+	defaultPreviewWidth   = 320
+	defaultPreviewBitrate = 300 // kbps
+	defaultPreviewFFmpeg  = "ffmpeg"
+	previewFeedBuffer     = 1024
+	previewMinBackoff     = time.Second
+	previewMaxBackoff     = 30 * time.Second
+)
+
+// VariantPreview 预览变体标识，与监控中的客户端统计一致
+const VariantPreview = monitor.VariantPreview
+
+// PreviewSource 返回频道预览变体的源地址（同时作为 HubKey）
+func PreviewSource(channelPath string) string {
+	return previewPrefix + channelPath
+}
+
+// IsPreviewSource 源地址是否为频道预览变体
+func IsPreviewSource(addr string) bool {
+	return strings.HasPrefix(addr, previewPrefix)
+}
+
+// Variant 返回 Hub 分发的流变体：预览 Hub 为 VariantPreview，完整流为空
+func (h *StreamHub) Variant() string {
+	if h.preview != "" {
+		return VariantPreview
+	}
+	return ""
+}
+
+// previewSettings 解析频道预览配置及完整流的源地址、网卡与本地地址
+type previewSettings struct {
+	addr      string
+	ifaces    []string
+	origin    HubIfaceOrigin
+	localAddr string
+	ffmpeg    string
+	args      []string
+}
+
+func lookupPreview(channelPath string) (previewSettings, error) {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	for _, ch := range config.Cfg.Channels {
+		if ch == nil || strings.TrimSuffix(ch.Path, "/") != strings.TrimSuffix(channelPath, "/") {
+			continue
+		}
+		if !ch.Preview.Enabled {
+			return previewSettings{}, fmt.Errorf("频道 %s 未启用预览", channelPath)
+		}
+		s := previewSettings{
+			addr:      ch.SourceAddr(),
+			localAddr: ch.LocalAddr,
+			ffmpeg:    ch.Preview.FFmpeg,
+		}
+		s.ifaces, s.origin = ResolveIfaces(ch.Path, ch.Ifaces)
+		if s.localAddr == "" {
+			s.localAddr = config.Cfg.Server.MulticastLocalAddr
+		}
+		if s.ffmpeg == "" {
+			s.ffmpeg = defaultPreviewFFmpeg
+		}
+		s.args = previewArgs(ch.Preview)
+		return s, nil
+	}
+	return previewSettings{}, fmt.Errorf("频道 %s 不存在", channelPath)
+}
+
+// previewArgs 生成 ffmpeg 参数：标准输入读完整 TS，缩放并以限定码率编码后从标准输出写出 TS
+func previewArgs(p config.PreviewConfig) []string {
+	width, bitrate := p.Width, p.Bitrate
+	if width <= 0 {
+		width = defaultPreviewWidth
+	}
+	if bitrate <= 0 {
+		bitrate = defaultPreviewBitrate
+	}
+	kbps := strconv.Itoa(bitrate) + "k"
+	return []string{
+		"-hide_banner", "-loglevel", "error",
+		"-fflags", "+genpts+discardcorrupt", "-f", "mpegts", "-i", "pipe:0",
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-vf", "scale=" + strconv.Itoa(width) + ":-2",
+		"-c:v", "libx264", "-preset", "veryfast", "-tune", "zerolatency",
+		"-b:v", kbps, "-maxrate", kbps, "-bufsize", strconv.Itoa(bitrate*2) + "k", "-g", "50",
+		"-c:a", "aac", "-b:a", "48k", "-ac", "2",
+		"-f", "mpegts", "pipe:1",
+	}
+}
+
+// previewReadLoop 运行预览转码并送入广播，ffmpeg 或完整流中断后指数退避重启；
+// 中断时已无预览观众则关闭 Hub
+func (h *StreamHub) previewReadLoop() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-h.Closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	backoff := previewMinBackoff
+	for {
+		received, err := h.previewOnce(ctx)
+		select {
+		case <-h.Closed:
+			return
+		default:
+		}
+
+		h.Mu.Lock()
+		clientCount := len(h.Clients)
+		if err != nil {
+			h.readErrors++
+			h.lastErr = err.Error()
+			h.lastErrAt = time.Now()
+		}
+		h.Mu.Unlock()
+		if clientCount == 0 && h.closeIfIdle() {
+			logger.LogPrintf("没有预览观众，停止转码并关闭 Hub: %s", h.addr)
+			return
+		}
+
+		if received {
+			backoff = previewMinBackoff
+		}
+		logger.LogPrintf("⚠️ 预览转码 %s 中断: %v，%v 后重启", h.preview, err, backoff)
+		t := time.NewTimer(backoff)
+		select {
+		case <-h.Closed:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		backoff *= 2
+		if backoff > previewMaxBackoff {
+			backoff = previewMaxBackoff
+		}
+	}
+}
+
+// previewOnce 订阅完整流并运行一次 ffmpeg，直到任一方结束；received 表示本次是否输出过数据
+func (h *StreamHub) previewOnce(parent context.Context) (received bool, err error) {
+	s, err := lookupPreview(h.preview)
+	if err != nil {
+		return false, err
+	}
+	full, err := GetOrCreateHubWithOrigin(s.addr, s.ifaces, s.localAddr, s.origin)
+	if err != nil {
+		return false, err
+	}
+	feed := make(chan []byte, previewFeedBuffer)
+	if err := full.subscribe(feed, getStreamTimeouts().subscribe); err != nil {
+		return false, err
+	}
+	defer full.unsubscribe(feed, getStreamTimeouts().subscribe)
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, s.ffmpeg, s.args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return false, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return false, err
+	}
+	logger.LogPrintf("🟢 预览转码 %s 已启动 (源 %s)", h.preview, s.addr)
+
+	// 完整流 → ffmpeg 标准输入；完整 Hub 关闭（通道被关闭）或写入失败时结束转码
+	go func() {
+		defer cancel()
+		defer stdin.Close()
+		for {
+			select {
+			case data, ok := <-feed:
+				if !ok {
+					return
+				}
+				if _, err := stdin.Write(data); err != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		buf := h.BufPool.Get().([]byte)
+		n, rerr := io.ReadFull(stdout, buf[:httpIngestChunk])
+		if n > 0 {
+			received = true
+			h.sendMu.Lock()
+			h.ingestLocked(buf[:n])
+			h.sendMu.Unlock()
+		}
+		h.BufPool.Put(buf[:cap(buf)])
+		if rerr != nil {
+			cancel()
+			werr := cmd.Wait()
+			if errors.Is(rerr, io.ErrUnexpectedEOF) || errors.Is(rerr, io.EOF) {
+				rerr = fmt.Errorf("ffmpeg 退出: %v", werr)
+			}
+			return received, rerr
+		}
+	}
+}
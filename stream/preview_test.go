@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// setChannels 临时替换频道配置，测试结束后恢复
+func setChannels(t *testing.T, channels ...*config.ChannelConfig) {
+	t.Helper()
+	config.CfgMu.Lock()
+	saved := config.Cfg.Channels
+	config.Cfg.Channels = channels
+	config.CfgMu.Unlock()
+	t.Cleanup(func() {
+		config.CfgMu.Lock()
+		config.Cfg.Channels = saved
+		config.CfgMu.Unlock()
+	})
+}
+
+func TestPreviewArgs(t *testing.T) {
+	args := strings.Join(previewArgs(config.PreviewConfig{}), " ")
+	for _, want := range []string{"-i pipe:0", "scale=320:-2", "-b:v 300k", "-bufsize 600k", "-f mpegts pipe:1"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("default args %q missing %q", args, want)
+		}
+	}
+	args = strings.Join(previewArgs(config.PreviewConfig{Width: 640, Bitrate: 800}), " ")
+	for _, want := range []string{"scale=640:-2", "-b:v 800k", "-maxrate 800k", "-bufsize 1600k"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("custom args %q missing %q", args, want)
+		}
+	}
+}
+
+func TestLookupPreview(t *testing.T) {
+	config.CfgMu.Lock()
+	savedIfaces := config.Cfg.Server.MulticastIfaces
+	config.Cfg.Server.MulticastIfaces = []string{"eth9"}
+	config.CfgMu.Unlock()
+	defer func() {
+		config.CfgMu.Lock()
+		config.Cfg.Server.MulticastIfaces = savedIfaces
+		config.CfgMu.Unlock()
+	}()
+	setChannels(t,
+		&config.ChannelConfig{Path: "/live/off", UDPAddr: "239.1.1.1:5000"},
+		&config.ChannelConfig{Path: "/live/on", UDPAddr: "239.1.1.2:5000", Mode: config.ListenModeUnicast,
+			Preview: config.PreviewConfig{Enabled: true, Width: 480}},
+	)
+
+	if _, err := lookupPreview("/live/off"); err == nil {
+		t.Error("preview disabled: want error")
+	}
+	if _, err := lookupPreview("/live/missing"); err == nil {
+		t.Error("missing channel: want error")
+	}
+	s, err := lookupPreview("/live/on/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.addr != "unicast://239.1.1.2:5000" || s.ffmpeg != defaultPreviewFFmpeg ||
+		len(s.ifaces) != 1 || s.ifaces[0] != "eth9" || !strings.Contains(strings.Join(s.args, " "), "scale=480:-2") {
+		t.Errorf("lookupPreview = %+v", s)
+	}
+}
+
+// 频道不存在或未启用预览时，任何入口都不能创建预览 Hub
+func TestPreviewHubRequiresEnabledChannel(t *testing.T) {
+	setChannels(t, &config.ChannelConfig{Path: "/live/off", UDPAddr: "239.1.1.1:5000"})
+	for _, path := range []string{"/live/off", "/live/missing"} {
+		if hub, err := GetOrCreateHub(PreviewSource(path), nil, ""); err == nil {
+			hub.Close()
+			t.Errorf("%s: preview hub created", path)
+		}
+	}
+}
+
+// 转码退出时已没有预览观众，预览 Hub 随之关闭，不再重启 ffmpeg
+func TestPreviewIdleShutdown(t *testing.T) {
+	setChannels(t, &config.ChannelConfig{Path: "/live/pv", UDPAddr: "127.0.0.1:0", Mode: config.ListenModeUnicast,
+		Preview: config.PreviewConfig{Enabled: true, FFmpeg: "true"}})
+	t.Cleanup(closeAllHubs)
+
+	hub, err := GetOrCreateHub(PreviewSource("/live/pv"), nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-hub.Closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle preview hub not closed")
+	}
+}
+
+func closeAllHubs() {
+	HubsMu.Lock()
+	hubs := make([]*StreamHub, 0, len(Hubs))
+	for key, h := range Hubs {
+		hubs = append(hubs, h)
+		delete(Hubs, key)
+	}
+	HubsMu.Unlock()
+	for _, h := range hubs {
+		h.Close()
+	}
+}
@@ -0,0 +1,60 @@
+package stream
+
+import (
+	"strings"
+	"time"
+
+	"github.com/qist/tvgate/monitor"
+)
+
+func init() {
+	monitor.RegisterProbeFunc(ProbeHub)
+}
+
+// findHub 按 HubKey 或源地址（别名）查找运行中的 Hub
+func findHub(keyOrAddr string) (string, *StreamHub, bool) {
+	HubsMu.Lock()
+	defer HubsMu.Unlock()
+	if h, ok := Hubs[keyOrAddr]; ok {
+		return keyOrAddr, h, true
+	}
+	for k, h := range Hubs {
+		if strings.SplitN(k, "|", 2)[0] == keyOrAddr {
+			return k, h, true
+		}
+	}
+	return "", nil, false
+}
+
+// ProbeHub 以静默订阅者身份在 d 时间内统计指定 Hub 收到的数据，不创建新 Hub
+func ProbeHub(keyOrAddr string, d time.Duration) (monitor.ProbeResult, bool) {
+	key, h, ok := findHub(keyOrAddr)
+	if !ok {
+		return monitor.ProbeResult{}, false
+	}
+	res := monitor.ProbeResult{HubKey: key, Duration: d}
+
+	ch, cancel := h.SubscribeSilent(200)
+	defer cancel()
+
+	start := time.Now()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case data, ok := <-ch:
+			if !ok {
+				// Hub 已关闭
+				return res, true
+			}
+			if res.Frames == 0 {
+				res.FirstFrame = time.Since(start)
+			}
+			res.Frames++
+			res.Bytes += uint64(len(data))
+			res.Flowing = true
+		case <-timer.C:
+			return res, true
+		}
+	}
+}
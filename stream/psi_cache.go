@@ -0,0 +1,107 @@
+package stream
+
+// MPEG-TS 常量
+const (
+	tsPacketSize = 188
+	tsSyncByte   = 0x47
+	patPID       = 0x0000
+	// 单个 PSI 表最多缓存的 TS 包数（跨包的长 PMT）
+	maxPSIPackets = 8
+)
+
+// psiCache 缓存最近一次完整的 PAT/PMT 包，新客户端加入时先发送，
+// 中途加入的播放器无需等待下一个 PAT/PMT 周期即可开始解码
+// 仅识别裸 TS 数据报（以 0x47 同步字节开头且长度为 188 的整数倍），RTP 封装的源不缓存
+type psiCache struct {
+	pat    [][]byte
+	pmt    [][]byte
+	pmtPID int // 从 PAT 中解析的第一个节目的 PMT PID，-1 表示未知
+}
+
+func newPSICache() *psiCache {
+	return &psiCache{pmtPID: -1}
+}
+
+// observe 扫描数据报中的 TS 包，更新 PAT/PMT 缓存；调用方需持有 h.Mu
+func (c *psiCache) observe(data []byte) {
+	if len(data) < tsPacketSize || len(data)%tsPacketSize != 0 || data[0] != tsSyncByte {
+		return
+	}
+	for off := 0; off+tsPacketSize <= len(data); off += tsPacketSize {
+		pkt := data[off : off+tsPacketSize]
+		if pkt[0] != tsSyncByte {
+			return
+		}
+		pid := int(pkt[1]&0x1f)<<8 | int(pkt[2])
+		pusi := pkt[1]&0x40 != 0
+		switch {
+		case pid == patPID:
+			c.pat = appendPSIPacket(c.pat, pkt, pusi)
+			if pusi {
+				if p := parsePMTPID(pkt); p >= 0 && p != c.pmtPID {
+					c.pmtPID = p
+					c.pmt = nil
+				}
+			}
+		case pid == c.pmtPID:
+			c.pmt = appendPSIPacket(c.pmt, pkt, pusi)
+		}
+	}
+}
+
+// appendPSIPacket 新表（PUSI）开始时重置缓存，续包追加，超出上限则丢弃
+func appendPSIPacket(pkts [][]byte, pkt []byte, pusi bool) [][]byte {
+	if pusi {
+		pkts = pkts[:0]
+	} else if len(pkts) == 0 || len(pkts) >= maxPSIPackets {
+		return pkts
+	}
+	cp := make([]byte, tsPacketSize)
+	copy(cp, pkt)
+	return append(pkts, cp)
+}
+
+// frame 返回缓存的 PAT+PMT 拼接数据，尚未收齐时返回 nil
+func (c *psiCache) frame() []byte {
+	if len(c.pat) == 0 || len(c.pmt) == 0 {
+		return nil
+	}
+	out := make([]byte, 0, (len(c.pat)+len(c.pmt))*tsPacketSize)
+	for _, p := range c.pat {
+		out = append(out, p...)
+	}
+	for _, p := range c.pmt {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// parsePMTPID 从携带 PAT 段起始的 TS 包中解析第一个非 0 节目的 PMT PID
+func parsePMTPID(pkt []byte) int {
+	i := 4
+	if afc := (pkt[3] >> 4) & 0x3; afc == 2 || afc == 3 {
+		// 跳过适配域
+		i += 1 + int(pkt[4])
+	}
+	if i >= len(pkt) {
+		return -1
+	}
+	i += 1 + int(pkt[i]) // pointer_field
+	// table_id(1) + section_length(2) + transport_stream_id(2) + version(1) + section_number(1) + last_section_number(1)
+	if i+8 > len(pkt) || pkt[i] != 0x00 {
+		return -1
+	}
+	sectionLen := int(pkt[i+1]&0x0f)<<8 | int(pkt[i+2])
+	end := i + 3 + sectionLen - 4 // 去掉 CRC32
+	if end > len(pkt) {
+		end = len(pkt)
+	}
+	for j := i + 8; j+4 <= end; j += 4 {
+		program := int(pkt[j])<<8 | int(pkt[j+1])
+		if program == 0 {
+			continue // 网络信息表
+		}
+		return int(pkt[j+2]&0x1f)<<8 | int(pkt[j+3])
+	}
+	return -1
+}
@@ -0,0 +1,159 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// RatePolicyPace 超出速率上限时延迟写入（按令牌桶节奏输出）
+	RatePolicyPace = "pace"
+	// RatePolicyDrop 超出速率上限时丢弃本次写入
+	RatePolicyDrop = "drop"
+
+	// rateBurstWindow 令牌桶容量对应的时长，允许秒开缓存等短时突发
+	rateBurstWindow = 500 * time.Millisecond
+	// throttleActiveWindow 最近一次限速后仍视为“限速中”的时长
+	throttleActiveWindow = 2 * time.Second
+)
+
+// RateLimitWriter 包装客户端 ResponseWriter，按令牌桶限制写入速率（频道 max_mbps），
+// 超出时按策略延迟（pace）或丢弃（drop）整次写入，保持 TS 包边界
+type RateLimitWriter struct {
+	http.ResponseWriter
+
+	rate   float64 // 字节/秒
+	burst  float64
+	policy string
+
+	mu        sync.Mutex
+	tokens    float64
+	last      time.Time
+	prepaid   int       // Pace 已扣除令牌、尚未写出的字节数
+	throttled time.Time // 最近一次限速（等待或丢弃）的时间
+	dropped   uint64    // drop 策略下累计丢弃的字节数
+}
+
+// writePacer 由限速写入器实现：ServeHTTP 在设置写截止时间之前调用 Pace 等待令牌，
+// 等待时间不计入 write_timeout，客户端断开时提前返回
+type writePacer interface {
+	Pace(ctx context.Context, n int) error
+}
+
+// NewRateLimitWriter 创建限速写入器，mbps 为速率上限，policy 为 pace/drop（其他值按 pace 处理）
+func NewRateLimitWriter(w http.ResponseWriter, mbps float64, policy string) *RateLimitWriter {
+	rate := mbps * 1e6 / 8
+	burst := rate * rateBurstWindow.Seconds()
+	if policy != RatePolicyDrop {
+		policy = RatePolicyPace
+	}
+	return &RateLimitWriter{
+		ResponseWriter: w,
+		rate:           rate,
+		burst:          burst,
+		policy:         policy,
+		tokens:         burst,
+		last:           time.Now(),
+	}
+}
+
+// reserveLocked 为写入 n 字节补充并扣除令牌，返回 pace 策略需等待的时长，drop 为 true 表示应丢弃本次写入。
+// 桶容量至少为本次写入大小：合并写入等超过突发容量的写入在令牌攒够后仍能通过，而不是永远被丢弃
+func (l *RateLimitWriter) reserveLocked(n int, now time.Time) (wait time.Duration, drop bool) {
+	need := float64(n)
+	capacity := l.burst
+	if need > capacity {
+		capacity = need
+	}
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > capacity {
+		l.tokens = capacity
+	}
+	l.last = now
+	if l.tokens < need {
+		l.throttled = now
+		if l.policy == RatePolicyDrop {
+			l.dropped += uint64(n)
+			return 0, true
+		}
+		wait = time.Duration((need - l.tokens) / l.rate * float64(time.Second))
+	}
+	// pace：预先扣除令牌（可为负），等待期间其他写入不会重复占用
+	l.tokens -= need
+	return wait, false
+}
+
+// Pace 在写入 n 字节之前按 pace 策略等待令牌，已扣除的令牌由随后的 Write 使用；drop 策略不等待
+func (l *RateLimitWriter) Pace(ctx context.Context, n int) error {
+	if l.policy == RatePolicyDrop {
+		return nil
+	}
+	l.mu.Lock()
+	wait, _ := l.reserveLocked(n, time.Now())
+	l.prepaid += n
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *RateLimitWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	if l.prepaid >= len(p) {
+		l.prepaid -= len(p)
+		l.mu.Unlock()
+		return l.ResponseWriter.Write(p)
+	}
+	wait, drop := l.reserveLocked(len(p), time.Now())
+	l.mu.Unlock()
+
+	if drop {
+		return len(p), nil
+	}
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	return l.ResponseWriter.Write(p)
+}
+
+// Flush 透传 http.Flusher，保证逐帧推送
+func (l *RateLimitWriter) Flush() {
+	if f, ok := l.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (l *RateLimitWriter) Unwrap() http.ResponseWriter {
+	return l.ResponseWriter
+}
+
+// Throttled 返回最近是否正在限速
+func (l *RateLimitWriter) Throttled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return !l.throttled.IsZero() && time.Since(l.throttled) < throttleActiveWindow
+}
+
+// Dropped 返回 drop 策略下累计丢弃的字节数
+func (l *RateLimitWriter) Dropped() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped
+}
+
+// Describe 返回限速配置的简短描述，例如 "4.0 Mbps/pace"
+func (l *RateLimitWriter) Describe() string {
+	return fmt.Sprintf("%.1f Mbps/%s", l.rate*8/1e6, l.policy)
+}
@@ -0,0 +1,71 @@
+package stream
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// drop 策略下超过突发容量的写入（如合并写入）在令牌攒够后可以通过，而不是永远被丢弃
+func TestRateLimitDropLargeWrite(t *testing.T) {
+	rec := httptest.NewRecorder()
+	l := NewRateLimitWriter(rec, 8, RatePolicyDrop) // 1 MB/s，突发 500 KB
+	p := make([]byte, 600*1000)
+
+	if _, err := l.Write(p); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Body.Len() != 0 || l.Dropped() != uint64(len(p)) {
+		t.Fatalf("first write: body = %d, dropped = %d; want dropped", rec.Body.Len(), l.Dropped())
+	}
+
+	// 模拟经过 1 秒：令牌可攒到本次写入大小
+	l.mu.Lock()
+	l.last = l.last.Add(-time.Second)
+	l.mu.Unlock()
+	if _, err := l.Write(p); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Body.Len() != len(p) {
+		t.Errorf("write larger than burst never succeeded: body = %d", rec.Body.Len())
+	}
+}
+
+// Pace 预先扣除令牌，随后的 Write 不重复计费也不再等待；客户端断开时 Pace 提前返回
+func TestRateLimitPace(t *testing.T) {
+	rec := httptest.NewRecorder()
+	l := NewRateLimitWriter(rec, 0.08, RatePolicyPace) // 10 KB/s，突发 5 KB
+	p := make([]byte, 5000)
+
+	if err := l.Pace(context.Background(), len(p)); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := l.Write(p); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("Write after Pace waited %v", d)
+	}
+	l.mu.Lock()
+	tokens, prepaid := l.tokens, l.prepaid
+	l.mu.Unlock()
+	if tokens < -1 || prepaid != 0 {
+		t.Errorf("tokens = %.0f, prepaid = %d; write was charged twice", tokens, prepaid)
+	}
+
+	// 令牌已耗尽，下一帧需等待约 500ms：上下文取消时立即返回
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start = time.Now()
+	if err := l.Pace(ctx, len(p)); err == nil {
+		t.Error("Pace ignored the canceled context")
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("Pace with canceled context took %v", d)
+	}
+	if !l.Throttled() {
+		t.Error("Throttled() = false after waiting for tokens")
+	}
+}
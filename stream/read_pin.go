@@ -0,0 +1,46 @@
+package stream
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+)
+
+// highPrioritySource 源地址是否被某个频道标记为 priority: high
+func highPrioritySource(addr string) bool {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	for _, ch := range config.Cfg.Channels {
+		if ch != nil && strings.EqualFold(ch.Priority, config.ChannelPriorityHigh) && ch.SourceAddr() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// readThreadNice 读取高优先级接收线程的 nice 值，0 表示不调整
+func readThreadNice() int {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	return config.Cfg.Stream.ReadThreadNice
+}
+
+// pinReadThread 高优先级 Hub 的接收协程独占当前 OS 线程，并按 stream.read_thread_nice 调整该线程优先级；
+// 返回的函数在接收协程退出时调用。调整过优先级的线程不再交还调度器，协程退出时随之销毁，避免影响其他协程
+func (h *StreamHub) pinReadThread() func() {
+	if !h.pinned {
+		return func() {}
+	}
+	runtime.LockOSThread()
+	nice := readThreadNice()
+	if nice == 0 {
+		return runtime.UnlockOSThread
+	}
+	if err := setThreadNice(nice); err != nil {
+		logger.LogPrintf("⚠️ Hub %s 接收线程设置 nice=%d 失败（需要 CAP_SYS_NICE）: %v", h.addr, nice, err)
+		return runtime.UnlockOSThread
+	}
+	return func() {}
+}
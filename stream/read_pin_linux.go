@@ -0,0 +1,8 @@
+package stream
+
+import "golang.org/x/sys/unix"
+
+// setThreadNice 设置当前 OS 线程的 nice 值（Linux 下 PRIO_PROCESS 以线程 id 作用于单个线程）
+func setThreadNice(nice int) error {
+	return unix.Setpriority(unix.PRIO_PROCESS, unix.Gettid(), nice)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package stream
+
+import "errors"
+
+// setThreadNice 非 Linux 平台不支持单独调整线程优先级
+func setThreadNice(nice int) error {
+	return errors.New("当前平台不支持")
+}
@@ -0,0 +1,142 @@
+package stream
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/libp2p/go-reuseport"
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+)
+
+// maxReadSockets 单个 Hub 并行读取的套接字上限
+const maxReadSockets = 16
+
+// readSocketCount 读取每个 Hub 的接收套接字数，不大于 1 表示单套接字
+func readSocketCount() int {
+	config.CfgMu.RLock()
+	n := config.Cfg.Stream.ReadSockets
+	config.CfgMu.RUnlock()
+	if n > maxReadSockets {
+		n = maxReadSockets
+	}
+	return n
+}
+
+// multiSocketEligible 判断地址能否使用多套接字接收：组播包会被内核复制给组内每个套接字，无法分摊，
+// 因此只用于单播（含非组播地址的普通 UDP 监听）；平台不支持 SO_REUSEPORT 时同样不可用
+func multiSocketEligible(udpAddr string, n int) bool {
+	if n <= 1 || !reuseport.Available() {
+		return false
+	}
+	addr, err := net.ResolveUDPAddr("udp", udpAddr)
+	return err == nil && !addr.IP.IsMulticast()
+}
+
+// listenReadSockets 以 SO_REUSEPORT 在同一地址上打开 n 个 UDP 套接字；localAddr 非空时绑定该地址。
+// spread 为 true 时（需要 RTP 重排兜底乱序）在支持的平台上把包随机分摊到各套接字，否则由内核按五元组哈希分配
+func listenReadSockets(udpAddr, localAddr string, n int, spread bool) ([]*net.UDPConn, error) {
+	addr, err := net.ResolveUDPAddr("udp", udpAddr)
+	if err != nil {
+		return nil, err
+	}
+	bind := udpAddr
+	if localAddr != "" {
+		ip := net.ParseIP(localAddr)
+		if ip == nil {
+			return nil, fmt.Errorf("无效的本地地址: %s", localAddr)
+		}
+		bind = (&net.UDPAddr{IP: ip, Port: addr.Port}).String()
+	}
+
+	conns := make([]*net.UDPConn, 0, n)
+	for i := 0; i < n; i++ {
+		pc, err := reuseport.ListenPacket("udp", bind)
+		if err == nil {
+			if c, ok := pc.(*net.UDPConn); ok {
+				_ = c.SetReadBuffer(8 * 1024 * 1024)
+				conns = append(conns, c)
+				continue
+			}
+			_ = pc.Close()
+			err = fmt.Errorf("非 UDP 套接字")
+		}
+		closeConns(conns)
+		return nil, fmt.Errorf("打开第 %d 个接收套接字失败: %v", i+1, err)
+	}
+	if spread {
+		if err := spreadReusePort(conns[0], n); err != nil {
+			logger.LogPrintf("⚠️ %s 无法按包分摊到各套接字，使用内核默认的按流分配: %v", udpAddr, err)
+		}
+	}
+	logger.LogPrintf("🟢 监听 %s 成功（%d 个 SO_REUSEPORT 套接字）", bind, n)
+	return conns, nil
+}
+
+func closeConns(conns []*net.UDPConn) {
+	for _, c := range conns {
+		_ = c.Close()
+	}
+}
+
+// readConnAt 返回第 idx 个接收套接字，0 为 UdpConn；不存在时返回 nil
+func (h *StreamHub) readConnAt(idx int) *net.UDPConn {
+	if idx == 0 {
+		return h.UdpConn
+	}
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+	if idx < len(h.readConns) {
+		return h.readConns[idx]
+	}
+	return nil
+}
+
+// countSocketRxLocked 累加套接字接收包数；调用方需持有 h.sendMu
+func (h *StreamHub) countSocketRxLocked(idx int) {
+	if idx < len(h.socketRx) {
+		h.socketRx[idx]++
+	}
+}
+
+// setReadConnsLocked 替换接收套接字组（第一个作为 UdpConn）并重置计数，
+// 新增的套接字启动读循环，多出的读循环在下次取套接字时退出；调用方需持有 h.Mu
+func (h *StreamHub) setReadConnsLocked(conns []*net.UDPConn) {
+	prev := len(h.readConns)
+	if prev == 0 {
+		prev = 1
+	}
+	h.sendMu.Lock()
+	defer h.sendMu.Unlock()
+	if len(conns) <= 1 {
+		h.readConns, h.socketRx = nil, nil
+		return
+	}
+	h.readConns = conns
+	h.socketRx = make([]uint64, len(conns))
+	for i := prev; i < len(conns); i++ {
+		go h.readLoop(i)
+	}
+}
+
+// SocketRxStat 单个接收套接字的计数
+type SocketRxStat struct {
+	Index   int    `json:"index"`
+	Local   string `json:"local"`
+	Packets uint64 `json:"packets"`
+}
+
+// socketRxStats 返回各接收套接字的包数，单套接字时返回 nil；调用方需同时持有 h.Mu 与 h.sendMu
+func (h *StreamHub) socketRxStats() []SocketRxStat {
+	if len(h.readConns) == 0 {
+		return nil
+	}
+	list := make([]SocketRxStat, len(h.readConns))
+	for i, c := range h.readConns {
+		list[i] = SocketRxStat{Index: i, Local: c.LocalAddr().String()}
+		if i < len(h.socketRx) {
+			list[i].Packets = h.socketRx[i]
+		}
+	}
+	return list
+}
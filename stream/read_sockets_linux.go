@@ -0,0 +1,32 @@
+package stream
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// skfAdRandom 经典 BPF 辅助数据：SKF_AD_OFF + SKF_AD_RANDOM，读取得到一个随机数
+const skfAdRandom = 0x100000000 - 0x1000 + 56
+
+// spreadReusePort 为 SO_REUSEPORT 组挂载经典 BPF 程序（random % n），把同一条流的包随机分摊到各套接字；
+// 挂载在组内任一套接字上即对整组生效，返回值为组内套接字下标
+func spreadReusePort(conn *net.UDPConn, n int) error {
+	prog := []unix.SockFilter{
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: skfAdRandom},
+		{Code: unix.BPF_ALU | unix.BPF_MOD | unix.BPF_K, K: uint32(n)},
+		{Code: unix.BPF_RET | unix.BPF_A},
+	}
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		serr = unix.SetsockoptSockFprog(int(fd), unix.SOL_SOCKET, unix.SO_ATTACH_REUSEPORT_CBPF,
+			&unix.SockFprog{Len: uint16(len(prog)), Filter: &prog[0]})
+	}); err != nil {
+		return err
+	}
+	return serr
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package stream
+
+import (
+	"errors"
+	"net"
+)
+
+// spreadReusePort 非 Linux 平台不支持为 SO_REUSEPORT 组挂载分摊程序，由内核按流分配
+func spreadReusePort(conn *net.UDPConn, n int) error {
+	return errors.New("当前平台不支持")
+}
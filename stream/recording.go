@@ -0,0 +1,249 @@
+package stream
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+	"github.com/qist/tvgate/monitor"
+)
+
+// recording 一次按需录制：像普通客户端一样订阅 Hub，把收到的 TS 数据写入磁盘
+type recording struct {
+	id        string
+	channel   string
+	addr      string
+	hub       *StreamHub
+	startedAt time.Time
+	until     time.Time
+	stop      chan struct{}
+	stopOnce  sync.Once
+
+	dir      string
+	prefix   string
+	maxFile  int64
+	maxFiles int
+
+	mu    sync.Mutex // 保护以下字段
+	f     *os.File
+	file  string
+	files []string // 当前保留的文件，最旧的在前
+	seq   int
+	size  int64
+	bytes uint64
+}
+
+var (
+	recordings   = make(map[string]*recording)
+	recordingsMu sync.Mutex
+	recordingSeq uint64
+)
+
+func init() {
+	monitor.RegisterRecordingsFunc(RecordingStatuses)
+}
+
+// recordingLimits 读取录制目录、单次最长时间、单文件大小上限及保留文件数
+func recordingLimits() (dir string, maxDuration time.Duration, maxFile int64, maxFiles int) {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	rc := config.Cfg.Recording
+	return rc.Dir, rc.MaxDuration, rc.MaxFileMB * 1024 * 1024, rc.MaxFiles
+}
+
+// recordingPrefix 由频道路径或地址生成文件名前缀，只保留字母数字
+func recordingPrefix(channel, addr string) string {
+	name := channel
+	if name == "" {
+		name = addr
+	}
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '_'
+	}, strings.Trim(name, "/"))
+	if name == "" {
+		name = "stream"
+	}
+	return name
+}
+
+// StartRecording 订阅指定源并开始录制，duration 为 0 或超过 recording.max_duration 时按上限处理；
+// 录制占用一个 Hub 客户端名额，频道无人观看时 Hub 也会保持运行直到录制结束；origin 为 ifaces 的来源（见 ResolveIfaces）
+func StartRecording(channel, addr string, ifaces []string, origin HubIfaceOrigin, localAddr string, duration time.Duration) (string, error) {
+	dir, maxDuration, maxFile, maxFiles := recordingLimits()
+	if duration <= 0 || duration > maxDuration {
+		duration = maxDuration
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建录制目录失败: %v", err)
+	}
+
+	h, err := GetOrCreateHubWithOrigin(addr, ifaces, localAddr, origin)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	recordingsMu.Lock()
+	recordingSeq++
+	rec := &recording{
+		id:        fmt.Sprintf("rec%d", recordingSeq),
+		channel:   channel,
+		addr:      addr,
+		hub:       h,
+		startedAt: now,
+		until:     now.Add(duration),
+		stop:      make(chan struct{}),
+		dir:       dir,
+		prefix:    recordingPrefix(channel, addr) + "_" + now.Format("20060102-150405"),
+		maxFile:   maxFile,
+		maxFiles:  maxFiles,
+	}
+	recordingsMu.Unlock()
+
+	ch := make(chan []byte, defaultClientBuffer)
+	if err := h.subscribe(ch, getStreamTimeouts().subscribe); err != nil {
+		return "", err
+	}
+
+	recordingsMu.Lock()
+	recordings[rec.id] = rec
+	recordingsMu.Unlock()
+
+	logger.LogPrintf("⏺ 开始录制 %s (%s)，时长 %v，目录 %s", rec.id, addr, duration, dir)
+	go rec.run(ch)
+	return rec.id, nil
+}
+
+// StopRecording 停止指定录制，返回是否找到
+func StopRecording(id string) bool {
+	recordingsMu.Lock()
+	rec, ok := recordings[id]
+	recordingsMu.Unlock()
+	if ok {
+		rec.stopOnce.Do(func() { close(rec.stop) })
+	}
+	return ok
+}
+
+// RecordingStatuses 返回正在进行的录制，按开始时间排序
+func RecordingStatuses() []monitor.RecordingStatus {
+	recordingsMu.Lock()
+	recs := make([]*recording, 0, len(recordings))
+	for _, rec := range recordings {
+		recs = append(recs, rec)
+	}
+	recordingsMu.Unlock()
+
+	out := make([]monitor.RecordingStatus, 0, len(recs))
+	for _, rec := range recs {
+		rec.mu.Lock()
+		out = append(out, monitor.RecordingStatus{
+			ID:        rec.id,
+			Channel:   rec.channel,
+			Addr:      rec.addr,
+			File:      rec.file,
+			Files:     rec.seq,
+			Bytes:     rec.bytes,
+			StartedAt: rec.startedAt,
+			Until:     rec.until,
+		})
+		rec.mu.Unlock()
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out
+}
+
+// run 消费订阅通道直到到期、被停止、Hub 关闭或写入失败
+func (rec *recording) run(ch chan []byte) {
+	timer := time.NewTimer(time.Until(rec.until))
+	defer timer.Stop()
+
+	reason := "到期"
+	hubClosed := false
+loop:
+	for {
+		select {
+		case data, ok := <-ch:
+			if !ok {
+				reason = "Hub 已关闭"
+				hubClosed = true
+				break loop
+			}
+			if err := rec.write(data); err != nil {
+				reason = "写入失败: " + err.Error()
+				break loop
+			}
+		case <-rec.stop:
+			reason = "手动停止"
+			break loop
+		case <-timer.C:
+			break loop
+		}
+	}
+
+	if !hubClosed {
+		rec.hub.unsubscribe(ch, getStreamTimeouts().subscribe)
+	}
+
+	recordingsMu.Lock()
+	delete(recordings, rec.id)
+	recordingsMu.Unlock()
+
+	rec.mu.Lock()
+	if rec.f != nil {
+		rec.f.Close()
+		rec.f = nil
+	}
+	files, bytes := rec.seq, rec.bytes
+	rec.mu.Unlock()
+	logger.LogPrintf("⏹ 录制 %s 结束（%s），共 %d 个文件 %d 字节", rec.id, reason, files, bytes)
+}
+
+// write 写入一帧，当前文件超过大小上限时切换到新文件
+func (rec *recording) write(data []byte) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.f == nil || (rec.size > 0 && rec.size+int64(len(data)) > rec.maxFile) {
+		if err := rec.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := rec.f.Write(data)
+	rec.size += int64(n)
+	rec.bytes += uint64(n)
+	return err
+}
+
+// rotate 关闭当前文件并创建下一个分段，超过保留数量时删除最旧的分段；调用方持有 rec.mu
+func (rec *recording) rotate() error {
+	if rec.f != nil {
+		rec.f.Close()
+		rec.f = nil
+	}
+	rec.seq++
+	name := filepath.Join(rec.dir, fmt.Sprintf("%s_%03d.ts", rec.prefix, rec.seq))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	rec.f, rec.file, rec.size = f, name, 0
+	rec.files = append(rec.files, name)
+
+	for rec.maxFiles > 0 && len(rec.files) > rec.maxFiles {
+		if err := os.Remove(rec.files[0]); err != nil && !os.IsNotExist(err) {
+			logger.LogPrintf("⚠️ 删除旧录制文件 %s 失败: %v", rec.files[0], err)
+		}
+		rec.files = rec.files[1:]
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package stream
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+)
+
+// reloadGraceUntil 配置重载宽限期的截止时间 (UnixNano)，0 表示不在宽限期内
+var reloadGraceUntil atomic.Int64
+
+// BeginReloadGrace 在配置重载时调用：stream.reload_grace 时间内没有客户端的 Hub 不会立即关闭，
+// 避免重载期间客户端短暂断开重连导致组播反复退出/加入
+func BeginReloadGrace() {
+	config.CfgMu.RLock()
+	grace := config.Cfg.Stream.ReloadGrace
+	config.CfgMu.RUnlock()
+	if grace <= 0 {
+		return
+	}
+	until := time.Now().Add(grace).UnixNano()
+	for {
+		cur := reloadGraceUntil.Load()
+		if cur >= until || reloadGraceUntil.CompareAndSwap(cur, until) {
+			break
+		}
+	}
+	logger.LogPrintf("⏳ 配置重载宽限期 %v：期间无客户端的 Hub 暂不关闭", grace)
+}
+
+// reloadGraceRemaining 返回重载宽限期剩余时间，不在宽限期内返回 0
+func reloadGraceRemaining() time.Duration {
+	until := reloadGraceUntil.Load()
+	if until == 0 {
+		return 0
+	}
+	if d := time.Until(time.Unix(0, until)); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// closeIfIdle 没有客户端时关闭 Hub，返回 true 表示已关闭；处于重载宽限期内时保留 Hub，
+// 宽限期结束后再检查一次，届时仍没有客户端才关闭
+func (h *StreamHub) closeIfIdle() bool {
+	wait := reloadGraceRemaining()
+	if wait <= 0 {
+		h.Close()
+		return true
+	}
+	if h.graceCheck.CompareAndSwap(false, true) {
+		logger.LogPrintf("⏳ 重载宽限期内保留无客户端的 Hub %s，%v 后再检查", h.addr, wait.Round(time.Millisecond))
+		time.AfterFunc(wait, func() {
+			h.graceCheck.Store(false)
+			h.Mu.Lock()
+			idle := h.Clients != nil && len(h.Clients) == 0 && len(h.silent) == 0
+			h.Mu.Unlock()
+			if idle && h.closeIfIdle() {
+				logger.LogPrintf("⏹ 重载宽限期结束仍无客户端，关闭 Hub: %s", h.addr)
+			}
+		})
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package stream
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+const (
+	maxRTPReorderDepth       = 1024
+	defaultRTPReorderTimeout = 50 * time.Millisecond
+	// rtpResyncGap 序号跳变超过该值视为源重启或切换，清空缓冲并重新同步，而不是把后续包都当作迟到丢弃
+	rtpResyncGap = 3000
+)
+
+// rtpReorderConfig 读取 RTP 重排缓冲参数，depth 为 0 表示关闭
+func rtpReorderConfig() (int, time.Duration) {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	depth := config.Cfg.Stream.RTPReorderDepth
+	if depth > maxRTPReorderDepth {
+		depth = maxRTPReorderDepth
+	}
+	timeout := config.Cfg.Stream.RTPReorderTimeout
+	if timeout <= 0 {
+		timeout = defaultRTPReorderTimeout
+	}
+	return depth, timeout
+}
+
+// rtpSeq 返回 RTP 包的序列号，非 RTP 包（如以 0x47 开头的裸 TS）返回 false
+func rtpSeq(p []byte) (uint16, bool) {
+	if len(p) < 12 || p[0]>>6 != 2 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint16(p[2:4]), true
+}
+
+type reorderEntry struct {
+	data []byte
+	at   time.Time
+}
+
+// rtpReorder 按 RTP 序列号重排的有界缓冲：按序输出，缺口等待超过 timeout 或缓冲超过 depth 时跳过，
+// 已经越过的序号视为迟到丢弃；序号比较按 16 位回绕处理。所有字段受 StreamHub.Mu 保护
+type rtpReorder struct {
+	depth   int
+	timeout time.Duration
+	started bool
+	next    uint16 // 下一个应输出的序号
+	pending map[uint16]reorderEntry
+
+	reordered uint64 // 乱序到达后被重排输出的包
+	late      uint64 // 迟到（序号已越过）被丢弃的包
+	lost      uint64 // 等待超时被跳过的缺口包数
+}
+
+func newRTPReorder(depth int, timeout time.Duration) *rtpReorder {
+	return &rtpReorder{
+		depth:   depth,
+		timeout: timeout,
+		pending: make(map[uint16]reorderEntry, depth),
+	}
+}
+
+// reset 清空缓冲并在下一个包重新同步
+func (r *rtpReorder) reset() {
+	r.started = false
+	clear(r.pending)
+}
+
+// push 放入一个包，返回可以按序输出的包
+func (r *rtpReorder) push(data []byte, seq uint16, now time.Time) [][]byte {
+	if !r.started {
+		r.started, r.next = true, seq
+	}
+	var out [][]byte
+	d := int16(seq - r.next)
+	switch {
+	case d < 0 && -int(d) <= rtpResyncGap:
+		r.late++
+		return r.expire(now, nil)
+	case d < 0 || int(d) > rtpResyncGap:
+		out = r.flush(out)
+		r.next, d = seq, 0
+	}
+
+	if d == 0 {
+		out = append(out, data)
+		r.next++
+		return r.expire(now, r.drain(out))
+	}
+	if _, dup := r.pending[seq]; !dup {
+		r.pending[seq] = reorderEntry{data: data, at: now}
+	}
+	if len(r.pending) > r.depth {
+		out = r.skipGap(out)
+	}
+	return r.expire(now, out)
+}
+
+// expire 缺口后的第一个缓存包等待超过 timeout 时跳过缺口
+func (r *rtpReorder) expire(now time.Time, out [][]byte) [][]byte {
+	for len(r.pending) > 0 {
+		seq, _ := r.earliest()
+		if now.Sub(r.pending[seq].at) < r.timeout {
+			break
+		}
+		out = r.skipGap(out)
+	}
+	return out
+}
+
+// flush 按序输出全部缓存包
+func (r *rtpReorder) flush(out [][]byte) [][]byte {
+	for len(r.pending) > 0 {
+		out = r.skipGap(out)
+	}
+	return out
+}
+
+// earliest 返回缓存中序号最靠前的包及其与 next 的距离
+func (r *rtpReorder) earliest() (uint16, int) {
+	best, bestDist := uint16(0), -1
+	for seq := range r.pending {
+		dist := int(uint16(seq - r.next))
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = seq, dist
+		}
+	}
+	return best, bestDist
+}
+
+// skipGap 跳过 next 到最早缓存包之间的缺口并输出随后连续的包
+func (r *rtpReorder) skipGap(out [][]byte) [][]byte {
+	seq, dist := r.earliest()
+	if dist < 0 {
+		return out
+	}
+	r.lost += uint64(dist)
+	r.next = seq
+	return r.drain(out)
+}
+
+// drain 输出从 next 开始连续的缓存包
+func (r *rtpReorder) drain(out [][]byte) [][]byte {
+	for {
+		e, ok := r.pending[r.next]
+		if !ok {
+			return out
+		}
+		delete(r.pending, r.next)
+		out = append(out, e.data)
+		r.reordered++
+		r.next++
+	}
+}
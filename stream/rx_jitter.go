@@ -0,0 +1,67 @@
+package stream
+
+import (
+	"time"
+)
+
+// 抖动统计窗口，监控展示最近一个完整窗口的结果
+const rxJitterWindow = 10 * time.Second
+
+// rxJitterStats 基于内核接收时间戳的包到达间隔抖动统计（|本次间隔 - 上次间隔|）；
+// 调用方需持有 StreamHub.Mu
+type rxJitterStats struct {
+	prevRx    time.Time
+	prevDelta time.Duration
+
+	windowStart   time.Time
+	min, max, sum time.Duration
+	count         int
+
+	// 最近一个完整窗口的结果
+	valid                     bool
+	lastMin, lastAvg, lastMax time.Duration
+}
+
+// reset 源套接字变更后丢弃之前的到达时间
+func (s *rxJitterStats) reset() {
+	s.prevRx = time.Time{}
+	s.prevDelta = 0
+}
+
+func (s *rxJitterStats) add(rx time.Time) {
+	if s.prevRx.IsZero() {
+		s.prevRx = rx
+		return
+	}
+	delta := rx.Sub(s.prevRx)
+	s.prevRx = rx
+	if s.prevDelta == 0 {
+		s.prevDelta = delta
+		return
+	}
+	jitter := delta - s.prevDelta
+	if jitter < 0 {
+		jitter = -jitter
+	}
+	s.prevDelta = delta
+
+	if s.windowStart.IsZero() {
+		s.windowStart = rx
+	}
+	if s.count == 0 || jitter < s.min {
+		s.min = jitter
+	}
+	if jitter > s.max {
+		s.max = jitter
+	}
+	s.sum += jitter
+	s.count++
+
+	if rx.Sub(s.windowStart) >= rxJitterWindow {
+		s.valid = true
+		s.lastMin, s.lastMax = s.min, s.max
+		s.lastAvg = s.sum / time.Duration(s.count)
+		s.windowStart = rx
+		s.min, s.max, s.sum, s.count = 0, 0, 0, 0
+	}
+}
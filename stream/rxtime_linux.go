@@ -0,0 +1,45 @@
+package stream
+
+import (
+	"net"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// rxTimestampOOBSize 接收 SCM_TIMESTAMPNS 控制消息所需的缓冲区大小
+var rxTimestampOOBSize = syscall.CmsgSpace(int(unsafe.Sizeof(syscall.Timespec{})))
+
+// enableRxTimestamps 在套接字上开启 SO_TIMESTAMPNS，由内核为每个数据包记录接收时间
+func enableRxTimestamps(conn *net.UDPConn) bool {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		serr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_TIMESTAMPNS, 1)
+	}); err != nil {
+		return false
+	}
+	return serr == nil
+}
+
+// parseRxTimestamp 从控制消息中解析内核接收时间
+func parseRxTimestamp(oob []byte) (time.Time, bool) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return time.Time{}, false
+	}
+	for _, m := range msgs {
+		if m.Header.Level != syscall.SOL_SOCKET || m.Header.Type != syscall.SCM_TIMESTAMPNS {
+			continue
+		}
+		if len(m.Data) < int(unsafe.Sizeof(syscall.Timespec{})) {
+			continue
+		}
+		ts := (*syscall.Timespec)(unsafe.Pointer(&m.Data[0]))
+		return time.Unix(ts.Unix()), true
+	}
+	return time.Time{}, false
+}
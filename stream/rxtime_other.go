@@ -0,0 +1,15 @@
+//go:build !linux
+
+package stream
+
+import (
+	"net"
+	"time"
+)
+
+// 非 Linux 平台不支持 SO_TIMESTAMPNS，不采集接收抖动
+var rxTimestampOOBSize = 0
+
+func enableRxTimestamps(conn *net.UDPConn) bool { return false }
+
+func parseRxTimestamp(oob []byte) (time.Time, bool) { return time.Time{}, false }
@@ -0,0 +1,58 @@
+package stream
+
+// SubscribeSilent 以静默订阅者身份接收 Hub 数据，用于健康探测等内部用途：
+// 不计入 Clients（观看人数），也不阻止最后一个真实客户端离开时关闭 Hub；通道满时直接丢帧。
+// Hub 关闭时返回的通道被关闭；cancel 退订，若此时已无真实客户端则关闭 Hub
+func (h *StreamHub) SubscribeSilent(buf int) (<-chan []byte, func()) {
+	ch := make(chan []byte, buf)
+
+	h.Mu.Lock()
+	select {
+	case <-h.Closed:
+		h.Mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	default:
+	}
+	h.sendMu.Lock()
+	if h.silent == nil {
+		h.silent = make(map[chan []byte]struct{})
+	}
+	h.silent[ch] = struct{}{}
+	h.sendMu.Unlock()
+	h.Mu.Unlock()
+
+	cancel := func() {
+		h.Mu.Lock()
+		h.sendMu.Lock()
+		if _, ok := h.silent[ch]; ok {
+			delete(h.silent, ch)
+			close(ch)
+		}
+		h.sendMu.Unlock()
+		idle := h.Clients != nil && len(h.Clients) == 0
+		h.Mu.Unlock()
+		if idle {
+			h.closeIfIdle()
+		}
+	}
+	return ch, cancel
+}
+
+// deliverSilentLocked 向静默订阅者投递一帧，通道满时丢弃；调用方需持有 h.sendMu
+func (h *StreamHub) deliverSilentLocked(data []byte) {
+	for ch := range h.silent {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// closeSilentLocked 关闭全部静默订阅者通道；调用方需同时持有 h.Mu 与 h.sendMu
+func (h *StreamHub) closeSilentLocked() {
+	for ch := range h.silent {
+		close(ch)
+	}
+	h.silent = nil
+}
@@ -0,0 +1,178 @@
+package stream
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+)
+
+const (
+	slateChunkPackets      = 7 // 每次发送 7 个 TS 包（1316 字节），与常见 UDP 负载一致
+	slateTick              = 20 * time.Millisecond
+	slateIdleCheck         = 500 * time.Millisecond
+	defaultSlateAfter      = 5 * time.Second
+	defaultSlateBitrateKbs = 2000
+)
+
+// slateSettings 源中断垫片配置
+type slateSettings struct {
+	file    string
+	after   time.Duration
+	bitrate int // kbps
+}
+
+func slateConfig() slateSettings {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	s := slateSettings{
+		file:    config.Cfg.Stream.SlateFile,
+		after:   config.Cfg.Stream.SlateAfter,
+		bitrate: config.Cfg.Stream.SlateBitrate,
+	}
+	if s.after <= 0 {
+		s.after = defaultSlateAfter
+	}
+	if s.bitrate <= 0 {
+		s.bitrate = defaultSlateBitrateKbs
+	}
+	return s
+}
+
+// slateState 单个 Hub 的垫片播放状态，受 h.Mu 保护
+type slateState struct {
+	cfg    slateSettings
+	active bool
+	since  time.Time     // 本次开始播放垫片的时间
+	total  time.Duration // 已结束的垫片播放累计时长
+	count  uint64        // 进入垫片的次数
+}
+
+// elapsed 返回累计垫片时长（含正在播放的一段）
+func (s *slateState) elapsed(now time.Time) time.Duration {
+	if s.active {
+		return s.total + now.Sub(s.since)
+	}
+	return s.total
+}
+
+var slateCache struct {
+	sync.Mutex
+	path    string
+	modTime time.Time
+	chunks  [][]byte
+}
+
+// loadSlate 读取垫片 TS 文件并按 slateChunkPackets 切块；按路径与修改时间缓存，文件更新后自动重新加载
+func loadSlate(path string) ([][]byte, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	slateCache.Lock()
+	defer slateCache.Unlock()
+	if slateCache.path == path && slateCache.modTime.Equal(fi.ModTime()) {
+		return slateCache.chunks, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	const packetSize = 188
+	data = data[:len(data)-len(data)%packetSize]
+	if len(data) == 0 || data[0] != 0x47 {
+		return nil, fmt.Errorf("%s 不是有效的 MPEG-TS 文件", path)
+	}
+	var chunks [][]byte
+	for off := 0; off < len(data); off += slateChunkPackets * packetSize {
+		end := off + slateChunkPackets*packetSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunks = append(chunks, data[off:end])
+	}
+	slateCache.path, slateCache.modTime, slateCache.chunks = path, fi.ModTime(), chunks
+	logger.LogPrintf("🎬 已加载源中断垫片 %s (%d 字节)", path, len(data))
+	return chunks, nil
+}
+
+// slateLoop 源超过 slate_after 无数据且有客户端时循环广播垫片，源恢复后自动切回
+// 垫片只发给客户端，不更新 LastFrame 与热切换缓存，也不计入源入流统计
+func (h *StreamHub) slateLoop() {
+	h.Mu.Lock()
+	cfg := h.slate.cfg
+	h.Mu.Unlock()
+
+	start := time.Now()
+	var (
+		chunks [][]byte
+		next   int
+		credit float64
+		lastAt time.Time
+	)
+	timer := time.NewTimer(slateIdleCheck)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-h.Closed:
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		h.Mu.Lock()
+		h.sendMu.Lock()
+		last := h.lastPacketAt
+		if last.Before(start) {
+			last = start
+		}
+		want := now.Sub(last) >= cfg.after && len(h.Clients) > 0
+		s := h.slate
+		switch {
+		case want && !s.active:
+			var err error
+			if chunks, err = loadSlate(cfg.file); err != nil {
+				h.sendMu.Unlock()
+				h.Mu.Unlock()
+				logger.LogPrintf("⚠️ 加载源中断垫片失败: %v", err)
+				timer.Reset(slateIdleCheck * 10)
+				continue
+			}
+			s.active, s.since, s.count = true, now, s.count+1
+			next, credit, lastAt = 0, 0, now
+			h.recordSwitch(h.addr, "垫片", fmt.Sprintf("源无数据 %s", now.Sub(last).Truncate(time.Second)))
+		case !want && s.active:
+			s.active = false
+			s.total += now.Sub(s.since)
+			reason := "源恢复"
+			if len(h.Clients) == 0 {
+				reason = "无客户端"
+			}
+			h.recordSwitch("垫片", h.addr, reason)
+		}
+
+		if !s.active {
+			h.sendMu.Unlock()
+			h.Mu.Unlock()
+			timer.Reset(slateIdleCheck)
+			continue
+		}
+		// 按配置码率累积发送额度，循环播放垫片
+		credit += now.Sub(lastAt).Seconds() * float64(cfg.bitrate) * 1000 / 8
+		lastAt = now
+		for credit > 0 {
+			chunk := chunks[next]
+			h.broadcast(chunk)
+			credit -= float64(len(chunk))
+			next = (next + 1) % len(chunks)
+		}
+		h.sendMu.Unlock()
+		h.Mu.Unlock()
+		timer.Reset(slateTick)
+	}
+}
@@ -0,0 +1,157 @@
+package stream
+
+import (
+	"net"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// 客户端通道已满时的广播策略
+const (
+	FullPolicyDropNewest = "drop-newest"         // 丢弃当前帧（默认，延迟最低）
+	FullPolicyDropOldest = "drop-oldest"         // 丢弃通道中最旧的帧再写入，优先最新画面
+	FullPolicyBlock      = "block-with-deadline" // 限时等待客户端消费，连续性更好但会拖慢同 Hub 其他客户端
+	FullPolicyDisconnect = "disconnect"          // 断开跟不上的客户端
+)
+
+// fullChannelPolicy 读取客户端通道已满时的策略及阻塞等待上限
+func fullChannelPolicy() (string, time.Duration) {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	switch p := config.Cfg.Stream.FullChannelPolicy; p {
+	case FullPolicyDropOldest, FullPolicyBlock, FullPolicyDisconnect:
+		return p, config.Cfg.Stream.BlockTimeout
+	default:
+		return FullPolicyDropNewest, config.Cfg.Stream.BlockTimeout
+	}
+}
+
+// streamTimeouts 流转发使用的超时参数（默认值与校验见 config.SetDefaults）
+type streamTimeouts struct {
+	write        time.Duration
+	idle         time.Duration
+	initialData  time.Duration // 0 表示不单独限制首帧等待
+	errorBackoff time.Duration
+	subscribe    time.Duration
+}
+
+// getStreamTimeouts 读取流转发超时参数，未加载配置时使用默认值
+func getStreamTimeouts() streamTimeouts {
+	config.CfgMu.RLock()
+	t := streamTimeouts{
+		write:        config.Cfg.Stream.WriteTimeout,
+		idle:         config.Cfg.Stream.IdleTimeout,
+		initialData:  config.Cfg.Stream.InitialDataTimeout,
+		errorBackoff: config.Cfg.Stream.ErrorBackoff,
+		subscribe:    config.Cfg.Stream.SubscribeTimeout,
+	}
+	config.CfgMu.RUnlock()
+
+	if t.write <= 0 {
+		t.write = 5 * time.Second
+	}
+	if t.idle <= 0 {
+		t.idle = 30 * time.Second
+	}
+	if t.errorBackoff <= 0 {
+		t.errorBackoff = 100 * time.Millisecond
+	}
+	if t.subscribe <= 0 {
+		t.subscribe = 2 * time.Second
+	}
+	return t
+}
+
+// writeCoalesce 客户端写合并参数，size 为 0 表示逐帧写入
+type writeCoalesce struct {
+	size     int
+	interval time.Duration
+}
+
+// getWriteCoalesce 读取写合并参数：缓冲达到 size 字节或首帧入缓冲后经过 interval 即写出
+func getWriteCoalesce() writeCoalesce {
+	config.CfgMu.RLock()
+	c := writeCoalesce{
+		size:     config.Cfg.Stream.WriteBufferSize,
+		interval: config.Cfg.Stream.FlushInterval,
+	}
+	config.CfgMu.RUnlock()
+
+	if c.size <= 0 {
+		return writeCoalesce{}
+	}
+	if c.size > 1<<20 {
+		c.size = 1 << 20
+	}
+	if c.interval <= 0 {
+		c.interval = 20 * time.Millisecond
+	}
+	return c
+}
+
+// keepaliveInterval 读取保活空包发送间隔，0 表示关闭
+func keepaliveInterval() time.Duration {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	return config.Cfg.Stream.KeepaliveInterval
+}
+
+// readDeadline 读取 UDP 读超时间隔（未配置时为默认的 5s），非正数表示不设置读超时
+func readDeadline() time.Duration {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	return config.Cfg.Stream.ReadDeadline
+}
+
+// logClientChurn 是否记录客户端加入/离开日志
+func logClientChurn() bool {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	return config.Cfg.Stream.LogClientChurn
+}
+
+// broadcastWorkers 读取每个 Hub 的广播工作协程数，0 表示串行广播
+func broadcastWorkers() int {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	n := config.Cfg.Stream.BroadcastWorkers
+	if n > 64 {
+		n = 64
+	}
+	return n
+}
+
+// psiReplayEnabled 是否为新客户端先发送缓存的 PAT/PMT
+func psiReplayEnabled() bool {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	return config.Cfg.Stream.PSIReplay
+}
+
+// strictIfaces 配置的网卡全部无法解析时是否拒绝创建组播 Hub（而不是回退为普通 UDP 监听）
+func strictIfaces() bool {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	return config.Cfg.Stream.StrictIfaces
+}
+
+// ifacesUnresolved 网卡列表非空且其中每个名称都无法解析
+func ifacesUnresolved(ifaces []string) bool {
+	if len(ifaces) == 0 {
+		return false
+	}
+	for _, name := range ifaces {
+		if _, err := net.InterfaceByName(name); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// jitterBufferFrames 读取每个 Hub 的抖动缓冲帧数，0 表示关闭
+func jitterBufferFrames() int {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+	return config.Cfg.Stream.JitterBufferFrames
+}
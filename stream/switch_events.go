@@ -0,0 +1,39 @@
+package stream
+
+import (
+	"strings"
+	"time"
+
+	"github.com/qist/tvgate/logger"
+	"github.com/qist/tvgate/monitor"
+)
+
+// maxSwitchEvents 每个 Hub 保留的源切换记录上限
+const maxSwitchEvents = 20
+
+// describeSource 生成源的可读描述：地址@网卡 或 地址@本地地址
+func describeSource(addr string, ifaces []string, localAddr string) string {
+	switch {
+	case localAddr != "":
+		return addr + "@" + localAddr
+	case len(ifaces) > 0:
+		return addr + "@" + strings.Join(ifaces, ",")
+	default:
+		return addr + "@默认"
+	}
+}
+
+// recordSwitch 记录一次源切换，调用方需持有 h.Mu
+func (h *StreamHub) recordSwitch(from, to, reason string) {
+	// 超出上限时丢弃最旧的记录
+	if n := len(h.switchEvents) - (maxSwitchEvents - 1); n > 0 {
+		h.switchEvents = append(h.switchEvents[:0], h.switchEvents[n:]...)
+	}
+	h.switchEvents = append(h.switchEvents, monitor.SourceSwitchEvent{
+		Time:   time.Now(),
+		From:   from,
+		To:     to,
+		Reason: reason,
+	})
+	logger.LogPrintf("🔀 Hub 源切换: %s → %s (%s)", from, to, reason)
+}
@@ -0,0 +1,245 @@
+package stream
+
+import (
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+	"github.com/qist/tvgate/monitor"
+)
+
+// tcpOutput 一个裸 TCP 输出监听
+type tcpOutput struct {
+	cfg config.TCPOutputConfig
+	ln  net.Listener
+}
+
+var (
+	tcpOutputs   = make(map[string]*tcpOutput) // 监听地址 → 输出
+	tcpOutputsMu sync.Mutex
+)
+
+// StartTCPOutputs 按配置启动/更新裸 TCP 输出监听：关闭已删除或变更的监听，启动新增的监听；
+// 启动时及配置重新加载后调用，已建立的连接不受影响
+func StartTCPOutputs() {
+	want := make(map[string]config.TCPOutputConfig)
+	config.CfgMu.RLock()
+	for _, o := range config.Cfg.TCPOutputs {
+		if o == nil || o.Listen == "" || (o.Channel == "" && o.UDPAddr == "") {
+			continue
+		}
+		c := *o
+		c.Ifaces = append([]string(nil), o.Ifaces...)
+		want[c.Listen] = c
+	}
+	config.CfgMu.RUnlock()
+
+	tcpOutputsMu.Lock()
+	defer tcpOutputsMu.Unlock()
+
+	for listen, out := range tcpOutputs {
+		if c, ok := want[listen]; ok && reflect.DeepEqual(c, out.cfg) {
+			continue
+		}
+		out.ln.Close()
+		delete(tcpOutputs, listen)
+		logger.LogPrintf("🛑 TCP 输出 %s 已停止", listen)
+	}
+	for listen, c := range want {
+		if _, ok := tcpOutputs[listen]; ok {
+			continue
+		}
+		ln, err := net.Listen("tcp", listen)
+		if err != nil {
+			logger.LogPrintf("❌ TCP 输出监听 %s 失败: %v", listen, err)
+			continue
+		}
+		out := &tcpOutput{cfg: c, ln: ln}
+		tcpOutputs[listen] = out
+		go out.serve()
+		logger.LogPrintf("🟢 TCP 输出 %s → %s%s", listen, c.Channel, c.UDPAddr)
+	}
+}
+
+func (o *tcpOutput) serve() {
+	for {
+		conn, err := o.ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			logger.LogPrintf("⚠️ TCP 输出 %s 接受连接失败: %v", o.cfg.Listen, err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go o.handle(conn)
+	}
+}
+
+// errTCPOutputProtected 源受频道令牌或签名链接保护，而裸 TCP 客户端无法携带凭据
+var errTCPOutputProtected = errors.New("源受访问令牌或签名链接保护，TCP 输出未设置 public")
+
+// resolve 在连接时解析源：频道路径优先（使用频道的最新配置），再应用全局网卡默认值；
+// 源需要凭据（频道令牌或 signed_url）而输出未设置 public 时拒绝
+func (o *tcpOutput) resolve() (channel, addr string, ifaces []string, origin HubIfaceOrigin, localAddr string, err error) {
+	config.CfgMu.RLock()
+	defer config.CfgMu.RUnlock()
+
+	addr, ifaces, localAddr = o.cfg.UDPAddr, o.cfg.Ifaces, o.cfg.LocalAddr
+	protected := config.Cfg.SignedURL.Secret != ""
+	ifacesChannel := ""
+	if o.cfg.Channel != "" {
+		addr = ""
+		for _, ch := range config.Cfg.Channels {
+			if ch != nil && ch.UDPAddr != "" && strings.TrimSuffix(ch.Path, "/") == strings.TrimSuffix(o.cfg.Channel, "/") {
+				channel, addr = ch.Path, ch.SourceAddr()
+				ifaces, localAddr, ifacesChannel = ch.Ifaces, ch.LocalAddr, ch.Path
+				protected = protected || ch.Token != ""
+				break
+			}
+		}
+	}
+	if addr == "" {
+		return "", "", nil, HubIfaceOrigin{}, "", errors.New("未找到频道 " + o.cfg.Channel)
+	}
+	if protected = protected || config.Cfg.TokenChannelForSource(addr) != nil; protected && !o.cfg.Public {
+		return "", "", nil, HubIfaceOrigin{}, "", errTCPOutputProtected
+	}
+	if channel == "" {
+		channel = addr
+	}
+	ifaces, origin = ResolveIfaces(ifacesChannel, ifaces)
+	if localAddr == "" {
+		localAddr = config.Cfg.Server.MulticastLocalAddr
+	}
+	return channel, addr, ifaces, origin, localAddr, nil
+}
+
+// handle 向一个 TCP 客户端推送 TS 数据，直到客户端断开、Hub 关闭或写入超时
+func (o *tcpOutput) handle(conn net.Conn) {
+	defer conn.Close()
+
+	clientIP, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		clientIP = conn.RemoteAddr().String()
+	}
+	channel, addr, ifaces, origin, localAddr, err := o.resolve()
+	if err != nil {
+		logger.LogPrintf("⚠️ TCP 输出 %s 拒绝客户端 %s: %v", o.cfg.Listen, clientIP, err)
+		return
+	}
+
+	if OverBandwidthCap() {
+		logger.LogPrintf("🚫 出口带宽已达上限，拒绝 TCP 客户端 %s 访问 %s", clientIP, addr)
+		return
+	}
+	if OverMemoryLimit() {
+		return
+	}
+	config.CfgMu.RLock()
+	maxPerIP := config.Cfg.Stream.MaxConnsPerIP
+	config.CfgMu.RUnlock()
+	releaseIP, ok := monitor.AcquireIPConn(clientIP, maxPerIP)
+	if !ok {
+		logger.LogPrintf("🚫 客户端 %s 连接数已达上限 %d，拒绝 TCP 访问 %s", clientIP, maxPerIP, addr)
+		return
+	}
+	defer releaseIP()
+
+	hub, err := GetOrCreateHubWithOrigin(addr, ifaces, localAddr, origin)
+	if err != nil {
+		logger.LogPrintf("❌ TCP 客户端 %s 加入 %s 失败: %v", clientIP, addr, err)
+		return
+	}
+
+	connID := clientIP + "_tcp_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	hubKey := HubKey(addr, ifaces, localAddr)
+	connectedAt := time.Now()
+	monitor.ActiveClients.Register(connID, &monitor.ClientConnection{
+		IP:             clientIP,
+		URL:            "tcp://" + o.cfg.Listen,
+		ConnectionType: "TCP",
+		HubKey:         hubKey,
+		Channel:        channel,
+		ConnectedAt:    connectedAt,
+		LastActive:     connectedAt,
+	})
+	defer monitor.ActiveClients.Unregister(connID, "TCP")
+
+	timeouts := getStreamTimeouts()
+	settings := hub.settings()
+	ch := make(chan []byte, settings.ClientBuffer)
+	if err := hub.subscribe(ch, timeouts.subscribe); err != nil {
+		return
+	}
+	defer hub.unsubscribe(ch, timeouts.subscribe)
+
+	if tc, ok := conn.(*net.TCPConn); ok {
+		_ = tc.SetKeepAlive(true)
+		_ = tc.SetKeepAlivePeriod(30 * time.Second)
+	}
+
+	// 机顶盒只接收不发送：读到 EOF/错误即表示客户端已断开，无需等到下次写入失败
+	gone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, conn)
+		close(gone)
+	}()
+
+	var (
+		sent       uint64
+		lastActive time.Time
+		reason     = "客户端断开"
+	)
+	// 整个连接复用一个空闲计时器，每帧 Reset 而不是新建（go 1.23 起 Reset 无需先排空通道）
+	idle := time.NewTimer(settings.IdleTimeout)
+	defer idle.Stop()
+loop:
+	for {
+		select {
+		case data, ok := <-ch:
+			if !ok {
+				reason = "Hub 已关闭"
+				break loop
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(timeouts.write))
+			n, err := conn.Write(data)
+			sent += uint64(n)
+			if err != nil {
+				reason = "写入失败: " + err.Error()
+				break loop
+			}
+			if now := time.Now(); now.Sub(lastActive) >= time.Second {
+				lastActive = now
+				monitor.ActiveClients.UpdateLastActive(connID, now)
+			}
+			idle.Reset(settings.IdleTimeout)
+		case <-gone:
+			break loop
+		case <-idle.C:
+			reason = "空闲超时"
+			break loop
+		}
+	}
+
+	now := time.Now()
+	duration := now.Sub(connectedAt).Round(time.Second)
+	logger.LogPrintf("👋 TCP 客户端 %s 离开 %s（%s），时长 %v，发送 %s", clientIP, addr, reason, duration, monitor.FormatBytes(sent))
+	monitor.RecordSession(monitor.SessionRecord{
+		IP:             clientIP,
+		Channel:        channel,
+		HubKey:         hubKey,
+		ConnectionType: "TCP",
+		ConnectedAt:    connectedAt,
+		DisconnectedAt: now,
+		Duration:       duration,
+		Bytes:          sent,
+	})
+}
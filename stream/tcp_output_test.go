@@ -0,0 +1,35 @@
+package stream
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/qist/tvgate/config"
+)
+
+// 裸 TCP 无法携带凭据：受频道令牌保护的源只有在输出设置 public 时才放行
+func TestTCPOutputResolveProtected(t *testing.T) {
+	config.CfgMu.Lock()
+	saved := config.Cfg.Channels
+	config.Cfg.Channels = []*config.ChannelConfig{{Path: "/live/secret", UDPAddr: "239.9.9.9:5000", Token: "s3cret"}}
+	config.CfgMu.Unlock()
+	defer func() {
+		config.CfgMu.Lock()
+		config.Cfg.Channels = saved
+		config.CfgMu.Unlock()
+	}()
+
+	for _, cfg := range []config.TCPOutputConfig{
+		{Listen: ":0", Channel: "/live/secret"},
+		{Listen: ":0", UDPAddr: "239.9.9.9:5000"},
+	} {
+		o := &tcpOutput{cfg: cfg}
+		if _, _, _, _, _, err := o.resolve(); !errors.Is(err, errTCPOutputProtected) {
+			t.Errorf("%+v: err = %v, want errTCPOutputProtected", cfg, err)
+		}
+		o.cfg.Public = true
+		if _, addr, _, _, _, err := o.resolve(); err != nil || addr != "239.9.9.9:5000" {
+			t.Errorf("%+v public: addr = %q, err = %v", cfg, addr, err)
+		}
+	}
+}
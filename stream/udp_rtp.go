@@ -1,32 +1,92 @@
 package stream
 
 import (
-	"context"
 	"errors"
 	"fmt"
+	"github.com/qist/tvgate/config"
 	"github.com/qist/tvgate/logger"
-	// "github.com/qist/tvgate/monitor"
+	"github.com/qist/tvgate/monitor"
 	"io"
 	"net"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // StreamHub 管理 UDP/组播流的多客户端转发
 type StreamHub struct {
-	Mu          sync.Mutex
-	Clients     map[chan []byte]struct{}
+	Mu          hubMutex                 // 保护客户端集合、配置与状态字段；stream.lock_stats 开启时统计竞争
+	Clients     map[chan []byte]struct{} // 客户端集合，修改时需同时持有 Mu 与 sendMu，读取持有其一即可
 	AddCh       chan chan []byte
 	RemoveCh    chan chan []byte
 	UdpConn     *net.UDPConn
 	Closed      chan struct{}
 	BufPool     *sync.Pool
-	LastFrame   []byte
-	CacheBuffer [][]byte // 缓存最近的数据包，用于热切换
-	Format      string   // 流格式（如HLS、RTMP等）
-	addr        string   // 监听地址
+	lastFrame   atomic.Pointer[[]byte]   // 最近一帧，读取方无需加锁
+	CacheBuffer [][]byte                 // 缓存最近的数据包，用于热切换，受 sendMu 保护
+	Format      string                   // 流格式（如HLS、RTMP等）
+	IsMulticast bool                     // 是否以组播方式加入成功，false 表示回退为普通 UDP 监听（或单播模式）
+	Ifaces      []string                 // 监听网卡列表
+	LocalAddr   string                   // 指定的本地绑定 IP，为空表示按网卡选择
+	addr        string                   // 监听地址
+	sourceURL   string                   // HTTP 拉流源地址，非空时不监听 UDP
+	preview     string                   // 预览变体对应的频道路径（源地址为 preview:路径），非空时由 ffmpeg 转码完整流
+	unicast     bool                     // 显式单播监听模式（addr 带 unicast:// 前缀），不加入组播
+	pinned      bool                     // 高优先级频道：接收协程独占 OS 线程，创建时确定
+	primed      map[chan []byte]struct{} // 加入时已回放秒开缓存、尚未取走首帧的客户端，受 Mu 保护
+	ingestRate  rateEstimator            // 源入流码率估算，受 sendMu 保护
+	ingestBytes uint64                   // 本 Hub 累计接收字节数，关闭时并入频道累计，受 sendMu 保护
+	jitter      *jitterBuffer            // 抖动缓冲，nil 表示关闭，受 sendMu 保护
+	reorder     *rtpReorder              // RTP 序号重排缓冲，nil 表示关闭，受 sendMu 保护
+	psi         *psiCache                // PAT/PMT 缓存，nil 表示关闭，受 sendMu 保护
+	fanout      *fanoutPool              // 广播工作池，nil 表示串行广播
+	silent      map[chan []byte]struct{} // 静默订阅者（探测等），不计入 Clients，修改规则同 Clients
+
+	// sendMu 数据路径锁：收包统计、秒开缓存、重排/抖动缓冲及向客户端通道发送与关闭通道都在此锁下进行，
+	// 收包与广播不再持有 Mu，不与加入/离开、状态查询等争用 Mu。锁序：Mu → sendMu
+	sendMu  sync.Mutex
+	evicted map[chan []byte]struct{} // 因跟不上被断开（通道已关闭）、等待订阅方退订的客户端，受 sendMu 保护
+
+	// 多网卡同时接收（stream.all_interfaces）：加入的网卡（受 Mu 保护）、去重窗口与各网卡接收计数（受 sendMu 保护）
+	allIfaces    bool
+	joinedIfaces []string
+	dedup        *dedupWindow
+	ifaceRx      map[string]*ifaceRxCounter
+
+	rxJitter     rxJitterStats  // 基于内核接收时间戳的到达抖动，受 sendMu 保护
+	fill         fillLevelStats // 客户端通道填充度，受 Mu 保护
+	fullPolicy   string         // 客户端通道满时的处理策略
+	blockTimeout time.Duration  // block-with-deadline 策略的等待上限
+
+	switchEvents []monitor.SourceSwitchEvent // 最近的源切换记录，受 Mu 保护
+
+	// 多套接字接收（stream.read_sockets）：全部接收套接字（第一个即 UdpConn，受 Mu 保护）及各自接收包数
+	// （替换时同时持有 Mu 与 sendMu，计数受 sendMu 保护），单套接字时为空
+	readConns []*net.UDPConn
+	socketRx  []uint64
+
+	graceCheck atomic.Bool // 已安排重载宽限期结束后的空闲检查
+
+	failover *ifaceFailover // 按网卡优先级的故障切换（stream.iface_failover），nil 表示关闭，受 Mu 保护
+	slate    *slateState    // 源中断垫片（stream.slate_file），nil 表示关闭，受 Mu 保护
+
+	ifacesLost  bool           // 配置的网卡全部无法解析，已回退为普通 UDP 监听（非 strict_ifaces 模式），受 Mu 保护
+	ifaceOrigin HubIfaceOrigin // 网卡列表的来源（全局默认、频道或请求指定），配置重载时据此切换网卡，受 Mu 保护
+
+	// 广播用的客户端快照（见 client_list.go），加入/离开时失效，受 sendMu 保护
+	clientList      []chan []byte
+	clientListStale bool
+
+	// 调试信息（/debug/hubs），收包计数与时间受 sendMu 保护，其余受 Mu 保护
+	createdAt     time.Time
+	ingestPackets uint64    // 累计接收包数
+	lastPacketAt  time.Time // 最近一次收到源数据的时间
+	readErrors    uint64    // 读错误次数（不含超时）
+	lastErr       string    // 最近一次读错误
+	lastErrAt     time.Time
 }
 
 var (
@@ -34,46 +94,102 @@ var (
 	HubsMu sync.Mutex
 )
 
-func NewStreamHub(udpAddr string, ifaces []string) (*StreamHub, error) {
-	addr, err := net.ResolveUDPAddr("udp", udpAddr)
+// interfaceByIP 查找拥有指定本地 IP 的网卡
+func interfaceByIP(ip net.IP) (*net.Interface, error) {
+	ifs, err := net.Interfaces()
 	if err != nil {
 		return nil, err
 	}
+	for i := range ifs {
+		addrs, err := ifs[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return &ifs[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("未找到拥有地址 %s 的网卡", ip)
+}
 
-	var conn *net.UDPConn
-	if len(ifaces) == 0 {
+// listenUDP 按网卡顺序加入组播组，全部失败时回退为普通 UDP 监听；
+// 组播地址的网卡名全部无法解析且开启 stream.strict_ifaces 时不回退，返回 errNoIfaceResolved
+// localAddr 非空时按本地 IP 精确选择网卡加入组播，回退时也绑定到该地址
+// 返回值 multicast 表示是否成功以组播方式监听，ifaceIdx 为成功加入的网卡在 ifaces 中的下标（未按网卡加入时为 -1）
+func listenUDP(udpAddr string, ifaces []string, localAddr string) (conn *net.UDPConn, multicast bool, ifaceIdx int, err error) {
+	ifaceIdx = -1
+	addr, err := net.ResolveUDPAddr("udp", udpAddr)
+	if err != nil {
+		return nil, false, ifaceIdx, err
+	}
+
+	if localAddr != "" {
+		ip := net.ParseIP(localAddr)
+		if ip == nil {
+			return nil, false, ifaceIdx, fmt.Errorf("无效的本地地址: %s", localAddr)
+		}
+		iface, ierr := interfaceByIP(ip)
+		if ierr == nil {
+			conn, err = net.ListenMulticastUDP("udp", iface, addr)
+			if err == nil {
+				multicast = true
+				logger.LogPrintf("🟢 监听 %s@%s(%s) 成功", udpAddr, localAddr, iface.Name)
+			}
+		} else {
+			err = ierr
+		}
+		if conn == nil {
+			logger.LogPrintf("⚠️ 通过本地地址 %s 加入组播失败: %v", localAddr, err)
+			conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: ip, Port: addr.Port})
+			if err != nil {
+				return nil, false, ifaceIdx, fmt.Errorf("绑定本地地址 %s 失败: %v", localAddr, err)
+			}
+			logger.LogPrintf("🟡 回退为普通 UDP 监听 %s:%d", localAddr, addr.Port)
+		}
+	} else if len(ifaces) == 0 {
 		// 未指定网卡，优先多播，再降级普通 UDP
 		conn, err = net.ListenMulticastUDP("udp", nil, addr)
-		if err != nil {
+		if err == nil {
+			multicast = true
+		} else {
 			conn, err = net.ListenUDP("udp", addr)
 			if err != nil {
-				return nil, err
+				return nil, false, ifaceIdx, err
 			}
+			logger.LogPrintf("🟡 组播加入失败，回退为普通 UDP 监听 %s", udpAddr)
 		}
 		logger.LogPrintf("🟢 监听 %s (默认接口)", udpAddr)
 	} else {
 		// 尝试每一个指定网卡，取第一个成功的
 		var lastErr error
-		for _, name := range ifaces {
+		resolved := 0
+		for i, name := range ifaces {
 			iface, ierr := net.InterfaceByName(name)
 			if ierr != nil {
 				lastErr = ierr
 				logger.LogPrintf("⚠️ 网卡 %s 不存在或不可用: %v", name, ierr)
 				continue
 			}
+			resolved++
 			conn, err = net.ListenMulticastUDP("udp", iface, addr)
 			if err == nil {
+				multicast, ifaceIdx = true, i
 				logger.LogPrintf("🟢 监听 %s@%s 成功", udpAddr, name)
 				break
 			}
 			lastErr = err
 			logger.LogPrintf("⚠️ 监听 %s@%s 失败: %v", udpAddr, name, err)
 		}
+		if conn == nil && resolved == 0 && addr.IP.IsMulticast() && strictIfaces() {
+			return nil, false, ifaceIdx, fmt.Errorf("%w: %v (last=%v)", errNoIfaceResolved, ifaces, lastErr)
+		}
 		if conn == nil {
 			// 所有网卡失败，尝试普通 UDP
 			conn, err = net.ListenUDP("udp", addr)
 			if err != nil {
-				return nil, fmt.Errorf("所有网卡监听失败且 UDP 监听失败: %v (last=%v)", err, lastErr)
+				return nil, false, ifaceIdx, fmt.Errorf("所有网卡监听失败且 UDP 监听失败: %v (last=%v)", err, lastErr)
 			}
 			logger.LogPrintf("🟡 回退为普通 UDP 监听 %s", udpAddr)
 		}
@@ -81,6 +197,103 @@ func NewStreamHub(udpAddr string, ifaces []string) (*StreamHub, error) {
 
 	// 增大内核缓冲区，尽可能减小丢包
 	_ = conn.SetReadBuffer(8 * 1024 * 1024)
+	return conn, multicast, ifaceIdx, nil
+}
+
+// listenUDPWithRetry 首次加入组播失败时按配置指数退避重试，适用于开机时网络尚未就绪的场景
+// 非组播地址本就无需加入组播，不做重试；重试期间会休眠，调用方不能持有 HubsMu
+func listenUDPWithRetry(udpAddr string, ifaces []string, localAddr string) (*net.UDPConn, bool, int, error) {
+	config.CfgMu.RLock()
+	retries := config.Cfg.Stream.JoinRetries
+	delay := config.Cfg.Stream.JoinRetryDelay
+	config.CfgMu.RUnlock()
+	if delay <= 0 {
+		delay = time.Second
+	}
+	const maxDelay = 10 * time.Second
+
+	isGroup := false
+	if addr, err := net.ResolveUDPAddr("udp", udpAddr); err == nil {
+		isGroup = addr.IP.IsMulticast()
+	}
+
+	for attempt := 0; ; attempt++ {
+		conn, multicast, ifaceIdx, err := listenUDP(udpAddr, ifaces, localAddr)
+		// strict_ifaces 下网卡名全部无法解析属于配置错误，立即返回，不占用请求等待重试
+		if (err == nil && (multicast || !isGroup)) || attempt >= retries || errors.Is(err, errNoIfaceResolved) {
+			return conn, multicast, ifaceIdx, err
+		}
+		if conn != nil {
+			_ = conn.Close()
+		}
+		logger.LogPrintf("🔁 加入组播 %s 失败，%v 后重试 (%d/%d): %v", udpAddr, delay, attempt+1, retries, err)
+		time.Sleep(delay)
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+func NewStreamHub(udpAddr string, ifaces []string, localAddr string) (*StreamHub, error) {
+	var (
+		conn      *net.UDPConn
+		multicast bool
+		sourceURL string
+		preview   string
+		joined    []string
+		readConns []*net.UDPConn
+		unicast   bool
+		ifaceIdx  = -1
+	)
+	allIfaces, dedupSize := allInterfacesMode()
+	if IsHTTPSource(udpAddr) {
+		// HTTP 拉流：网卡与本地地址不适用
+		sourceURL, ifaces, localAddr = udpAddr, nil, ""
+		allIfaces = false
+	} else if IsPreviewSource(udpAddr) {
+		// 预览变体：数据来自 ffmpeg 转码，网卡与本地地址由完整流使用；频道不存在或未启用预览时不创建
+		preview, ifaces, localAddr = strings.TrimPrefix(udpAddr, previewPrefix), nil, ""
+		allIfaces = false
+		if _, err := lookupPreview(preview); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		listenAddr := udpAddr
+		if IsUnicastSource(udpAddr) {
+			// 单播：只绑定端口，不加入组播，网卡参数不适用
+			unicast, listenAddr, ifaces, allIfaces = true, unicastAddr(udpAddr), nil, false
+		}
+		if allIfaces && localAddr == "" {
+			if conn, joined, err = listenAllInterfaces(udpAddr, ifaces); err == nil {
+				multicast = true
+			} else {
+				logger.LogPrintf("⚠️ 多网卡监听 %s 失败，回退为单网卡: %v", udpAddr, err)
+			}
+		}
+		if conn == nil {
+			allIfaces = false
+			if n := readSocketCount(); multiSocketEligible(listenAddr, n) {
+				depth, _ := rtpReorderConfig()
+				if readConns, err = listenReadSockets(listenAddr, localAddr, n, depth > 0); err == nil {
+					conn = readConns[0]
+				} else {
+					logger.LogPrintf("⚠️ 多套接字监听 %s 失败，回退为单套接字: %v", udpAddr, err)
+				}
+			}
+		}
+		if conn == nil {
+			if unicast {
+				conn, err = listenUnicast(listenAddr, localAddr)
+			} else {
+				conn, multicast, ifaceIdx, err = listenUDPWithRetry(udpAddr, ifaces, localAddr)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	hub := &StreamHub{
 		Clients:     make(map[chan []byte]struct{}),
@@ -90,136 +303,477 @@ func NewStreamHub(udpAddr string, ifaces []string) (*StreamHub, error) {
 		Closed:      make(chan struct{}),
 		BufPool:     &sync.Pool{New: func() any { return make([]byte, 4096) }}, // 增大缓冲区
 		CacheBuffer: make([][]byte, 0, 50),                                     // 初始化缓存缓冲区，用于热切换
+		IsMulticast: multicast,
+		ifacesLost:  sourceURL == "" && preview == "" && !unicast && localAddr == "" && !multicast && ifacesUnresolved(ifaces),
+		Ifaces:      append([]string(nil), ifaces...),
+		LocalAddr:   localAddr,
 		addr:        udpAddr,
+		sourceURL:   sourceURL,
+		preview:     preview,
+		unicast:     unicast,
+		pinned:      sourceURL == "" && preview == "" && highPrioritySource(udpAddr),
+		createdAt:   time.Now(),
+	}
+	if allIfaces {
+		hub.allIfaces, hub.joinedIfaces = true, joined
+		hub.dedup = newDedupWindow(dedupSize)
+	}
+	if n := jitterBufferFrames(); n > 0 {
+		hub.jitter = newJitterBuffer(n)
+	}
+	if depth, timeout := rtpReorderConfig(); depth > 0 {
+		hub.reorder = newRTPReorder(depth, timeout)
+	}
+	hub.fullPolicy, hub.blockTimeout = fullChannelPolicy()
+	hub.Mu.instrument = lockStatsEnabled()
+	hub.setReadConnsLocked(readConns)
+	if psiReplayEnabled() {
+		hub.psi = newPSICache()
+	}
+	if timeout, failback := ifaceFailoverConfig(); timeout > 0 && multicast && !allIfaces && localAddr == "" && len(ifaces) > 1 && ifaceIdx >= 0 {
+		hub.failover = &ifaceFailover{timeout: timeout, failback: failback, active: ifaceIdx, since: time.Now()}
+	}
+	if cfg := slateConfig(); cfg.file != "" {
+		hub.slate = &slateState{cfg: cfg}
+	}
+	if n := broadcastWorkers(); n > 0 {
+		hub.fanout = newFanoutPool(hub, n)
+	}
+	if !multicast && sourceURL == "" && preview == "" && !unicast {
+		logger.LogPrintf("⚠️ Hub %s 处于回退模式（非组播），若源为组播可能收不到数据", udpAddr)
 	}
 
 	go hub.run()
-	go hub.readLoop()
+	switch {
+	case sourceURL != "":
+		go hub.httpReadLoop()
+	case preview != "":
+		go hub.previewReadLoop()
+	case allIfaces:
+		go hub.allIfacesReadLoop()
+	default:
+		go hub.readLoop(0)
+	}
+	if hub.jitter != nil {
+		go hub.jitterLoop()
+	}
+	if hub.failover != nil {
+		go hub.failoverLoop()
+	}
+	if hub.slate != nil {
+		go hub.slateLoop()
+	}
 
-	logger.LogPrintf("UDP 监听地址：%s ifaces=%v", udpAddr, ifaces)
+	logger.LogPrintf("UDP 监听地址：%s ifaces=%v laddr=%s", udpAddr, ifaces, localAddr)
+	publishEvent(HubEvent{Type: HubCreated, HubKey: HubKey(udpAddr, ifaces, localAddr), Addr: udpAddr})
 	return hub, nil
 }
 
 func (h *StreamHub) run() {
+	fillTicker := time.NewTicker(fillSampleInterval)
+	defer fillTicker.Stop()
+
+	// 源停顿时由定时器释放重排缓冲中等待超时的包
+	var reorderC <-chan time.Time
+	if h.reorder != nil {
+		t := time.NewTicker(h.reorder.timeout)
+		defer t.Stop()
+		reorderC = t.C
+	}
+
 	for {
 		select {
-		case ch := <-h.AddCh:
+		case now := <-fillTicker.C:
 			h.Mu.Lock()
-			h.Clients[ch] = struct{}{}
-			// 新客户端秒开：发送缓存的数据包以提高热切换流畅性
-			for _, pkt := range h.CacheBuffer {
-				select {
-				case ch <- pkt:
-				default:
-					// 如果客户端通道已满，跳过以避免阻塞
-				}
-			}
+			h.fill.sample(h.Clients, now)
 			h.Mu.Unlock()
-			logger.LogPrintf("➕ 客户端加入，当前=%d", len(h.Clients))
 
-		case ch := <-h.RemoveCh:
+		case now := <-reorderC:
+			h.sendMu.Lock()
+			for _, data := range h.reorder.expire(now, nil) {
+				h.deliverLocked(data)
+			}
+			h.sendMu.Unlock()
+
+		case ch := <-h.AddCh:
 			h.Mu.Lock()
-			if _, ok := h.Clients[ch]; ok {
-				delete(h.Clients, ch)
+			if h.Clients == nil {
+				// Hub 已关闭（Close 已清空客户端集合），通知订阅方退出
+				h.Mu.Unlock()
 				close(ch)
+				continue
+			}
+			fastStart := h.settings().FastStart
+			// 加入与回放在同一个 sendMu 临界区内完成，之后的广播不会插到缓存包之前，保证回放顺序
+			h.sendMu.Lock()
+			h.addClientLocked(ch)
+			// 先发送缓存的 PAT/PMT，便于中途加入的播放器立即解码
+			if h.psi != nil {
+				if psi := h.psi.frame(); psi != nil {
+					select {
+					case ch <- psi:
+					default:
+					}
+				}
+			}
+			// 新客户端秒开：发送缓存的数据包以提高热切换流畅性（可按频道关闭）
+			if fastStart {
+				replayed := 0
+				for _, pkt := range h.CacheBuffer {
+					select {
+					case ch <- pkt:
+						replayed++
+					default:
+						// 如果客户端通道已满，跳过以避免阻塞
+					}
+				}
+				monitor.RecordFastStartFrames(replayed)
+			}
+			primed := len(ch) > 0
+			h.sendMu.Unlock()
+			// 回放的帧先于任何实时包入队，据此判断客户端首帧是否来自秒开缓存
+			if primed {
+				if h.primed == nil {
+					h.primed = make(map[chan []byte]struct{})
+				}
+				h.primed[ch] = struct{}{}
 			}
 			clientCount := len(h.Clients)
+			monitor.RecordHubJoin()
+			h.publishLocked(ClientJoined, clientCount)
 			h.Mu.Unlock()
-			logger.LogPrintf("➖ 客户端离开，当前=%d", clientCount)
-
-			// 如果没有客户端了，关闭UDP监听
-			if clientCount == 0 {
-				logger.LogPrintf("⏹ 没有客户端，立即关闭 Hub")
-				h.Close()
+			if logClientChurn() {
+				logger.LogPrintf("➕ 客户端加入，当前=%d", clientCount)
 			}
 
+		case ch := <-h.RemoveCh:
+			h.leave(ch)
+
 		case <-h.Closed:
 			h.Mu.Lock()
-			for ch := range h.Clients {
-				close(ch)
-			}
-			h.Clients = nil
+			h.sendMu.Lock()
+			h.closeClientsLocked(nil)
+			h.closeSilentLocked()
+			h.sendMu.Unlock()
 			h.Mu.Unlock()
 			return
 		}
 	}
 }
 
-func (h *StreamHub) readLoop() {
+// readLoop 第 idx 个接收套接字的读循环（0 为 UdpConn），套接字被移除后退出
+func (h *StreamHub) readLoop(idx int) {
 	// 检查是否已经关闭
 	select {
 	case <-h.Closed:
 		return
 	default:
 	}
+	defer h.pinReadThread()()
+
+	// 周期性读超时，避免半开套接字上 ReadFromUDP 永久阻塞；超时后按普通读错误处理
+	deadline := readDeadline()
+	errorBackoff := getStreamTimeouts().errorBackoff
+
+	// 内核接收时间戳（仅支持 SO_TIMESTAMPNS 的平台），用于统计到达抖动
+	var (
+		tsConn *net.UDPConn
+		oob    []byte
+	)
 
+	var conn *net.UDPConn
 	for {
 		buf := h.BufPool.Get().([]byte)
-		n, _, err := h.UdpConn.ReadFromUDP(buf)
+		// UdpConn 每次读取前重新获取以跟随网卡切换；附加套接字只在读错误（如被关闭替换）后重新获取，避免每包多一次加锁
+		if idx == 0 || conn == nil {
+			conn = h.readConnAt(idx)
+		}
+		if conn == nil {
+			h.BufPool.Put(buf)
+			return
+		}
+		if conn != tsConn {
+			// 首次读取或套接字已切换（网卡更新）
+			tsConn, oob = conn, nil
+			if rxTimestampOOBSize > 0 && enableRxTimestamps(conn) {
+				oob = make([]byte, rxTimestampOOBSize)
+			}
+			h.sendMu.Lock()
+			h.rxJitter.reset()
+			h.sendMu.Unlock()
+		}
+		if deadline > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(deadline))
+		}
+		var (
+			n, oobn int
+			err     error
+		)
+		if oob != nil {
+			n, oobn, _, _, err = conn.ReadMsgUDP(buf, oob)
+		} else {
+			n, _, err = conn.ReadFromUDP(buf)
+		}
 		if err != nil {
 			h.BufPool.Put(buf)
-			select {
-			case <-h.Closed:
+			conn = nil
+			if h.handleReadError(err, errorBackoff) {
 				return
-			default:
-				// 检查是否还有客户端连接
-				h.Mu.Lock()
-				clientCount := len(h.Clients)
-				h.Mu.Unlock()
-
-				if clientCount == 0 {
-					logger.LogPrintf("没有客户端，停止接收数据并关闭连接: %s", h.addr)
-					h.Close()
-					return
-				}
-				continue
 			}
-		}
-
-		// 检查是否还有客户端连接
-		h.Mu.Lock()
-		clientCount := len(h.Clients)
-		if clientCount == 0 {
-			h.Mu.Unlock()
-			h.BufPool.Put(buf[:cap(buf)])
-			// 没有客户端，但继续监听以防新客户端加入
 			continue
 		}
 
-		// 复制数据以避免竞态
-		data := make([]byte, n)
-		copy(data, buf[:n])
+		h.sendMu.Lock()
+		h.countSocketRxLocked(idx)
+		if oobn > 0 {
+			if rx, ok := parseRxTimestamp(oob[:oobn]); ok {
+				h.rxJitter.add(rx)
+			}
+		}
+		h.ingestLocked(buf[:n])
+		h.sendMu.Unlock()
 		h.BufPool.Put(buf[:cap(buf)])
+	}
+}
 
-		// 统计入流量
-		// monitor.AddAppInboundBytes(uint64(len(data)))
+// handleReadError 处理 UDP 读错误，返回 true 表示读循环应退出（Hub 已关闭或已无客户端）
+func (h *StreamHub) handleReadError(err error, errorBackoff time.Duration) bool {
+	select {
+	case <-h.Closed:
+		return true
+	default:
+	}
+
+	// 检查是否还有客户端连接
+	h.Mu.Lock()
+	clientCount := len(h.Clients)
+	h.recordReadErrorLocked(err)
+	h.Mu.Unlock()
 
-		// 更新最近一帧
-		h.LastFrame = data
+	if clientCount == 0 && h.closeIfIdle() {
+		logger.LogPrintf("没有客户端，停止接收数据并关闭连接: %s", h.addr)
+		return true
+	}
+	// 非超时错误短暂退避，避免套接字异常时空转
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		time.Sleep(errorBackoff)
+	}
+	return false
+}
+
+// recordReadErrorLocked 记录最近一次读错误，超时不计入；调用方需持有 h.Mu
+func (h *StreamHub) recordReadErrorLocked(err error) {
+	var ne net.Error
+	if err == nil || (errors.As(err, &ne) && ne.Timeout()) {
+		return
+	}
+	h.readErrors++
+	h.lastErr = err.Error()
+	h.lastErrAt = time.Now()
+}
 
-		// 缓存数据包用于热切换
-		if len(h.CacheBuffer) >= 50 {
-			// 移除最旧的数据包
-			copy(h.CacheBuffer, h.CacheBuffer[1:])
-			h.CacheBuffer = h.CacheBuffer[:len(h.CacheBuffer)-1]
+// ingestLocked 处理从源收到的一段数据：统计码率、更新缓存并广播；p 会被复制，调用方可复用；
+// 调用方需持有 h.sendMu（不需要 h.Mu）。UDP 与 HTTP 拉流共用此路径
+func (h *StreamHub) ingestLocked(p []byte) {
+	n := len(p)
+	now := time.Now()
+	h.ingestRate.add(n, now)
+	h.ingestBytes += uint64(n)
+	h.ingestPackets++
+	h.lastPacketAt = now
+
+	// 没有客户端，但继续监听以防新客户端加入
+	if len(h.Clients) == 0 && len(h.silent) == 0 {
+		if h.reorder != nil {
+			h.reorder.reset()
 		}
-		h.CacheBuffer = append(h.CacheBuffer, data)
+		return
+	}
 
-		// 广播数据到所有客户端
-		for ch := range h.Clients {
-			select {
-			case ch <- data:
-			default:
-				// 如果通道缓冲区满了，断开客户端
-				close(ch)
-				delete(h.Clients, ch)
+	// 复制数据以避免竞态
+	data := make([]byte, n)
+	copy(data, p)
+
+	// 统计入流量
+	// monitor.AddAppInboundBytes(uint64(len(data)))
+
+	// RTP 包按序列号重排后再分发，裸 TS 不经过重排缓冲
+	if h.reorder != nil {
+		if seq, ok := rtpSeq(data); ok {
+			for _, d := range h.reorder.push(data, seq, now) {
+				h.deliverLocked(d)
 			}
+			return
+		}
+	}
+	h.deliverLocked(data)
+}
+
+// deliverLocked 更新最近一帧与缓存并分发给客户端；调用方需持有 h.sendMu
+func (h *StreamHub) deliverLocked(data []byte) {
+	// 更新最近一帧
+	h.lastFrame.Store(&data)
+	if h.psi != nil {
+		h.psi.observe(data)
+	}
+
+	// 缓存数据包用于热切换
+	if len(h.CacheBuffer) >= 50 {
+		// 移除最旧的数据包
+		copy(h.CacheBuffer, h.CacheBuffer[1:])
+		h.CacheBuffer = h.CacheBuffer[:len(h.CacheBuffer)-1]
+	}
+	h.CacheBuffer = append(h.CacheBuffer, data)
+
+	if h.jitter != nil {
+		// 经抖动缓冲平滑后由 jitterLoop 广播
+		h.jitter.push(data, time.Now())
+	} else {
+		h.broadcast(data)
+	}
+}
+
+// LastFrame 返回最近一帧，尚未收到数据时返回 nil；不需要持有 h.Mu
+func (h *StreamHub) LastFrame() []byte {
+	if p := h.lastFrame.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// broadcast 广播数据到所有客户端，客户端通道已满时按 fullPolicy 处理；调用方需持有 h.sendMu
+// 启用 broadcast_workers 且客户端较多时由 fanout 分片并行投递
+func (h *StreamHub) broadcast(data []byte) {
+	h.deliverSilentLocked(data)
+	clients := h.clientSnapshotLocked()
+	if h.fanout != nil && !h.fanout.stopped && len(clients) >= minFanoutClients {
+		h.fanout.broadcast(h, clients, data)
+		return
+	}
+	for _, ch := range clients {
+		if h.deliver(ch, data) {
+			// 断开跟不上的客户端
+			h.evictLocked(ch)
 		}
+	}
+}
+
+// deliver 向单个客户端投递一帧，返回 true 表示该客户端应被断开（disconnect 策略）
+// 不修改 h.Clients，可在 fanout 工作协程中并发调用（调用方持有 h.sendMu 等待全部完成）
+func (h *StreamHub) deliver(ch chan []byte, data []byte) bool {
+	select {
+	case ch <- data:
+		return false
+	default:
+	}
+
+	switch h.fullPolicy {
+	case FullPolicyDropOldest:
+		// 丢弃通道中最旧的一帧后再写入
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- data:
+		default:
+		}
+	case FullPolicyBlock:
+		// 限时等待客户端消费，超时丢弃本帧
+		timer := time.NewTimer(h.blockTimeout)
+		select {
+		case ch <- data:
+		case <-timer.C:
+		}
+		timer.Stop()
+	case FullPolicyDisconnect:
+		return true
+	default:
+		// drop-newest：丢弃本帧
+	}
+	return false
+}
+
+var (
+	errHubClosed       = errors.New("Hub 已关闭")
+	errHubBusy         = errors.New("加入 Hub 超时")
+	errNoIfaceResolved = errors.New("配置的网卡均不存在")
+)
+
+// takeFirstFrameSource 返回客户端首帧的来源（秒开缓存或实时包），在收到首帧后调用一次
+func (h *StreamHub) takeFirstFrameSource(ch chan []byte) string {
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+	if _, ok := h.primed[ch]; ok {
+		delete(h.primed, ch)
+		return monitor.FirstFrameFastStart
+	}
+	return monitor.FirstFrameLive
+}
+
+// subscribe 将客户端通道加入 Hub；订阅不无限等待：Hub 正在关闭或 run 繁忙时快速失败
+func (h *StreamHub) subscribe(ch chan []byte, timeout time.Duration) error {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case h.AddCh <- ch:
+		return nil
+	case <-h.Closed:
+		return errHubClosed
+	case <-t.C:
+		logger.LogPrintf("⏱ 加入 Hub %s 超时 (%v)", h.addr, timeout)
+		return errHubBusy
+	}
+}
+
+// unsubscribe 将客户端通道移出 Hub；run 繁忙超时后直接在短临界区内移除，
+// 避免已离开的客户端（及其秒开标记）留在 Clients 中
+func (h *StreamHub) unsubscribe(ch chan []byte, timeout time.Duration) {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case h.RemoveCh <- ch:
+	case <-h.Closed:
+	case <-t.C:
+		logger.LogPrintf("⏱ 退出 Hub %s 超时 (%v)，直接移除客户端", h.addr, timeout)
+		h.leave(ch)
+	}
+}
+
+// leave 从 Hub 移除客户端并清理其秒开标记，没有客户端时关闭 Hub（重载宽限期内暂缓）；
+// 由 run 处理 RemoveCh 时调用，退订超时时由订阅方直接调用
+func (h *StreamHub) leave(ch chan []byte) {
+	h.Mu.Lock()
+	if h.Clients == nil {
+		// Hub 已关闭，Close 已关闭全部通道
 		h.Mu.Unlock()
+		return
+	}
+	_, ok := h.Clients[ch]
+	if ok {
+		h.sendMu.Lock()
+		h.removeClientLocked(ch)
+		h.sendMu.Unlock()
+	}
+	delete(h.primed, ch)
+	clientCount := len(h.Clients)
+	if ok {
+		h.publishLocked(ClientLeft, clientCount)
+	}
+	h.Mu.Unlock()
+	if logClientChurn() {
+		logger.LogPrintf("➖ 客户端离开，当前=%d", clientCount)
+	}
+
+	// 如果没有客户端了，关闭UDP监听（重载宽限期内暂缓）
+	if clientCount == 0 && h.closeIfIdle() {
+		logger.LogPrintf("⏹ 没有客户端，立即关闭 Hub")
 	}
 }
 
-func (h *StreamHub) ServeHTTP(w http.ResponseWriter, r *http.Request, contentType string, updateActive func()) {
+// ServeHTTP 向客户端持续输出 Hub 数据；onFirstFrame 在首帧写出后以首帧来源（monitor.FirstFrameFastStart / FirstFrameLive）调用，可为 nil
+func (h *StreamHub) ServeHTTP(w http.ResponseWriter, r *http.Request, contentType string, updateActive func(), onFirstFrame func(source string)) {
 	select {
 	case <-h.Closed:
 		http.Error(w, "Stream hub closed", http.StatusServiceUnavailable)
@@ -227,12 +781,27 @@ func (h *StreamHub) ServeHTTP(w http.ResponseWriter, r *http.Request, contentTyp
 	default:
 	}
 
+	timeouts := getStreamTimeouts()
+	// 频道级运行参数（可通过管理接口调整），只影响新加入的客户端
+	settings := h.settings()
+	timeouts.idle = settings.IdleTimeout
+
 	// 增大客户端通道缓冲区以减少丢包
-	ch := make(chan []byte, 200)
-	h.AddCh <- ch
-	defer func() { h.RemoveCh <- ch }()
+	ch := make(chan []byte, settings.ClientBuffer)
+	if err := h.subscribe(ch, timeouts.subscribe); err != nil {
+		if errors.Is(err, errHubClosed) {
+			http.Error(w, "Stream hub closed", http.StatusServiceUnavailable)
+		} else {
+			http.Error(w, "Stream hub busy", http.StatusServiceUnavailable)
+		}
+		return
+	}
+	defer h.unsubscribe(ch, timeouts.subscribe)
 
 	w.Header().Set("Content-Type", contentType)
+	// HTTP/1.1 与 HTTP/2 的 ResponseWriter 均实现 Flusher：h2 下 Flush 会立即把缓冲数据
+	// 作为 DATA 帧发出，逐帧推送行为一致；但发送受流级/连接级流控窗口限制，
+	// 窗口耗尽时 Write 阻塞，最终由 write_timeout 断开慢客户端
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
@@ -241,165 +810,324 @@ func (h *StreamHub) ServeHTTP(w http.ResponseWriter, r *http.Request, contentTyp
 
 	ctx := r.Context()
 
+	// 所有写入都在处理协程中同步完成，由 ResponseController 的写截止时间断开慢客户端，
+	// 处理函数返回后不会再有协程访问 ResponseWriter；底层不支持写截止时间时（如测试用的 ResponseRecorder）不限时
+	rc := http.NewResponseController(w)
+	defer rc.SetWriteDeadline(time.Time{})
+
+	// Hub 关闭（而非客户端断开）时按配置发送结束标记，便于播放器区分正常结束与错误
+	eos := getEndOfStreamAction(contentType)
+	eos.announce(w)
+	hubClosed := false
+	defer func() {
+		if hubClosed {
+			eos.finish(w, rc, timeouts.write)
+		}
+	}()
+
+	// 写入一帧数据（带超时），返回 false 表示需要断开客户端
+	writeFrame := func(data []byte) bool {
+		if p, ok := w.(writePacer); ok {
+			if p.Pace(ctx, len(data)) != nil {
+				return false
+			}
+		}
+		_ = rc.SetWriteDeadline(time.Now().Add(timeouts.write))
+		if _, err := w.Write(data); err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				logger.LogPrintf("写入超时，关闭连接")
+			} else if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				logger.LogPrintf("写入客户端错误: %v", err)
+			}
+			return false
+		}
+		flusher.Flush()
+		if updateActive != nil {
+			updateActive()
+		}
+		return true
+	}
+
+	// 保活：源暂停时定期发送 TS 空包，避免中间设备因长时间无数据断开连接
+	keepalive := keepaliveInterval()
+	var keepaliveC <-chan time.Time
+	if keepalive > 0 {
+		ticker := time.NewTicker(keepalive)
+		defer ticker.Stop()
+		keepaliveC = ticker.C
+	}
+	lastData := time.Now()
+	subscribedAt := lastData
+	firstFrame := true
+
+	// 首帧等待上限：源从未送达数据时尽快返回 504，而不是等到空闲超时；响应头尚未发出前保活空包也暂缓
+	var initialC <-chan time.Time
+	if timeouts.initialData > 0 {
+		t := time.NewTimer(timeouts.initialData)
+		defer t.Stop()
+		initialC = t.C
+	}
+
+	// 写合并：累积多帧后一次写入并 Flush，减少小包写入的系统调用；首帧不合并以保证起播速度
+	coalesce := getWriteCoalesce()
+	var (
+		pending []byte
+		flushC  <-chan time.Time
+	)
+	if coalesce.size > 0 {
+		pending = make([]byte, 0, coalesce.size+4096)
+	}
+	flushPending := func() bool {
+		flushC = nil
+		if len(pending) == 0 {
+			return true
+		}
+		ok := writeFrame(pending)
+		pending = pending[:0]
+		return ok
+	}
+
 	for {
+		// 启用保活后由空包维持连接，不再触发空闲超时
+		var idleC <-chan time.Time
+		if keepalive <= 0 {
+			idleC = time.After(timeouts.idle)
+		}
+
 		select {
 		case data, ok := <-ch:
 			if !ok {
+				select {
+				case <-h.Closed:
+					hubClosed = true
+				default:
+				}
 				return
 			}
-			writeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-			errCh := make(chan error, 1)
-			go func() {
-				n, err := w.Write(data)
-				if err == nil {
-					// monitor.AddAppOutboundBytes(uint64(n))
-					_ = n // 避免未使用报错
-				}
-				errCh <- err
-			}()
-			select {
-			case err := <-errCh:
-				cancel()
-				if err != nil {
-					if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
-						logger.LogPrintf("写入客户端错误: %v", err)
+			if coalesce.size > 0 && !firstFrame {
+				pending = append(pending, data...)
+				if len(pending) >= coalesce.size {
+					if !flushPending() {
+						return
 					}
-					return
+				} else if flushC == nil {
+					flushC = time.After(coalesce.interval)
 				}
-				flusher.Flush()
-				if updateActive != nil {
-					updateActive()
+			} else if !writeFrame(data) {
+				return
+			}
+			lastData = time.Now()
+			if firstFrame {
+				firstFrame = false
+				initialC = nil
+				monitor.ObserveFirstFrameLatency(lastData.Sub(subscribedAt))
+				if onFirstFrame != nil {
+					onFirstFrame(h.takeFirstFrameSource(ch))
 				}
-			case <-writeCtx.Done():
-				cancel()
-				logger.LogPrintf("写入超时，关闭连接")
+			}
+		case <-flushC:
+			if !flushPending() {
 				return
-			case <-h.Closed:
-				cancel()
-				logger.LogPrintf("Hub关闭，断开客户端连接")
+			}
+		case <-initialC:
+			logger.LogPrintf("⏱ %v 内未收到源 %s 的数据，返回 504", timeouts.initialData, h.addr)
+			http.Error(w, "No data from source", http.StatusGatewayTimeout)
+			return
+		case <-keepaliveC:
+			if time.Since(lastData) < keepalive || initialC != nil {
+				continue
+			}
+			if !flushPending() || !writeFrame(tsNullPacket) {
 				return
 			}
 		case <-ctx.Done():
-			logger.LogPrintf("客户端断开连接")
+			if logClientChurn() {
+				logger.LogPrintf("客户端断开连接")
+			}
 			return
-		case <-time.After(30 * time.Second): // 缩短超时时间以更快检测断开连接
+		case <-idleC:
 			logger.LogPrintf("客户端空闲超时，关闭连接")
 			return
 		}
 	}
 }
 
+// TransferClientsTo 将全部客户端迁移到新 Hub；锁序：旧 Hub 的 Mu → 旧 Hub 的 sendMu → 新 Hub 的 Mu → 新 Hub 的 sendMu
 func (h *StreamHub) TransferClientsTo(newHub *StreamHub) {
 	h.Mu.Lock()
 	defer h.Mu.Unlock()
+	// 迁移期间持有旧 Hub 的 sendMu：旧 Hub 不再向迁移中的客户端广播
+	h.sendMu.Lock()
+	defer h.sendMu.Unlock()
 
 	// 检查newHub是否已初始化
 	if newHub.Clients == nil {
 		newHub.Mu.Lock()
+		newHub.sendMu.Lock()
 		if newHub.Clients == nil {
-			newHub.Clients = make(map[chan []byte]struct{})
+			newHub.resetClientsLocked(make(map[chan []byte]struct{}))
 		}
+		newHub.sendMu.Unlock()
 		newHub.Mu.Unlock()
 	}
 
 	// 将当前缓存的数据包传递给新hub，以提高热切换流畅性
+	newHub.Mu.Lock()
 	if len(h.CacheBuffer) > 0 {
-		newHub.Mu.Lock()
 		// 复制缓存数据到新hub
+		newHub.sendMu.Lock()
 		newHub.CacheBuffer = make([][]byte, len(h.CacheBuffer))
 		copy(newHub.CacheBuffer, h.CacheBuffer)
-		newHub.Mu.Unlock()
+		newHub.sendMu.Unlock()
 	}
+	// 继承旧 Hub 的切换记录并追加本次迁移
+	newHub.switchEvents = append(append([]monitor.SourceSwitchEvent(nil), h.switchEvents...), newHub.switchEvents...)
+	newHub.recordSwitch(describeSource(h.addr, h.Ifaces, h.LocalAddr), describeSource(newHub.addr, newHub.Ifaces, newHub.LocalAddr), "Hub 迁移")
+	newHub.Mu.Unlock()
 
 	// 将所有客户端迁移到新Hub
-	clientCount := 0
+	clientCount, dropped := 0, 0
+	lastFrame := h.LastFrame()
+	// 整次迁移共用一个等待期限，避免慢客户端累计阻塞过久；超时后余下的客户端改为非阻塞投递
+	deadline := time.NewTimer(transferFrameTimeout)
+	defer deadline.Stop()
+	expired := false
 	for ch := range h.Clients {
-		// 添加客户端到新Hub
-		newHub.Mu.Lock()
-		newHub.Clients[ch] = struct{}{}
-		// 发送最新的帧以实现无缝切换
-		if len(h.LastFrame) > 0 {
-			select {
-			case ch <- h.LastFrame:
-			default:
+		// 已因跟不上被断开的客户端通道已关闭，由其订阅方自行退订，不迁移
+		if _, gone := h.evicted[ch]; gone {
+			continue
+		}
+		// 先投递最新帧再加入新 Hub：持有旧 Hub 锁时旧 Hub 不再向 ch 广播，
+		// 且新 Hub 的实时包只会排在该帧之后，保证迁移前后帧序连续
+		if len(lastFrame) > 0 {
+			if deliverTransferFrame(ch, lastFrame, deadline.C, &expired) {
+				monitor.RecordFastStartFrames(1)
+			} else {
+				dropped++
 			}
 		}
+
+		// 添加客户端到新Hub，已存在时不重复登记
+		newHub.Mu.Lock()
+		if _, ok := newHub.Clients[ch]; !ok {
+			newHub.sendMu.Lock()
+			newHub.addClientLocked(ch)
+			newHub.sendMu.Unlock()
+			monitor.RecordHubJoin()
+			newHub.publishLocked(ClientJoined, len(newHub.Clients))
+			clientCount++
+		}
 		newHub.Mu.Unlock()
-		clientCount++
 	}
 
 	// 清空当前Hub的客户端列表
-	h.Clients = make(map[chan []byte]struct{})
+	h.resetClientsLocked(make(map[chan []byte]struct{}))
 
+	if dropped > 0 {
+		logger.LogPrintf("🔄 客户端已迁移到新Hub，数量=%d，%d 个客户端通道已满未收到衔接帧", clientCount, dropped)
+		return
+	}
 	logger.LogPrintf("🔄 客户端已迁移到新Hub，数量=%d", clientCount)
 }
 
+// transferFrameTimeout 迁移时等待客户端通道腾出空间接收衔接帧的总时长
+const transferFrameTimeout = 200 * time.Millisecond
+
+// deliverTransferFrame 向迁移中的客户端投递衔接帧：期限内阻塞等待，期限过后只做非阻塞投递；返回是否送达
+func deliverTransferFrame(ch chan []byte, frame []byte, deadline <-chan time.Time, expired *bool) bool {
+	select {
+	case ch <- frame:
+		return true
+	default:
+	}
+	if *expired {
+		return false
+	}
+	select {
+	case ch <- frame:
+		return true
+	case <-deadline:
+		*expired = true
+		return false
+	}
+}
+
 // UpdateInterfaces 更新网络接口配置
 func (h *StreamHub) UpdateInterfaces(udpAddr string, ifaces []string) error {
 	h.Mu.Lock()
 	defer h.Mu.Unlock()
 
-	// 创建新的UDP连接
-	addr, err := net.ResolveUDPAddr("udp", udpAddr)
-	if err != nil {
-		return err
+	// 单播监听与网卡无关
+	if h.unicast {
+		return nil
 	}
 
-	var newConn *net.UDPConn
-	if len(ifaces) == 0 {
-		// 未指定网卡，优先多播，再降级普通 UDP
-		newConn, err = net.ListenMulticastUDP("udp", nil, addr)
+	// 创建新的UDP连接
+	var (
+		newConn   *net.UDPConn
+		multicast bool
+		joined    []string
+		ifaceIdx  = -1
+		err       error
+	)
+	var readConns []*net.UDPConn
+	if h.allIfaces {
+		newConn, joined, err = listenAllInterfaces(udpAddr, ifaces)
+		multicast = true
+	} else if n := len(h.readConns); multiSocketEligible(udpAddr, n) {
+		// 旧套接字同为 SO_REUSEPORT，新套接字可以先绑定，随后再关闭旧套接字
+		readConns, err = listenReadSockets(udpAddr, h.LocalAddr, n, h.reorder != nil)
 		if err != nil {
-			newConn, err = net.ListenUDP("udp", addr)
-			if err != nil {
-				return err
-			}
+			logger.LogPrintf("⚠️ 多套接字监听 %s 失败，回退为单套接字: %v", udpAddr, err)
+			newConn, multicast, ifaceIdx, err = listenUDP(udpAddr, ifaces, h.LocalAddr)
+		} else {
+			newConn = readConns[0]
 		}
-		logger.LogPrintf("🟢 监听 %s (默认接口)", udpAddr)
 	} else {
-		// 尝试每一个指定网卡，取第一个成功的
-		var lastErr error
-		for _, name := range ifaces {
-			iface, ierr := net.InterfaceByName(name)
-			if ierr != nil {
-				lastErr = ierr
-				logger.LogPrintf("⚠️ 网卡 %s 不存在或不可用: %v", name, ierr)
-				continue
-			}
-			newConn, err = net.ListenMulticastUDP("udp", iface, addr)
-			if err == nil {
-				logger.LogPrintf("🟢 监听 %s@%s 成功", udpAddr, name)
-				break
-			}
-			lastErr = err
-			logger.LogPrintf("⚠️ 监听 %s@%s 失败: %v", udpAddr, name, err)
-		}
-		if newConn == nil {
-			// 所有网卡失败，尝试普通 UDP
-			newConn, err = net.ListenUDP("udp", addr)
-			if err != nil {
-				return fmt.Errorf("所有网卡监听失败且 UDP 监听失败: %v (last=%v)", err, lastErr)
-			}
-			logger.LogPrintf("🟡 回退为普通 UDP 监听 %s", udpAddr)
-		}
+		newConn, multicast, ifaceIdx, err = listenUDP(udpAddr, ifaces, h.LocalAddr)
+	}
+	if err != nil {
+		return err
 	}
-
-	// 增大内核缓冲区，尽可能减小丢包
-	_ = newConn.SetReadBuffer(8 * 1024 * 1024)
 
 	// 关闭旧连接
-	if h.UdpConn != nil {
-		_ = h.UdpConn.Close()
-	}
+	h.leaveAndCloseConnLocked()
+	h.joinedIfaces = joined
+	h.setReadConnsLocked(readConns)
+
+	h.recordSwitch(describeSource(h.addr, h.Ifaces, h.LocalAddr), describeSource(udpAddr, ifaces, h.LocalAddr), "网卡配置变更")
 
 	// 使用新连接替换旧连接
 	h.UdpConn = newConn
 	h.addr = udpAddr
+	h.IsMulticast = multicast
+	h.ifacesLost = !h.unicast && h.LocalAddr == "" && !multicast && ifacesUnresolved(ifaces)
+	h.Ifaces = append([]string(nil), ifaces...)
+	if h.failover != nil {
+		// 网卡列表变更后按新列表的优先级重新开始
+		h.failover.active, h.failover.since = ifaceIdx, time.Now()
+	}
 
 	logger.LogPrintf("UDP 监听地址更新：%s ifaces=%v", udpAddr, ifaces)
 	return nil
 }
 
+// leaveAndCloseConnLocked 退出组播组并关闭当前 UDP 连接；调用方需持有 h.Mu
+func (h *StreamHub) leaveAndCloseConnLocked() {
+	if h.UdpConn == nil {
+		return
+	}
+	if h.allIfaces {
+		leaveAllInterfaces(h.UdpConn, h.addr, h.joinedIfaces)
+	} else if h.IsMulticast {
+		leaveMulticastGroup(h.UdpConn, h.addr, h.Ifaces, h.LocalAddr)
+	}
+	_ = h.UdpConn.Close()
+	if len(h.readConns) > 1 {
+		closeConns(h.readConns[1:])
+	}
+}
+
 // 关闭 hub
 func (h *StreamHub) Close() {
 	h.Mu.Lock()
@@ -412,34 +1140,73 @@ func (h *StreamHub) Close() {
 		close(h.Closed)
 	}
 
-	// 关闭 UDP 连接
-	if h.UdpConn != nil {
-		_ = h.UdpConn.Close()
-		h.UdpConn = nil
-	}
+	// 关闭 UDP 连接，组播先显式退出组
+	h.leaveAndCloseConnLocked()
+	h.UdpConn = nil
 
 	// 关闭所有客户端通道
-	for ch := range h.Clients {
-		close(ch)
+	h.sendMu.Lock()
+	h.closeClientsLocked(nil)
+	h.primed = nil
+	h.closeSilentLocked()
+	if h.fanout != nil {
+		h.fanout.stopLocked()
 	}
-	h.Clients = nil
 
 	// 清理缓存数据
 	h.CacheBuffer = nil
+	h.lastFrame.Store(nil)
+
+	// 累计接收字节并入频道总量（HubStatuses 之后不再重复计入）
+	monitor.AddChannelBytes(h.addr, h.ingestBytes)
+	h.ingestBytes = 0
+	h.sendMu.Unlock()
 
+	h.publishLocked(HubClosed, 0)
 	logger.LogPrintf("UDP监听已关闭，端口已释放: %s", h.addr)
 }
 
-func HubKey(addr string, ifaces []string) string {
-	return addr + "|" + strings.Join(ifaces, ",")
+// HubKey 生成 Hub 的唯一标识：地址|网卡列表[|本地地址]；HTTP 拉流源直接使用 URL，预览变体为 preview:频道路径；
+// 单播源为 unicast://地址[|本地地址]（不区分网卡）
+func HubKey(addr string, ifaces []string, localAddr string) string {
+	if IsHTTPSource(addr) || IsPreviewSource(addr) {
+		return addr
+	}
+	if IsUnicastSource(addr) {
+		if localAddr != "" {
+			return addr + "|" + localAddr
+		}
+		return addr
+	}
+	key := addr + "|" + strings.Join(ifaces, ",")
+	if localAddr != "" {
+		key += "|" + localAddr
+	}
+	return key
+}
+
+// hubCreation 正在创建中的 Hub 占位：创建（含加入组播的退避重试）在 HubsMu 之外进行，
+// 同一 key 的并发请求等待 done 后共享结果
+type hubCreation struct {
+	done chan struct{}
+	hub  *StreamHub
+	err  error
 }
 
-func GetOrCreateHub(udpAddr string, ifaces []string) (*StreamHub, error) {
-	key := HubKey(udpAddr, ifaces)
+// hubsCreating 正在创建的 Hub，受 HubsMu 保护
+var hubsCreating = make(map[string]*hubCreation)
 
-	HubsMu.Lock()
-	defer HubsMu.Unlock()
+// GetOrCreateHub 返回（或创建）监听指定源的 Hub，网卡列表视为请求显式指定，配置重载时不切换
+func GetOrCreateHub(udpAddr string, ifaces []string, localAddr string) (*StreamHub, error) {
+	return GetOrCreateHubWithOrigin(udpAddr, ifaces, localAddr, HubIfaceOrigin{})
+}
+
+// GetOrCreateHubWithOrigin 同 GetOrCreateHub，新建的 Hub 记录网卡来源 origin（见 ResolveIfaces）；
+// 已存在的 Hub 保持创建时的来源
+func GetOrCreateHubWithOrigin(udpAddr string, ifaces []string, localAddr string, origin HubIfaceOrigin) (*StreamHub, error) {
+	key := HubKey(udpAddr, ifaces, localAddr)
 
+	HubsMu.Lock()
 	// 检查是否已存在对应 key 的 hub
 	if hub, ok := Hubs[key]; ok {
 		select {
@@ -449,17 +1216,61 @@ func GetOrCreateHub(udpAddr string, ifaces []string) (*StreamHub, error) {
 			logger.LogPrintf("🗑️ 删除已关闭的Hub: %s", key)
 		default:
 			// 如果 hub 仍在运行，直接返回它
+			HubsMu.Unlock()
 			return hub, nil
 		}
 	}
+	// 其他请求正在创建同一个 hub，等待其结果
+	if c, ok := hubsCreating[key]; ok {
+		HubsMu.Unlock()
+		<-c.done
+		return c.hub, c.err
+	}
+	c := &hubCreation{done: make(chan struct{})}
+	hubsCreating[key] = c
+	HubsMu.Unlock()
 
-	// 创建新的 hub
-	newHub, err := NewStreamHub(udpAddr, ifaces)
-	if err != nil {
-		return nil, err
+	// 创建新的 hub：加入组播失败时的退避重试可能持续数秒，不能持有全局锁
+	c.hub, c.err = NewStreamHub(udpAddr, ifaces, localAddr)
+	if c.err == nil {
+		c.hub.SetIfaceOrigin(origin)
 	}
 
 	// 将新的 hub 插入全局映射
-	Hubs[key] = newHub
-	return newHub, nil
+	HubsMu.Lock()
+	delete(hubsCreating, key)
+	if c.err == nil {
+		Hubs[key] = c.hub
+	}
+	HubsMu.Unlock()
+	close(c.done)
+	return c.hub, c.err
+}
+
+// CloseHub 根据 HubKey 或组播地址（别名）强制关闭一个 Hub，返回被关闭的 HubKey
+// 先在 HubsMu 下摘除映射，释放全局锁后再调用 Close，避免与 Hub 自身锁产生锁序问题
+func CloseHub(keyOrAddr string) (string, bool) {
+	HubsMu.Lock()
+	key := keyOrAddr
+	hub, ok := Hubs[key]
+	if !ok {
+		for k, h := range Hubs {
+			if strings.SplitN(k, "|", 2)[0] == keyOrAddr {
+				key, hub, ok = k, h, true
+				break
+			}
+		}
+	}
+	if ok {
+		delete(Hubs, key)
+	}
+	HubsMu.Unlock()
+
+	if !ok {
+		return "", false
+	}
+
+	hub.Close()
+	logger.LogPrintf("🛑 管理员强制关闭 Hub：%s", key)
+	return key, true
 }
@@ -0,0 +1,245 @@
+package stream
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qist/tvgate/config"
+)
+
+// 加入组播的退避重试在 HubsMu 之外进行：重试期间其他 Hub 的查找不被阻塞，同一 key 的并发请求共享同一个 Hub
+func TestGetOrCreateHubRetriesOutsideHubsMu(t *testing.T) {
+	config.CfgMu.Lock()
+	savedRetries, savedDelay := config.Cfg.Stream.JoinRetries, config.Cfg.Stream.JoinRetryDelay
+	config.Cfg.Stream.JoinRetries, config.Cfg.Stream.JoinRetryDelay = 2, 200*time.Millisecond
+	config.CfgMu.Unlock()
+	defer func() {
+		config.CfgMu.Lock()
+		config.Cfg.Stream.JoinRetries, config.Cfg.Stream.JoinRetryDelay = savedRetries, savedDelay
+		config.CfgMu.Unlock()
+	}()
+	t.Cleanup(closeAllHubs)
+
+	// 网卡不存在：每次加入组播都回退为普通 UDP，按配置重试 2 次（约 600ms）
+	const addr = "239.255.77.1:0"
+	ifaces := []string{"tvgate-test-none0"}
+	var (
+		wg   sync.WaitGroup
+		hubs [2]*StreamHub
+		errs [2]error
+	)
+	for i := range hubs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hubs[i], errs[i] = GetOrCreateHub(addr, ifaces, "")
+		}(i)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	HubsMu.Lock()
+	HubsMu.Unlock()
+	if waited := time.Since(start); waited > 50*time.Millisecond {
+		t.Errorf("HubsMu held for %v during join retries", waited)
+	}
+
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("GetOrCreateHub #%d: %v", i, err)
+		}
+	}
+	if hubs[0] != hubs[1] {
+		t.Error("concurrent GetOrCreateHub returned different hubs for the same key")
+	}
+	HubsMu.Lock()
+	registered := Hubs[HubKey(addr, ifaces, "")]
+	pending := len(hubsCreating)
+	HubsMu.Unlock()
+	if registered != hubs[0] || pending != 0 {
+		t.Errorf("registered = %p, want %p; pending = %d", registered, hubs[0], pending)
+	}
+}
+
+// run 繁忙导致退订超时时，订阅方直接移除客户端，不留在 Clients 中
+func TestUnsubscribeTimeoutRemovesClient(t *testing.T) {
+	hub := newTestHub(t)
+	keep := make(chan []byte, 16)
+	if err := hub.subscribe(keep, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan []byte, 16)
+	if err := hub.subscribe(ch, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	waitClients(hub, 2)
+
+	// 持有 Mu 让 run 卡在处理退订上，并占满 RemoveCh，使本次退订超时
+	hub.Mu.Lock()
+	for len(hub.RemoveCh) < cap(hub.RemoveCh) {
+		hub.RemoveCh <- make(chan []byte)
+	}
+	stalled := true
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		stalled = false
+		hub.Mu.Unlock()
+	}()
+	hub.unsubscribe(ch, 20*time.Millisecond)
+	if stalled {
+		t.Fatal("unsubscribe returned while Mu was held")
+	}
+
+	hub.Mu.Lock()
+	_, present := hub.Clients[ch]
+	hub.Mu.Unlock()
+	if present {
+		t.Error("client still registered after unsubscribe timeout")
+	}
+	if _, ok := <-ch; ok {
+		t.Error("client channel not closed after removal")
+	}
+}
+
+// 网卡名全部无法解析：默认回退为普通 UDP 并标记 ifacesLost；strict_ifaces 下立即报错，不做 join_retries 重试
+func TestAllIfacesUnresolved(t *testing.T) {
+	config.CfgMu.Lock()
+	saved := config.Cfg.Stream
+	config.Cfg.Stream.JoinRetries, config.Cfg.Stream.JoinRetryDelay = 3, time.Second
+	config.CfgMu.Unlock()
+	defer func() {
+		config.CfgMu.Lock()
+		config.Cfg.Stream = saved
+		config.CfgMu.Unlock()
+	}()
+	ifaces := []string{"tvgate-test-none0", "tvgate-test-none1"}
+
+	// 默认模式：回退监听成功，加入组播失败仍会重试，这里只验证回退后的状态
+	config.CfgMu.Lock()
+	config.Cfg.Stream.JoinRetries = 0
+	config.CfgMu.Unlock()
+	hub, err := NewStreamHub("239.255.77.2:0", ifaces, "")
+	if err != nil {
+		t.Fatalf("NewStreamHub: %v", err)
+	}
+	hub.Mu.Lock()
+	lost, multicast := hub.ifacesLost, hub.IsMulticast
+	hub.Mu.Unlock()
+	hub.Close()
+	if !lost || multicast {
+		t.Errorf("ifacesLost = %v, IsMulticast = %v; want true, false", lost, multicast)
+	}
+
+	config.CfgMu.Lock()
+	config.Cfg.Stream.StrictIfaces, config.Cfg.Stream.JoinRetries = true, 3
+	config.CfgMu.Unlock()
+	start := time.Now()
+	conn, _, _, err := listenUDPWithRetry("239.255.77.2:0", ifaces, "")
+	if conn != nil {
+		conn.Close()
+	}
+	if !errors.Is(err, errNoIfaceResolved) {
+		t.Fatalf("err = %v, want errNoIfaceResolved", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("strict_ifaces failure took %v, want no retries", elapsed)
+	}
+	if _, err := NewStreamHub("239.255.77.2:0", ifaces, ""); !errors.Is(err, errNoIfaceResolved) {
+		t.Errorf("NewStreamHub err = %v, want errNoIfaceResolved", err)
+	}
+}
+
+// 迁移时每个客户端先收到旧 Hub 的最新帧（通道已满时在期限内等待），随后只在新 Hub 登记一次，帧序不出现缺口
+func TestTransferClientsToWithoutGap(t *testing.T) {
+	oldHub, newHub := newTestHub(t), newTestHub(t)
+
+	const before, after = 10, 10
+	ready := make(chan []byte, 64)
+	full := make(chan []byte, before) // 迁移时正好已满
+	dup := make(chan []byte, 64)      // 迁移前已在新 Hub 登记
+	for _, ch := range []chan []byte{ready, full, dup} {
+		if err := oldHub.subscribe(ch, time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := newHub.subscribe(dup, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	waitClients(oldHub, 3)
+	waitClients(newHub, 1)
+	for seq := uint32(1); seq <= before; seq++ {
+		ingest(oldHub, seq)
+	}
+
+	// 慢客户端在迁移开始后才腾出空间，仍在 transferFrameTimeout 之内
+	fullFrames := make(chan []uint32, 1)
+	go func() {
+		time.Sleep(transferFrameTimeout / 4)
+		var seqs []uint32
+		for p := range full {
+			seqs = append(seqs, frameSeq(p))
+			if frameSeq(p) == before+after {
+				break
+			}
+		}
+		fullFrames <- seqs
+	}()
+
+	oldHub.TransferClientsTo(newHub)
+	for seq := uint32(before + 1); seq <= before+after; seq++ {
+		ingest(newHub, seq)
+	}
+
+	oldHub.Mu.Lock()
+	oldCount := len(oldHub.Clients)
+	oldHub.Mu.Unlock()
+	newHub.Mu.Lock()
+	newCount := len(newHub.Clients)
+	newHub.sendMu.Lock()
+	listed := len(newHub.clientSnapshotLocked())
+	newHub.sendMu.Unlock()
+	newHub.Mu.Unlock()
+	if oldCount != 0 || newCount != 3 || listed != 3 {
+		t.Fatalf("old clients = %d, new clients = %d, snapshot = %d; want 0, 3, 3", oldCount, newCount, listed)
+	}
+
+	check := func(name string, seqs []uint32) {
+		t.Helper()
+		if len(seqs) == 0 || seqs[0] != 1 || seqs[len(seqs)-1] != before+after {
+			t.Errorf("%s: frames %v, want 1..%d", name, seqs, before+after)
+			return
+		}
+		// 衔接帧即旧 Hub 的最后一帧，应恰好多收到一次；除此之外不允许跳帧或重复
+		if len(seqs) != before+after+1 {
+			t.Errorf("%s: got %d frames, want %d (with the transfer frame): %v", name, len(seqs), before+after+1, seqs)
+			return
+		}
+		for i := 1; i < len(seqs); i++ {
+			if d := int64(seqs[i]) - int64(seqs[i-1]); d < 0 || d > 1 || (d == 0 && seqs[i] != before) {
+				t.Errorf("%s: gap or duplicate at %d: %v", name, i, seqs)
+				return
+			}
+		}
+	}
+	drain := func(ch chan []byte) (seqs []uint32) {
+		for {
+			select {
+			case p := <-ch:
+				seqs = append(seqs, frameSeq(p))
+			default:
+				return seqs
+			}
+		}
+	}
+	check("ready", drain(ready))
+	check("dup", drain(dup))
+	select {
+	case seqs := <-fullFrames:
+		check("full", seqs)
+	case <-time.After(2 * time.Second):
+		t.Fatal("full client did not receive the frames after migration")
+	}
+}
@@ -0,0 +1,46 @@
+package stream
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/logger"
+)
+
+// IsUnicastSource 判断源地址是否为显式单播监听（unicast:// 前缀）
+func IsUnicastSource(addr string) bool {
+	return strings.HasPrefix(addr, config.UnicastScheme)
+}
+
+// unicastAddr 去掉 unicast:// 前缀，返回实际监听的 ip:port
+func unicastAddr(addr string) string {
+	return strings.TrimPrefix(addr, config.UnicastScheme)
+}
+
+// listenUnicast 单播模式：只绑定端口接收推送到本机的 UDP，不加入组播；
+// localAddr 非空时绑定该地址，否则绑定地址中的 IP（0.0.0.0 或留空表示全部地址）
+func listenUnicast(udpAddr, localAddr string) (*net.UDPConn, error) {
+	addr, err := net.ResolveUDPAddr("udp", udpAddr)
+	if err != nil {
+		return nil, err
+	}
+	if localAddr != "" {
+		ip := net.ParseIP(localAddr)
+		if ip == nil {
+			return nil, fmt.Errorf("无效的本地地址: %s", localAddr)
+		}
+		addr.IP = ip
+	}
+	if addr.IP.IsMulticast() {
+		logger.LogPrintf("⚠️ 单播模式监听的 %s 是组播地址，不会加入组播组，可能收不到数据", udpAddr)
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("单播监听 %s 失败: %v", addr, err)
+	}
+	_ = conn.SetReadBuffer(8 * 1024 * 1024)
+	logger.LogPrintf("🟢 单播监听 %s 成功（不加入组播）", addr)
+	return conn, nil
+}
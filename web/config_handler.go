@@ -102,13 +102,17 @@ func (h *ConfigHandler) cookieAuth(handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 如果未启用web管理，则返回404
 		if !h.webConfig.Enabled {
-			http.NotFound(w, r)
+			monitor.WriteError(w, r, http.StatusNotFound, "404 page not found")
 			return
 		}
 
 		// 检查用户是否已认证
 		if !h.isAuthenticated(r) {
-			// 未认证用户重定向到登录页面
+			// 程序化调用（Accept: application/json）返回 401 JSON，浏览器重定向到登录页面
+			if monitor.WantsJSON(r) {
+				monitor.WriteJSONError(w, http.StatusUnauthorized, "未登录或登录已过期")
+				return
+			}
 			webPath := h.getWebPath()
 			http.Redirect(w, r, webPath+"login", http.StatusFound)
 			return
@@ -225,6 +229,28 @@ func (h *ConfigHandler) ServeMux(mux *http.ServeMux) {
 	mux.HandleFunc(webPath+"config/http", h.cookieAuth(h.handleHTTPConfig))
 	mux.HandleFunc(webPath+"config/log", h.cookieAuth(http.HandlerFunc(h.handleGetLogConfig)))
 
+	// Hub 管理接口
+	mux.HandleFunc(webPath+"hubs/close", h.cookieAuth(h.handleHubClose))
+	mux.HandleFunc(webPath+"hubs/settings", h.cookieAuth(h.handleHubSettings))
+	mux.HandleFunc(webPath+"debug/hubs", h.cookieAuth(h.handleDebugHubs))
+	mux.HandleFunc(webPath+"debug/lb", h.cookieAuth(h.handleLBPreview))
+
+	// 流量统计管理接口
+	mux.HandleFunc(webPath+"traffic/reset", h.cookieAuth(h.handleTrafficReset))
+
+	// 维护模式开关
+	mux.HandleFunc(webPath+"maintenance", h.cookieAuth(h.handleMaintenance))
+
+	// 限时签名链接生成
+	mux.HandleFunc(webPath+"signed-url", h.cookieAuth(h.handleSignedURL))
+
+	// 代理 DNS 缓存刷新接口
+	mux.HandleFunc(webPath+"proxydns/refresh", h.cookieAuth(h.handleProxyDNSRefresh))
+
+	// 按需录制接口
+	mux.HandleFunc(webPath+"recordings/start", h.cookieAuth(h.handleRecordingStart))
+	mux.HandleFunc(webPath+"recordings/stop", h.cookieAuth(h.handleRecordingStop))
+
 }
 
 // handleHome 处理功能面板页面
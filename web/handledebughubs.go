@@ -0,0 +1,29 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/qist/tvgate/monitor"
+	"github.com/qist/tvgate/stream"
+)
+
+// handleDebugHubs 导出所有 Hub 的完整内部状态（JSON），用于排查问题
+func (h *ConfigHandler) handleDebugHubs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		monitor.WriteJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	hubs := stream.DebugHubs()
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(map[string]any{
+		"time":  time.Now(),
+		"count": len(hubs),
+		"hubs":  hubs,
+	})
+}
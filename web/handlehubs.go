@@ -0,0 +1,35 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/qist/tvgate/monitor"
+	"github.com/qist/tvgate/stream"
+)
+
+// handleHubClose 强制关闭指定的组播 Hub，客户端重连后会重新创建
+func (h *ConfigHandler) handleHubClose(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		monitor.WriteJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		monitor.WriteJSONError(w, http.StatusBadRequest, "参数 key 必须提供")
+		return
+	}
+
+	closedKey, ok := stream.CloseHub(key)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !ok {
+		monitor.WriteJSONError(w, http.StatusNotFound, "未找到对应的 Hub: "+key)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "success",
+		"key":    closedKey,
+	})
+}
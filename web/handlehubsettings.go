@@ -0,0 +1,79 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qist/tvgate/monitor"
+	"github.com/qist/tvgate/stream"
+)
+
+// handleHubSettings 查看/调整频道级运行参数：
+// GET 列出运行中及已覆盖的 Hub 的生效参数；
+// POST ?key=HubKey或源地址 &client_buffer= &idle_timeout= &fast_start= [&reset=1] [&persist=1]，
+// 只影响之后加入的客户端，未指定 persist 时重启后失效
+func (h *ConfigHandler) handleHubSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(stream.ListHubSettings())
+		return
+	case http.MethodPost:
+	default:
+		monitor.WriteJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	key := strings.TrimSpace(q.Get("key"))
+	if key == "" {
+		monitor.WriteJSONError(w, http.StatusBadRequest, "参数 key 必须提供")
+		return
+	}
+	key = stream.ResolveHubKey(key)
+
+	var o stream.HubSettingsOverride
+	if s := q.Get("client_buffer"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			monitor.WriteJSONError(w, http.StatusBadRequest, "无效的 client_buffer: "+s)
+			return
+		}
+		o.ClientBuffer = &n
+	}
+	if s := q.Get("idle_timeout"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			monitor.WriteJSONError(w, http.StatusBadRequest, "无效的 idle_timeout: "+s)
+			return
+		}
+		o.IdleTimeout = &d
+	}
+	if s := q.Get("fast_start"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			monitor.WriteJSONError(w, http.StatusBadRequest, "无效的 fast_start: "+s)
+			return
+		}
+		o.FastStart = &b
+	}
+	reset := q.Get("reset") == "1" || q.Get("reset") == "true"
+	persist := q.Get("persist") == "1" || q.Get("persist") == "true"
+
+	effective, err := stream.SetHubSettings(key, o, reset, persist)
+	if err != nil {
+		monitor.WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"key":       key,
+		"effective": effective,
+		"persisted": persist,
+	})
+}
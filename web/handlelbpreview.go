@@ -0,0 +1,48 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/lb"
+	"github.com/qist/tvgate/monitor"
+)
+
+// handleLBPreview 预演代理组的选择结果：GET ?group=名称[&client_ip=][&strategy=]，只读取测速缓存，不产生流量也不修改状态
+func (h *ConfigHandler) handleLBPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		monitor.WriteJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	name := strings.TrimSpace(q.Get("group"))
+	if name == "" {
+		monitor.WriteJSONError(w, http.StatusBadRequest, "缺少 group 参数")
+		return
+	}
+	strategy := strings.TrimSpace(q.Get("strategy"))
+	switch strings.ToLower(strategy) {
+	case "", "fastest", "round-robin", "roundrobin", "least-conn", "least_conn", "leastconn":
+	default:
+		monitor.WriteJSONError(w, http.StatusBadRequest, "无效的负载均衡策略: "+strategy)
+		return
+	}
+
+	config.CfgMu.RLock()
+	group := config.Cfg.ProxyGroups[name]
+	config.CfgMu.RUnlock()
+	if group == nil {
+		monitor.WriteJSONError(w, http.StatusNotFound, "代理组不存在: "+name)
+		return
+	}
+
+	preview := lb.PreviewProxy(name, group, strategy, strings.TrimSpace(q.Get("client_ip")), time.Now())
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(preview)
+}
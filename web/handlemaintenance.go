@@ -0,0 +1,30 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/qist/tvgate/monitor"
+)
+
+// handleMaintenance GET 查看维护模式状态；POST enabled=true|false [message=...] 临时切换，
+// 配置重载时若 maintenance.enabled 发生变化会覆盖此处的切换
+func (h *ConfigHandler) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		enabled, err := strconv.ParseBool(r.FormValue("enabled"))
+		if err != nil {
+			monitor.WriteJSONError(w, http.StatusBadRequest, "enabled 参数必须为 true 或 false")
+			return
+		}
+		monitor.SetMaintenance(enabled, r.FormValue("message"), "admin")
+	default:
+		monitor.WriteJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(monitor.Maintenance())
+}
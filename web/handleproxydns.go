@@ -0,0 +1,29 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/qist/tvgate/logger"
+	"github.com/qist/tvgate/monitor"
+	"github.com/qist/tvgate/proxy"
+)
+
+// handleProxyDNSRefresh 清空代理 DNS 缓存，?host= 指定时仅刷新该主机名，下次使用时重新解析
+func (h *ConfigHandler) handleProxyDNSRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		monitor.WriteJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	host := strings.TrimSpace(r.URL.Query().Get("host"))
+	n := proxy.RefreshDNSCache(host)
+	logger.LogPrintf("🔄 代理 DNS 缓存已刷新（%d 条）", n)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"cleared": n,
+	})
+}
@@ -90,6 +90,8 @@ func (h *ConfigHandler) handleProxyGroupsConfig(w http.ResponseWriter, r *http.R
 					"ResponseTime":  proxyStats.ResponseTime,
 					"Alive":         proxyStats.Alive,
 					"FailCount":     proxyStats.FailCount,
+					"LastSuccess":   proxyStats.LastSuccess,
+					"LastFailure":   proxyStats.LastFailure,
 					"CooldownUntil": proxyStats.CooldownUntil,
 					"StatusCode":    proxyStats.StatusCode,
 				}
@@ -0,0 +1,100 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/monitor"
+	"github.com/qist/tvgate/stream"
+)
+
+// handleRecordingStart 开始录制：?channel= 频道路径，或 ?addr= 源地址（可选 ifaces、laddr）；
+// ?duration= 录制时长（如 30m），为空或超过 recording.max_duration 时按上限处理
+func (h *ConfigHandler) handleRecordingStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		monitor.WriteJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	channel := strings.TrimSuffix(strings.TrimSpace(q.Get("channel")), "/")
+	addr := strings.TrimSpace(q.Get("addr"))
+	var ifaces []string
+	if s := strings.TrimSpace(q.Get("ifaces")); s != "" {
+		ifaces = strings.Split(s, ",")
+	}
+	localAddr := strings.TrimSpace(q.Get("laddr"))
+
+	var duration time.Duration
+	if s := q.Get("duration"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			monitor.WriteJSONError(w, http.StatusBadRequest, "无效的 duration: "+s)
+			return
+		}
+		duration = d
+	}
+
+	config.CfgMu.RLock()
+	ifacesChannel := ""
+	if channel != "" {
+		addr = ""
+		for _, ch := range config.Cfg.Channels {
+			if ch != nil && ch.UDPAddr != "" && strings.TrimSuffix(ch.Path, "/") == channel {
+				addr = ch.SourceAddr()
+				ifaces, localAddr, ifacesChannel = ch.Ifaces, ch.LocalAddr, ch.Path
+				break
+			}
+		}
+	}
+	ifaces, origin := stream.ResolveIfaces(ifacesChannel, ifaces)
+	if localAddr == "" {
+		localAddr = config.Cfg.Server.MulticastLocalAddr
+	}
+	config.CfgMu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if addr == "" {
+		monitor.WriteJSONError(w, http.StatusBadRequest, "必须提供已配置的 channel 或 addr")
+		return
+	}
+
+	id, err := stream.StartRecording(channel, addr, ifaces, origin, localAddr, duration)
+	if err != nil {
+		monitor.WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "success",
+		"id":     id,
+	})
+}
+
+// handleRecordingStop 停止指定 id 的录制
+func (h *ConfigHandler) handleRecordingStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		monitor.WriteJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		monitor.WriteJSONError(w, http.StatusBadRequest, "参数 id 必须提供")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !stream.StopRecording(id) {
+		monitor.WriteJSONError(w, http.StatusNotFound, "未找到对应的录制: "+id)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "success",
+		"id":     id,
+	})
+}
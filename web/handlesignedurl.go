@@ -0,0 +1,55 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/qist/tvgate/auth"
+	"github.com/qist/tvgate/config"
+	"github.com/qist/tvgate/monitor"
+)
+
+// handleSignedURL 生成限时签名链接：GET signed-url?path=/live/cctv1[&ttl=2h]
+// path 可带查询参数（如 /udp/239.0.0.1:5000?iface=eth0），参数一并签名；ttl 缺省取 signed_url.default_ttl
+func (h *ConfigHandler) handleSignedURL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		monitor.WriteJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	config.CfgMu.RLock()
+	secret, ttl := config.Cfg.SignedURL.Secret, config.Cfg.SignedURL.DefaultTTL
+	config.CfgMu.RUnlock()
+	if secret == "" {
+		monitor.WriteJSONError(w, http.StatusConflict, "未配置 signed_url.secret")
+		return
+	}
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		monitor.WriteJSONError(w, http.StatusBadRequest, "缺少 path 参数")
+		return
+	}
+	if s := r.URL.Query().Get("ttl"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			monitor.WriteJSONError(w, http.StatusBadRequest, "ttl 格式错误，例如 30m、2h")
+			return
+		}
+		ttl = d
+	}
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+
+	expires := time.Now().Add(ttl)
+	signed, err := auth.SignURL(secret, path, expires)
+	if err != nil {
+		monitor.WriteJSONError(w, http.StatusBadRequest, "path 无效: "+err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]any{
+		"url":     signed,
+		"expires": expires.Unix(),
+	})
+}
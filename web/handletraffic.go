@@ -0,0 +1,27 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/qist/tvgate/logger"
+	"github.com/qist/tvgate/monitor"
+)
+
+// handleTrafficReset 将全局累计流量计数清零，实时带宽不受影响
+func (h *ConfigHandler) handleTrafficReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		monitor.WriteJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	resetAt := monitor.GlobalTrafficStats.ResetCounters()
+	logger.LogPrintf("🧮 累计流量计数已清零")
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":   "success",
+		"reset_at": resetAt.Format(time.RFC3339),
+	})
+}